@@ -0,0 +1,490 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+// Command goulc-http sends a request through the http/client package, with
+// the options and authenticators used by the services, and prints the
+// response.
+//
+// Usage:
+//
+//	goulc-http [flags] [URL | path]
+//
+// The status line, the headers and, on demand, the redirects and timings
+// are printed to the standard error, the body to the standard output so it
+// can be piped.
+//
+// The options of a service can be loaded from its configuration file and
+// environment, see http/client/config, the flags taking precedence. A path
+// is then resolved against the configured URL:
+//
+//	goulc-http -config billing.yaml -env BILLING -d @invoice.json /invoices
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"time"
+
+	"gitlab.com/iglou.eu/goulc/duration"
+	"gitlab.com/iglou.eu/goulc/hided"
+	"gitlab.com/iglou.eu/goulc/http/client"
+	"gitlab.com/iglou.eu/goulc/http/client/auth"
+	"gitlab.com/iglou.eu/goulc/http/client/auth/oauth2"
+	"gitlab.com/iglou.eu/goulc/http/client/config"
+)
+
+// defaultEnvPrefix is the prefix of the environment variables read when
+// -env is not set, such as GOULC_HTTP_AUTH_CLIENT_SECRET.
+const defaultEnvPrefix = "GOULC_HTTP"
+
+// secretHeaders are masked when the request is printed.
+var secretHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	stop()
+
+	if err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "goulc-http:", err)
+		}
+		os.Exit(2)
+	}
+}
+
+// command holds the parsed flags.
+type command struct {
+	configFile string
+	envPrefix  string
+
+	method string
+	header list
+	query  list
+	body   string
+
+	user   string
+	digest bool
+
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       list
+	clientAuth   string
+
+	follow        bool
+	maxRedirect   int
+	followAuth    bool
+	followReferer bool
+	onlyHTTPS     bool
+	insecure      bool
+	timeout       time.Duration
+	protocol      string
+
+	verbose bool
+	trace   bool
+	timing  bool
+	json    bool
+	debug   bool
+}
+
+// list is a flag that can be repeated.
+type list []string
+
+func (l *list) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *list) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// run sends the request described by `args` and prints the response to
+// `stdout` and `stderr`. The body is read from `stdin` with "-d @-".
+func run(
+	ctx context.Context, args []string, stdin io.Reader, stdout, stderr io.Writer,
+) error {
+	cmd, flags := newCommand(stderr)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return errors.New("expected a single URL or path")
+	}
+
+	cfg, err := config.Load(cmd.configFile, cmd.envPrefix)
+	if err != nil {
+		return err
+	}
+	flags.Visit(func(f *flag.Flag) { cmd.apply(&cfg, f.Name) })
+
+	path, query, err := target(&cfg, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	body, err := cmd.readBody(stdin)
+	if err != nil {
+		return err
+	}
+
+	level := slog.LevelWarn
+	if cmd.debug {
+		level = slog.LevelDebug
+	}
+	logger := slog.New(slog.NewTextHandler(stderr,
+		&slog.HandlerOptions{Level: level}))
+
+	opt, err := cfg.Options()
+	if err != nil {
+		return err
+	}
+
+	authenticator, closeAuth, err := cmd.authenticator(ctx, cfg, opt, logger)
+	if err != nil {
+		return err
+	}
+	defer closeAuth()
+
+	// The timings are traced on the context of the client only, the OAuth2
+	// token requests are not measured
+	var times timings
+	main, err := client.New(times.context(ctx), cfg.URL, authenticator, opt,
+		logger)
+	if err != nil {
+		return err
+	}
+	defer main.Close()
+
+	for key, value := range cfg.Header {
+		main.Header.Set(key, value)
+	}
+
+	req := main.R().Path(path).Body(body)
+	for key, values := range query {
+		for _, value := range values {
+			req = req.Query(key, value)
+		}
+	}
+	for _, param := range cmd.query {
+		key, value, _ := strings.Cut(param, "=")
+		req = req.Query(key, value)
+	}
+	for _, header := range cmd.header {
+		key, value, ok := strings.Cut(header, ":")
+		if !ok {
+			return errors.New("invalid header " + header + ", expected Name: value")
+		}
+		req = req.Header(strings.TrimSpace(key), strings.TrimSpace(value))
+	}
+
+	method := cmd.method
+	if method == "" {
+		method = http.MethodGet
+		if body != nil {
+			method = http.MethodPost
+		}
+	}
+
+	times.reset()
+	resp, err := req.Do(method, nil)
+	if err != nil {
+		return err
+	}
+
+	if cmd.verbose {
+		printRequest(stderr, resp.Request)
+	}
+	if cmd.trace {
+		printTrace(stderr, resp.Trace)
+	}
+	printResponse(stderr, resp)
+	if cmd.timing {
+		times.print(stderr, resp.ResponseTime)
+	}
+
+	return printBody(stdout, resp.Body, cmd.json)
+}
+
+// newCommand declares the flags, their defaults are the ones of
+// config.Default.
+func newCommand(stderr io.Writer) (*command, *flag.FlagSet) {
+	cmd := &command{}
+	def := config.Default()
+
+	flags := flag.NewFlagSet("goulc-http", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+
+	flags.StringVar(&cmd.configFile, "config", "",
+		"JSON or YAML client configuration file, see http/client/config")
+	flags.StringVar(&cmd.envPrefix, "env", defaultEnvPrefix,
+		"prefix of the environment variables overriding the configuration, "+
+			"empty to ignore them")
+
+	flags.StringVar(&cmd.method, "X", "",
+		"request method (default GET, or POST with a body)")
+	flags.Var(&cmd.header, "H", "request header `Name: value`, repeatable")
+	flags.Var(&cmd.query, "q", "query parameter `key=value`, repeatable")
+	flags.StringVar(&cmd.body, "d", "",
+		"request body, @file reads a file and @- the standard input")
+
+	flags.StringVar(&cmd.user, "u", "",
+		"`user:password` of the Basic authentication")
+	flags.BoolVar(&cmd.digest, "digest", false,
+		"use the Digest authentication with -u, from the server challenge")
+
+	flags.StringVar(&cmd.tokenURL, "oauth2-token-url", "",
+		"token endpoint of the OAuth2 client credentials authentication")
+	flags.StringVar(&cmd.clientID, "oauth2-client-id", "",
+		"OAuth2 client ID, the configured client_id is used when empty")
+	flags.StringVar(&cmd.clientSecret, "oauth2-client-secret", "",
+		"OAuth2 client secret, prefer the "+defaultEnvPrefix+
+			"_AUTH_CLIENT_SECRET variable, only used with its client_id")
+	flags.Var(&cmd.scopes, "oauth2-scope", "OAuth2 scope, repeatable")
+	flags.StringVar(&cmd.clientAuth, "oauth2-client-auth", "header",
+		"where the OAuth2 client credentials are sent, header or body")
+
+	flags.BoolVar(&cmd.follow, "follow", def.Follow, "follow the redirects")
+	flags.IntVar(&cmd.maxRedirect, "max-redirects", def.MaxRedirect,
+		"maximum number of redirects to follow")
+	flags.BoolVar(&cmd.followAuth, "follow-auth", def.FollowAuth,
+		"keep the Authorization header on redirects to another host")
+	flags.BoolVar(&cmd.followReferer, "follow-referer", def.FollowReferer,
+		"keep the Referer header on redirects")
+	flags.BoolVar(&cmd.onlyHTTPS, "only-https", def.OnlyHTTPS,
+		"upgrade the http URLs to https")
+	flags.BoolVar(&cmd.insecure, "k", def.DisableTLSVerify,
+		"skip the TLS certificate verification")
+	flags.DurationVar(&cmd.timeout, "timeout",
+		def.Timeout.Duration, "timeout of the whole request")
+	flags.StringVar(&cmd.protocol, "proto", def.Protocol,
+		"HTTP protocol: auto, http/1.1, prefer-h2, h2 or h2c")
+
+	flags.BoolVar(&cmd.verbose, "v", false,
+		"print the request line and headers, the secrets masked")
+	flags.BoolVar(&cmd.trace, "trace", false, "print the redirects")
+	flags.BoolVar(&cmd.timing, "timing", false,
+		"print the DNS, connection, TLS and first byte timings")
+	flags.BoolVar(&cmd.json, "json", false, "pretty-print a JSON body")
+	flags.BoolVar(&cmd.debug, "debug", false, "print the client debug logs")
+
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: goulc-http [flags] [URL | path]")
+		flags.PrintDefaults()
+	}
+
+	return cmd, flags
+}
+
+// apply sets the configuration field of the flag `name`, it is only called
+// for the flags set on the command line.
+func (cmd *command) apply(cfg *config.Config, name string) {
+	switch name {
+	case "follow":
+		cfg.Follow = cmd.follow
+	case "max-redirects":
+		cfg.MaxRedirect = cmd.maxRedirect
+	case "follow-auth":
+		cfg.FollowAuth = cmd.followAuth
+	case "follow-referer":
+		cfg.FollowReferer = cmd.followReferer
+	case "only-https":
+		cfg.OnlyHTTPS = cmd.onlyHTTPS
+	case "k":
+		cfg.DisableTLSVerify = cmd.insecure
+	case "timeout":
+		cfg.Timeout = duration.Duration{Duration: cmd.timeout}
+	case "proto":
+		cfg.Protocol = cmd.protocol
+	case "u":
+		user, password, _ := strings.Cut(cmd.user, ":")
+		cfg.Auth = &config.Auth{
+			Type:     config.AuthBasic,
+			Username: user,
+			Password: hided.String(password),
+		}
+		if cmd.digest {
+			cfg.Auth.Type = config.AuthDigest
+		}
+	}
+}
+
+// target sets the URL of the configuration from the command argument, or
+// returns it as a path relative to the configured URL, with its query.
+func target(cfg *config.Config, arg string) (string, url.Values, error) {
+	if arg == "" {
+		if cfg.URL == "" {
+			return "", nil, errors.New("expected a URL")
+		}
+		return "", nil, nil
+	}
+
+	u, err := url.Parse(arg)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if u.IsAbs() || cfg.URL == "" {
+		cfg.URL = arg
+		return "", nil, nil
+	}
+
+	return u.Path, u.Query(), nil
+}
+
+// readBody returns the body of the -d flag, nil when not set.
+func (cmd *command) readBody(stdin io.Reader) ([]byte, error) {
+	switch {
+	case cmd.body == "":
+		return nil, nil
+	case cmd.body == "@-":
+		return io.ReadAll(stdin)
+	case strings.HasPrefix(cmd.body, "@"):
+		return os.ReadFile(cmd.body[1:])
+	}
+
+	return []byte(cmd.body), nil
+}
+
+// authenticator builds the authenticator of the flags, or of the
+// configuration when none is set. The OAuth2 token is requested with the
+// options of the command, and the Digest one answers the server challenge.
+// The returned function closes the client of the token requests.
+func (cmd *command) authenticator(
+	ctx context.Context, cfg config.Config, opt *client.Options,
+	logger *slog.Logger,
+) (auth.Authenticator, func(), error) {
+	noop := func() {}
+
+	if cmd.tokenURL == "" {
+		if cmd.digest && (cfg.Auth == nil || cfg.Auth.Type != config.AuthDigest) {
+			return nil, noop, errors.New("-digest requires -u user:password")
+		}
+
+		authenticator, err := cfg.Authenticator(logger)
+		return authenticator, noop, err
+	}
+
+	if cmd.user != "" {
+		return nil, noop, errors.New("-u and -oauth2-token-url are exclusive")
+	}
+
+	clientAuth := oauth2.ClientInHeader
+	switch cmd.clientAuth {
+	case "header":
+	case "body":
+		clientAuth = oauth2.ClientInBody
+	default:
+		return nil, noop, errors.New("unknown OAuth2 client auth " + cmd.clientAuth)
+	}
+
+	token, err := client.New(ctx, cmd.tokenURL, nil, opt,
+		logger.WithGroup("oauth2"))
+	if err != nil {
+		return nil, noop, err
+	}
+	closeToken := func() { token.Close() }
+
+	// The configured credentials are used together, the configured secret
+	// is never sent with the ID of another client
+	id, secret := cmd.clientID, hided.String(cmd.clientSecret)
+	if cfg.Auth != nil && (id == "" || id == cfg.Auth.ClientID) {
+		id = cfg.Auth.ClientID
+		if secret.IsEmpty() {
+			secret = cfg.Auth.ClientSecret
+		}
+	}
+
+	authenticator, err := oauth2.NewClientCredentials(clientAuth, oauth2.Config{
+		ClientID:     id,
+		ClientSecret: secret,
+		Scopes:       cmd.scopes,
+		Endpoint:     oauth2.Endpoint{URL: cmd.tokenURL},
+	}, logger, &token)
+	if err != nil {
+		closeToken()
+		return nil, noop, err
+	}
+
+	return authenticator, closeToken, nil
+}
+
+// printRequest prints the request line and headers, the secrets masked.
+func printRequest(w io.Writer, req *http.Request) {
+	fmt.Fprintln(w, ">", req.Method, req.URL.String(), req.Proto)
+	for _, key := range slices.Sorted(maps.Keys(req.Header)) {
+		for _, value := range req.Header[key] {
+			if slices.Contains(secretHeaders, key) {
+				value = hided.String(value).String()
+			}
+			fmt.Fprintf(w, "> %s: %s\n", key, value)
+		}
+	}
+	fmt.Fprintln(w, ">")
+}
+
+// printTrace prints the redirects followed.
+func printTrace(w io.Writer, trace []client.Redirects) {
+	for _, redirect := range trace {
+		fmt.Fprintf(w, "* %s %s redirected to %s\n",
+			redirect.Timestamp.Format("15:04:05.000"), redirect.Status,
+			redirect.URL)
+	}
+}
+
+// printResponse prints the status line and headers.
+func printResponse(w io.Writer, resp *client.Response) {
+	fmt.Fprintln(w, "<", resp.Proto, resp.Status)
+	for _, key := range slices.Sorted(maps.Keys(resp.Header)) {
+		for _, value := range resp.Header[key] {
+			fmt.Fprintf(w, "< %s: %s\n", key, value)
+		}
+	}
+	fmt.Fprintln(w, "<")
+}
+
+// printBody prints the body, indented when it is JSON and `pretty` is set.
+func printBody(w io.Writer, body []byte, pretty bool) error {
+	if pretty && json.Valid(body) {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, body, "", "  "); err != nil {
+			return err
+		}
+		indented.WriteByte('\n')
+		body = indented.Bytes()
+	}
+
+	_, err := w.Write(body)
+	return err
+}
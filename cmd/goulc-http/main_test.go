@@ -0,0 +1,257 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"gitlab.com/iglou.eu/goulc/http/client"
+	"gitlab.com/iglou.eu/goulc/http/client/config"
+)
+
+// envPrefix isolates the tests from the environment of the runner.
+const envPrefix = "GOULC_HTTP_TEST"
+
+func TestRun(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/v1/pets?"+r.URL.RawQuery, http.StatusTemporaryRedirect)
+	})
+	mux.HandleFunc("/v1/pets", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPut || r.Header.Get("X-Vault") != "13" ||
+			r.URL.Query().Get("kind") != "dog" || r.URL.Query().Get("name") != "Dogmeat" ||
+			string(body) != `{"name":"Dogmeat"}` {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":1,"name":"Dogmeat"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	var stdout, stderr bytes.Buffer
+	err := run(context.Background(), []string{
+		"-env", envPrefix, "-only-https=false", "-max-redirects", "3",
+		"-X", "PUT", "-H", "X-Vault: 13", "-q", "name=Dogmeat", "-d", "@-",
+		"-json", "-trace", "-timing", "-v",
+		server.URL + "/old?kind=dog",
+	}, strings.NewReader(`{"name":"Dogmeat"}`), &stdout, &stderr)
+	if err != nil {
+		t.Fatalf("run() error = %v, stderr = %s", err, stderr.String())
+	}
+
+	if want := "{\n  \"id\": 1,\n  \"name\": \"Dogmeat\"\n}\n"; stdout.String() != want {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), want)
+	}
+	for _, want := range []string{
+		"> PUT " + server.URL + "/v1/pets?",
+		"> X-Vault: 13\n",
+		"307 Temporary Redirect redirected to " + server.URL + "/v1/pets?",
+		"< HTTP/1.1 200 OK\n",
+		"< Content-Type: application/json\n",
+		"* TCP connection: ",
+		"* total: ",
+	} {
+		if !strings.Contains(stderr.String(), want) {
+			t.Errorf("run() stderr = %s, missing %q", stderr.String(), want)
+		}
+	}
+}
+
+func TestRun_Auth(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/basic", func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok ||
+			user != "minsc" || password != "boo" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+	mux.HandleFunc("/digest", func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		if !strings.HasPrefix(authorization, "Digest ") {
+			w.Header().Set("WWW-Authenticate", `Basic realm="vault"`)
+			w.Header().Add("WWW-Authenticate",
+				`Digest realm="vault", qop="auth", algorithm=SHA-256, nonce="abc"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		for _, want := range []string{
+			`uri="/digest"`, `username="minsc"`, `nonce="abc"`, "qop=auth", "nc=00000001",
+		} {
+			if !strings.Contains(authorization, want) {
+				w.WriteHeader(http.StatusForbidden)
+			}
+		}
+	})
+	var leaked atomic.Bool
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != "overseer" && secret == "vault-13" {
+			leaked.Store(true)
+		}
+		if id != "overseer" || secret != "vault-13" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"water-chip","token_type":"bearer","expires_in":3600}`))
+	})
+	mux.HandleFunc("/oauth2", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer water-chip" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	t.Setenv(envPrefix+"_AUTH_CLIENT_ID", "overseer")
+	t.Setenv(envPrefix+"_AUTH_CLIENT_SECRET", "vault-13")
+
+	for name, args := range map[string][]string{
+		"basic":  {"-u", "minsc:boo", server.URL + "/basic"},
+		"digest": {"-u", "minsc:boo", "-digest", server.URL + "/digest"},
+		"oauth2": {
+			"-oauth2-token-url", server.URL + "/token",
+			"-oauth2-client-id", "overseer", server.URL + "/oauth2",
+		},
+		"oauth2 configured client": {
+			"-oauth2-token-url", server.URL + "/token", server.URL + "/oauth2",
+		},
+	} {
+		var stdout, stderr bytes.Buffer
+		args = append([]string{"-env", envPrefix, "-only-https=false", "-v"}, args...)
+		if err := run(context.Background(), args, nil, &stdout, &stderr); err != nil {
+			t.Fatalf("run() %s: error = %v", name, err)
+		}
+		if !strings.Contains(stderr.String(), "< HTTP/1.1 200 OK\n") ||
+			!strings.Contains(stderr.String(), "> Authorization: ***\n") {
+			t.Errorf("run() %s: stderr = %s", name, stderr.String())
+		}
+	}
+
+	// The configured secret is not sent with another client ID
+	var stdout, stderr bytes.Buffer
+	if err := run(context.Background(), []string{
+		"-env", envPrefix, "-only-https=false", "-oauth2-token-url", server.URL + "/token",
+		"-oauth2-client-id", "minsc", server.URL + "/oauth2",
+	}, nil, &stdout, &stderr); err == nil || leaked.Load() {
+		t.Errorf("run() oauth2 other client: error = %v, secret leaked = %v", err, leaked.Load())
+	}
+}
+
+func TestRun_Config(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path + " " + r.Header.Get("User-Agent") + " " + r.URL.RawQuery))
+	}))
+	defer server.Close()
+
+	file := filepath.Join(t.TempDir(), "client.yaml")
+	if err := os.WriteFile(file, []byte("url: "+server.URL+"/v1\n"+
+		"only_https: false\nheader:\n  User-Agent: vault-tec/1.0\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// A path is resolved against the configured URL
+	var stdout, stderr bytes.Buffer
+	if err := run(context.Background(), []string{
+		"-config", file, "-env", envPrefix, "/pets?limit=2",
+	}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if want := "/v1/pets vault-tec/1.0 limit=2"; stdout.String() != want {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), want)
+	}
+
+	// The environment overrides the file
+	t.Setenv(envPrefix+"_HEADER", "User-Agent=pip-boy/3000")
+	stdout.Reset()
+	if err := run(context.Background(), []string{
+		"-config", file, "-env", envPrefix,
+	}, nil, &stdout, &stderr); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if want := "/v1 pip-boy/3000 "; stdout.String() != want {
+		t.Errorf("run() stdout = %q, want %q", stdout.String(), want)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	for name, args := range map[string][]string{
+		"no URL":          {},
+		"two URLs":        {"https://a.example", "https://b.example"},
+		"unknown flag":    {"-curl", "https://a.example"},
+		"invalid header":  {"-H", "X-Vault", "https://a.example"},
+		"digest without":  {"-digest", "https://a.example"},
+		"exclusive auth":  {"-u", "a:b", "-oauth2-token-url", "https://auth.example", "https://a.example"},
+		"client auth":     {"-oauth2-token-url", "https://auth.example", "-oauth2-client-auth", "cookie", "https://a.example"},
+		"invalid proto":   {"-proto", "h3", "https://a.example"},
+		"missing body":    {"-d", "@missing.json", "https://a.example"},
+		"missing config":  {"-config", "missing.yaml", "https://a.example"},
+		"invalid timeout": {"-timeout", "-1s", "https://a.example"},
+	} {
+		var stdout, stderr bytes.Buffer
+		args = append([]string{"-env", envPrefix}, args...)
+		if err := run(context.Background(), args, nil, &stdout, &stderr); err == nil {
+			t.Errorf("run() %s: expected an error", name)
+		}
+	}
+}
+
+func TestCommand_AuthenticatorClose(t *testing.T) {
+	var tokens atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"water-chip","token_type":"bearer","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	cmd, flags := newCommand(io.Discard)
+	if err := flags.Parse([]string{
+		"-oauth2-token-url", server.URL, "-oauth2-client-id", "overseer",
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	authenticator, closeAuth, err := cmd.authenticator(context.Background(),
+		config.Default(), &client.OptDefault, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatalf("authenticator() error = %v", err)
+	}
+
+	// The token client is closed with the command, no token is requested
+	closeAuth()
+	if err := authenticator.Update(); !errors.Is(err, client.ErrClientClosed) || tokens.Load() != 0 {
+		t.Errorf("Update() error = %v after %d token requests, want %v", err, tokens.Load(), client.ErrClientClosed)
+	}
+}
@@ -0,0 +1,123 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// timings measures the phases of a request with an httptrace.ClientTrace.
+// The phases of the redirects add up, the first byte is the one of the
+// first response.
+type timings struct {
+	mu sync.Mutex
+
+	start                            time.Time
+	dnsStart, connectStart, tlsStart time.Time
+	dns, connect, tls, firstByte     time.Duration
+	connections, reusedConnections   int
+}
+
+// context returns `ctx` tracing the requests into the timings.
+func (t *timings) context(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.begin(&t.dnsStart)
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.end(&t.dns, &t.dnsStart)
+		},
+		ConnectStart: func(_, _ string) {
+			t.begin(&t.connectStart)
+		},
+		ConnectDone: func(_, _ string, _ error) {
+			t.end(&t.connect, &t.connectStart)
+		},
+		TLSHandshakeStart: func() {
+			t.begin(&t.tlsStart)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.end(&t.tls, &t.tlsStart)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+
+			t.connections++
+			if info.Reused {
+				t.reusedConnections++
+			}
+		},
+		GotFirstResponseByte: func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+
+			if t.firstByte == 0 {
+				t.firstByte = time.Since(t.start)
+			}
+		},
+	})
+}
+
+// reset clears the timings before a new request.
+func (t *timings) reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.start = time.Now()
+	t.dns, t.connect, t.tls, t.firstByte = 0, 0, 0, 0
+	t.connections, t.reusedConnections = 0, 0
+}
+
+// begin records the start of a phase.
+func (t *timings) begin(start *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	*start = time.Now()
+}
+
+// end adds the duration of a phase started at `start`.
+func (t *timings) end(total *time.Duration, start *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	*total += time.Since(*start)
+}
+
+// print prints the timings and the `total` response time.
+func (t *timings) print(w io.Writer, total time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	fmt.Fprintf(w, "* connections:    %d (%d reused)\n",
+		t.connections, t.reusedConnections)
+	fmt.Fprintf(w, "* DNS lookup:     %s\n", t.dns)
+	fmt.Fprintf(w, "* TCP connection: %s\n", t.connect)
+	fmt.Fprintf(w, "* TLS handshake:  %s\n", t.tls)
+	fmt.Fprintf(w, "* first byte:     %s\n", t.firstByte)
+	fmt.Fprintf(w, "* total:          %s\n", total)
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+// Command goulc-openapi generates a typed Go client on top of the
+// http/client package from an OpenAPI 3.x document, in YAML when its
+// extension is .yaml or .yml and in JSON otherwise.
+//
+// Usage:
+//
+//	goulc-openapi [-package name] [-type name] [-o file] document.{json,yaml}
+//
+// The package name defaults to the name of the output directory. It is
+// meant to be run by go generate:
+//
+//	//go:generate go run gitlab.com/iglou.eu/goulc/cmd/goulc-openapi -o petstore.go petstore.json
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"gitlab.com/iglou.eu/goulc/http/openapi"
+)
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "goulc-openapi:", err)
+		}
+		os.Exit(2)
+	}
+}
+
+// run generates the client of the document given in `args`, to the output
+// file or to `stdout`.
+func run(args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("goulc-openapi", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	pkg := flags.String("package", "",
+		"name of the generated package (default the output directory name)")
+	typeName := flags.String("type", openapi.DefaultTypeName,
+		"name of the generated client type")
+	output := flags.String("o", "",
+		"output file (default the standard output)")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: goulc-openapi [-package name] [-type name] [-o file] document.{json,yaml}")
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errors.New("expected a single OpenAPI document")
+	}
+
+	data, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	parse := openapi.Parse
+	switch strings.ToLower(filepath.Ext(flags.Arg(0))) {
+	case ".yaml", ".yml":
+		parse = openapi.ParseYAML
+	}
+
+	doc, err := parse(data)
+	if err != nil {
+		return err
+	}
+
+	if *pkg == "" {
+		if *pkg, err = packageName(*output); err != nil {
+			return err
+		}
+	}
+
+	src, err := openapi.Generate(doc, openapi.Config{
+		Package:  *pkg,
+		TypeName: *typeName,
+		Source:   filepath.Base(flags.Arg(0)),
+	})
+	if err != nil {
+		return err
+	}
+
+	if *output == "" {
+		_, err = stdout.Write(src)
+		return err
+	}
+
+	return os.WriteFile(*output, src, 0o644)
+}
+
+// packageName returns the package name derived from the directory of the
+// output file, the letters and digits of its name in lower case.
+func packageName(output string) (string, error) {
+	dir, err := filepath.Abs(filepath.Dir(output))
+	if err != nil {
+		return "", err
+	}
+
+	name := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, filepath.Base(dir))
+
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		return "", errors.New("cannot derive the package name from " + dir + ", use -package")
+	}
+
+	return name, nil
+}
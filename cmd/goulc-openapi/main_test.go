@@ -0,0 +1,89 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	document := filepath.Join("..", "..", "http", "openapi", "testdata", "petstore.json")
+	dir := filepath.Join(t.TempDir(), "pet-store")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	// The package name is derived from the output directory
+	output := filepath.Join(dir, "client.go")
+	var stdout, stderr bytes.Buffer
+	if err := run([]string{"-o", output, "-type", "API", document}, &stdout, &stderr); err != nil {
+		t.Fatalf("run() error = %v, stderr = %s", err, stderr.String())
+	}
+
+	src, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(src), "package petstore\n") ||
+		!strings.Contains(string(src), "func (api *API) ListPets(") || stdout.Len() != 0 {
+		t.Errorf("run() generated %d bytes, stdout %d bytes", len(src), stdout.Len())
+	}
+
+	// Without output file, the code is written on stdout
+	if err := run([]string{"-package", "zoo", document}, &stdout, &stderr); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if !strings.HasPrefix(stdout.String(), "// Code generated by goulc-openapi. DO NOT EDIT.") ||
+		!strings.Contains(stdout.String(), "package zoo\n") {
+		t.Errorf("run() stdout = %.100s", stdout.String())
+	}
+
+	// A YAML document generates the same client, JSON being valid YAML
+	data, err := os.ReadFile(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+	yamlDocument := filepath.Join(dir, "petstore.yml")
+	if err := os.WriteFile(yamlDocument, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var yamlStdout bytes.Buffer
+	if err := run([]string{"-package", "zoo", yamlDocument}, &yamlStdout, &stderr); err != nil {
+		t.Fatalf("run() error = %v", err)
+	}
+	if got := strings.ReplaceAll(yamlStdout.String(), "petstore.yml", "petstore.json"); got != stdout.String() {
+		t.Errorf("run() YAML document generated a different client")
+	}
+
+	for name, args := range map[string][]string{
+		"no document":      {},
+		"missing document": {"-package", "zoo", "missing.json"},
+		"invalid package":  {"-package", "a-b", document},
+		"unknown flag":     {"-yaml", document},
+	} {
+		if err := run(args, &stdout, &stderr); err == nil {
+			t.Errorf("run() %s: expected an error", name)
+		}
+	}
+}
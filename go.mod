@@ -1,8 +1,10 @@
 module gitlab.com/iglou.eu/goulc
 
-go 1.23.4
+go 1.24
 
 require (
+	go.yaml.in/yaml/v3 v3.0.5
+	golang.org/x/net v0.38.0
 	golang.org/x/time v0.11.0
 	gorm.io/driver/sqlite v1.5.7
 	gorm.io/gorm v1.25.12
@@ -12,5 +14,5 @@ require (
 	github.com/jinzhu/inflection v1.0.0 // indirect
 	github.com/jinzhu/now v1.1.5 // indirect
 	github.com/mattn/go-sqlite3 v1.14.22 // indirect
-	golang.org/x/text v0.22.0 // indirect
+	golang.org/x/text v0.23.0 // indirect
 )
@@ -0,0 +1,86 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+package auth
+
+import (
+	"net/http"
+	"net/url"
+
+	"gitlab.com/iglou.eu/goulc/hided"
+)
+
+const (
+	// APIKeyName is the identifier for this authentication method
+	APIKeyName = "auth.APIKey"
+)
+
+// Verify APIKey implements Authenticator interface
+var _ Authenticator = &APIKey{}
+
+// APIKey struct implements the Authenticator interface with a static key
+// sent in a custom header, such as "X-API-Key".
+type APIKey struct {
+	// HeaderName is the canonical name of the header carrying the key
+	HeaderName string
+	// Key is the API key in hidden mode
+	Key hided.String
+}
+
+// NewAPIKey creates a new APIKey authentication instance sending `key` in
+// the `header` header. It returns an error if the header or the key is
+// empty.
+func NewAPIKey(header string, key hided.String) (APIKey, error) {
+	if header == "" {
+		return APIKey{}, ErrNoHeaderName
+	}
+
+	if key.Value() == hided.String("").Value() {
+		return APIKey{}, ErrNoToken
+	}
+
+	return APIKey{
+		HeaderName: http.CanonicalHeaderKey(header),
+		Key:        key,
+	}, nil
+}
+
+// Name returns the identifier for this authentication method.
+func (_ *APIKey) Name() string {
+	return APIKeyName
+}
+
+// Update implements the Authenticator interface.
+// This method is a no-op as the key is static.
+func (_ *APIKey) Update() error {
+	return nil
+}
+
+// Header return the configured Header name and the key.
+func (a *APIKey) Header(_ string, _ *url.URL, _ []byte,
+) (headerKey, headerValue string, err error) {
+	return a.HeaderName, a.Key.Value().(string), nil
+}
+
+// Clone creates a deep copy of the instance.
+func (a *APIKey) Clone() Authenticator {
+	return &APIKey{
+		HeaderName: a.HeaderName,
+		Key:        a.Key,
+	}
+}
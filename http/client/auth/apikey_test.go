@@ -0,0 +1,66 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+package auth_test
+
+import (
+	"net/http"
+	"testing"
+
+	"gitlab.com/iglou.eu/goulc/hided"
+	"gitlab.com/iglou.eu/goulc/http/client/auth"
+)
+
+func TestNewAPIKey(t *testing.T) {
+	tests := []struct {
+		name        string
+		header      string
+		key         hided.String
+		expectedErr error
+	}{
+		{name: "valid key", header: "x-api-key", key: "ultra-violence"},
+		{name: "empty header", header: "", key: "ultra-violence", expectedErr: auth.ErrNoHeaderName},
+		{name: "empty key", header: "X-API-Key", key: "", expectedErr: auth.ErrNoToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := auth.NewAPIKey(tt.header, tt.key)
+			if err != tt.expectedErr {
+				t.Fatalf("NewAPIKey() error = %v, want %v", err, tt.expectedErr)
+			}
+			if tt.expectedErr != nil {
+				return
+			}
+
+			name, value, err := got.Header(http.MethodGet, nil, nil)
+			if err != nil || name != "X-Api-Key" || value != "ultra-violence" {
+				t.Errorf("APIKey.Header() = %q, %q, %v", name, value, err)
+			}
+
+			cloned := got.Clone().(*auth.APIKey)
+			if cloned == &got || *cloned != got {
+				t.Errorf("Clone() = %+v, want a copy of %+v", cloned, got)
+			}
+
+			if got.Name() != auth.APIKeyName || got.Update() != nil {
+				t.Errorf("APIKey.Name() = %v, want %v", got.Name(), auth.APIKeyName)
+			}
+		})
+	}
+}
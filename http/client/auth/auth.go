@@ -24,6 +24,7 @@
 package auth
 
 import (
+	"net/http"
 	net_url "net/url"
 )
 
@@ -43,3 +44,28 @@ type Authenticator interface {
 	// Clone creates and returns a copy of the authenticator.
 	Clone() Authenticator
 }
+
+// HeadersAuthenticator extends Authenticator for the mechanisms setting
+// several headers or signing the request headers, such as AWS SigV4.
+// When implemented, the client uses Headers instead of Header.
+type HeadersAuthenticator interface {
+	Authenticator
+
+	// Headers generates the authentication headers based on the provided
+	// method, URL, request headers and body. The request headers must not
+	// be modified, the returned ones are set on the request.
+	Headers(method string, url *net_url.URL, header http.Header,
+		body []byte) (http.Header, error)
+}
+
+// ChallengeAuthenticator extends Authenticator for the mechanisms answering
+// the challenges of the server, such as Digest. When implemented, the
+// client gives the headers of an unauthorized response to Challenge and
+// sends the request once more if it returns true.
+type ChallengeAuthenticator interface {
+	HeadersAuthenticator
+
+	// Challenge updates the authenticator from the headers of a 401
+	// response. It reports if the request must be sent again.
+	Challenge(header http.Header) (bool, error)
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+package auth
+
+import (
+	"net/url"
+
+	"gitlab.com/iglou.eu/goulc/hided"
+)
+
+const (
+	// BearerName is the identifier for this authentication method
+	BearerName = "auth.Bearer"
+	// BearerHeaderName is the HTTP header name for authentication
+	BearerHeaderName = "Authorization"
+	// BearerValuePrefix is the prefix for the bearer authentication value
+	BearerValuePrefix = "Bearer "
+)
+
+// Verify Bearer implements Authenticator interface
+var _ Authenticator = &Bearer{}
+
+// Bearer struct implements the Authenticator interface with a static
+// bearer token, such as a personal access token or a JWT issued out of band.
+// RFC 6750 §2.1: https://www.rfc-editor.org/rfc/rfc6750#section-2.1
+type Bearer struct {
+	// Token is the bearer token in hidden mode
+	Token hided.String
+}
+
+// NewBearer creates a new Bearer authentication instance with the provided
+// token. It returns an error if the token is empty.
+func NewBearer(token hided.String) (Bearer, error) {
+	if token.Value() == hided.String("").Value() {
+		return Bearer{}, ErrNoToken
+	}
+
+	return Bearer{Token: token}, nil
+}
+
+// Name returns the identifier for this authentication method.
+func (_ *Bearer) Name() string {
+	return BearerName
+}
+
+// Update implements the Authenticator interface.
+// This method is a no-op as the token is static.
+func (_ *Bearer) Update() error {
+	return nil
+}
+
+// Header return the Header name and Header line with prefix and token.
+// RFC 6750 §2.1: https://www.rfc-editor.org/rfc/rfc6750#section-2.1
+func (b *Bearer) Header(_ string, _ *url.URL, _ []byte,
+) (headerKey, headerValue string, err error) {
+	return BearerHeaderName, BearerValuePrefix + b.Token.Value().(string), nil
+}
+
+// Clone creates a deep copy of the instance.
+func (b *Bearer) Clone() Authenticator {
+	return &Bearer{Token: b.Token}
+}
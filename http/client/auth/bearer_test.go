@@ -0,0 +1,52 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+package auth_test
+
+import (
+	"net/http"
+	"testing"
+
+	"gitlab.com/iglou.eu/goulc/hided"
+	"gitlab.com/iglou.eu/goulc/http/client/auth"
+)
+
+func TestNewBearer(t *testing.T) {
+	if _, err := auth.NewBearer(""); err != auth.ErrNoToken {
+		t.Errorf("NewBearer() error = %v, want %v", err, auth.ErrNoToken)
+	}
+
+	bearer, err := auth.NewBearer(hided.String("nightmare"))
+	if err != nil {
+		t.Fatalf("NewBearer() error = %v", err)
+	}
+
+	name, value, err := bearer.Header(http.MethodGet, nil, nil)
+	if err != nil || name != auth.BearerHeaderName || value != "Bearer nightmare" {
+		t.Errorf("Bearer.Header() = %q, %q, %v", name, value, err)
+	}
+
+	cloned := bearer.Clone().(*auth.Bearer)
+	if cloned == &bearer || cloned.Token != bearer.Token {
+		t.Errorf("Clone() = %+v, want a copy of %+v", cloned, bearer)
+	}
+
+	if bearer.Name() != auth.BearerName || bearer.Update() != nil {
+		t.Errorf("Bearer.Name() = %v, want %v", bearer.Name(), auth.BearerName)
+	}
+}
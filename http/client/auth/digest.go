@@ -24,6 +24,7 @@ import (
 	"crypto/sha256"
 	"crypto/sha512"
 	"encoding/hex"
+	"errors"
 	"net/url"
 	"strconv"
 	"strings"
@@ -46,6 +47,8 @@ const (
 	DigestHeaderName = "Authorization"
 	// DigestValuePrefix is the prefix for the digest authentication value
 	DigestValuePrefix = "Digest "
+	// DigestChallengeHeaderName is the HTTP header name of the challenge
+	DigestChallengeHeaderName = "WWW-Authenticate"
 
 	// Quality of Protection (QOP) options
 
@@ -216,6 +219,114 @@ func NewDigest(
 	}, nil
 }
 
+// ParseDigestChallenge parses the value of a WWW-Authenticate header holding
+// a Digest challenge, as defined in RFC7616 Section 3.3
+// at https://datatracker.ietf.org/doc/html/rfc7616#section-3.3
+//
+// The returned parameters hold the realm, nonce, opaque, algorithm and
+// userhash of the challenge. The MD5 algorithm is used when none is given,
+// and the "auth" quality of protection is preferred when both are offered.
+// The URI, CNonce and NC must be set by the caller before NewDigest.
+//
+// A header listing several challenges must be split by the caller, the
+// value must start with the Digest scheme.
+func ParseDigestChallenge(challenge string) (DigestParameters, error) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	if !strings.EqualFold(scheme, strings.TrimSpace(DigestValuePrefix)) {
+		return DigestParameters{}, errors.Join(ErrInvalidChallenge,
+			errors.New("not a digest challenge: "+scheme))
+	}
+
+	values, err := parseAuthParams(rest)
+	if err != nil {
+		return DigestParameters{}, errors.Join(ErrInvalidChallenge, err)
+	}
+
+	params := DigestParameters{
+		Algorithm: DigestMD5,
+		Realm:     values["realm"],
+		Nonce:     values["nonce"],
+		Opaque:    values["opaque"],
+		UserHash:  strings.EqualFold(values["userhash"], "true"),
+	}
+
+	if algorithm, ok := values["algorithm"]; ok {
+		params.Algorithm = DigestAlgo(strings.ToLower(algorithm))
+	}
+
+	if qop, ok := values["qop"]; ok {
+		for option := range strings.SplitSeq(qop, ",") {
+			option = strings.ToLower(strings.TrimSpace(option))
+			if option == DigestQOPAuth ||
+				(option == DigestQOPAuthInt && params.QOP == "") {
+				params.QOP = option
+			}
+		}
+
+		if params.QOP == "" {
+			return DigestParameters{}, errors.Join(ErrInvalidChallenge,
+				errors.New("unsupported qop "+qop))
+		}
+	}
+
+	if params.Hash([]byte("")) == ErrUnknownAlgorithm.Error() {
+		return DigestParameters{}, ErrUnknownAlgorithm
+	}
+
+	if params.Realm == "" {
+		return DigestParameters{}, ErrNoRealm
+	}
+
+	if params.Nonce == "" {
+		return DigestParameters{}, ErrNoNonce
+	}
+
+	return params, nil
+}
+
+// parseAuthParams parses the comma-separated auth-params of a challenge,
+// as defined in RFC9110 Section 11.2
+// at https://datatracker.ietf.org/doc/html/rfc9110#section-11.2
+// The keys are lower-cased and the quoted values unescaped.
+func parseAuthParams(s string) (map[string]string, error) {
+	values := make(map[string]string)
+
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if s == "" {
+			return values, nil
+		}
+
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			return nil, errors.New("missing value of " + key)
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		rest = strings.TrimLeft(rest, " \t")
+
+		var value strings.Builder
+		if strings.HasPrefix(rest, `"`) {
+			i := 1
+			for ; i < len(rest) && rest[i] != '"'; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+				}
+				value.WriteByte(rest[i])
+			}
+			if i == len(rest) {
+				return nil, errors.New("unterminated value of " + key)
+			}
+			s = rest[i+1:]
+		} else {
+			token, next, _ := strings.Cut(rest, ",")
+			value.WriteString(strings.TrimSpace(token))
+			s = next
+		}
+
+		values[key] = value.String()
+	}
+}
+
 // Name returns the identifier for this authentication method.
 func (_ *Digest) Name() string {
 	return DigestName
@@ -1,6 +1,7 @@
 package auth_test
 
 import (
+	"errors"
 	"net/http"
 	"net/url"
 	"strings"
@@ -576,3 +577,88 @@ func TestDigest_Header(t *testing.T) {
 		})
 	}
 }
+
+func TestParseDigestChallenge(t *testing.T) {
+	tests := []struct {
+		name      string
+		challenge string
+		want      auth.DigestParameters
+		wantErr   error
+	}{
+		{
+			name: "RFC7616 example",
+			challenge: `Digest realm="http-auth@example.org", qop="auth, auth-int", ` +
+				`algorithm=SHA-256, nonce="7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", ` +
+				`opaque="FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"`,
+			want: auth.DigestParameters{
+				Algorithm: auth.DigestSHA256,
+				Realm:     "http-auth@example.org",
+				QOP:       auth.DigestQOPAuth,
+				Nonce:     "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v",
+				Opaque:    "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS",
+			},
+		},
+		{
+			name:      "RFC2069 without algorithm nor qop",
+			challenge: `digest realm="testrealm@host.com",nonce="dcd98b7102dd2f0e8b11d0f600bfb0c093"`,
+			want: auth.DigestParameters{
+				Algorithm: auth.DigestMD5,
+				Realm:     "testrealm@host.com",
+				Nonce:     "dcd98b7102dd2f0e8b11d0f600bfb0c093",
+			},
+		},
+		{
+			name:      "Escaped realm, auth-int and userhash",
+			challenge: `Digest realm="the \"vault\"", nonce=abc, qop="auth-int", userhash=true`,
+			want: auth.DigestParameters{
+				Algorithm: auth.DigestMD5,
+				Realm:     `the "vault"`,
+				QOP:       auth.DigestQOPAuthInt,
+				Nonce:     "abc",
+				UserHash:  true,
+			},
+		},
+		{
+			name:      "Other scheme",
+			challenge: `Basic realm="vault"`,
+			wantErr:   auth.ErrInvalidChallenge,
+		},
+		{
+			name:      "Unterminated value",
+			challenge: `Digest realm="vault, nonce=abc`,
+			wantErr:   auth.ErrInvalidChallenge,
+		},
+		{
+			name:      "Missing value",
+			challenge: `Digest realm`,
+			wantErr:   auth.ErrInvalidChallenge,
+		},
+		{
+			name:      "Unsupported qop",
+			challenge: `Digest realm="vault", nonce=abc, qop="auth-conf"`,
+			wantErr:   auth.ErrInvalidChallenge,
+		},
+		{
+			name:      "Unknown algorithm",
+			challenge: `Digest realm="vault", nonce=abc, algorithm=SHA-1`,
+			wantErr:   auth.ErrUnknownAlgorithm,
+		},
+		{
+			name:      "Missing nonce",
+			challenge: `Digest realm="vault"`,
+			wantErr:   auth.ErrNoNonce,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := auth.ParseDigestChallenge(tt.challenge)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ParseDigestChallenge() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDigestChallenge() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
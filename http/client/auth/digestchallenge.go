@@ -0,0 +1,172 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const (
+	// DigestChallengeName is the identifier for this authentication method
+	DigestChallengeName = "auth.DigestChallenge"
+
+	// digestCNonceSize is the number of random bytes of the client nonce
+	digestCNonceSize = 16
+)
+
+// Verify DigestChallenge implements ChallengeAuthenticator interface
+var _ ChallengeAuthenticator = &DigestChallenge{}
+
+// DigestChallenge implements the HTTP Digest Authentication scheme from the
+// challenges of the server, as defined in RFC7616 Section 3.3
+// at https://datatracker.ietf.org/doc/html/rfc7616#section-3.3
+//
+// No header is sent until a first challenge is received, the realm, nonce,
+// opaque, algorithm and quality of protection come from the last challenge.
+// The URI, client nonce and nonce count are set for each request.
+//
+// It is not safe for concurrent use, the client serializes the calls.
+type DigestChallenge struct {
+	// Username for authentication
+	Username string
+	// Password for authentication
+	Password string
+
+	// params holds the parameters of the last challenge, nc counts the
+	// requests sent with its nonce
+	params *DigestParameters
+	nc     uint32
+}
+
+// NewDigestChallenge creates a new DigestChallenge authentication instance
+// with the provided credentials.
+func NewDigestChallenge(username, password string) (DigestChallenge, error) {
+	if username == "" {
+		return DigestChallenge{}, ErrNoUserID
+	}
+
+	if password == "" {
+		return DigestChallenge{}, ErrNoPassword
+	}
+
+	return DigestChallenge{Username: username, Password: password}, nil
+}
+
+// Name returns the identifier for this authentication method.
+func (_ *DigestChallenge) Name() string {
+	return DigestChallengeName
+}
+
+// Update implements the Authenticator interface.
+// This method is a no-op as the state is updated by Challenge.
+func (_ *DigestChallenge) Update() error {
+	return nil
+}
+
+// Header generates the HTTP Authorization header answering the last
+// challenge, it fails when no challenge was received yet.
+func (d *DigestChallenge) Header(method string, url *url.URL, body []byte,
+) (headerKey, headerValue string, err error) {
+	if d.params == nil {
+		return "", "", errors.Join(ErrNoNonce,
+			errors.New("no digest challenge received"))
+	}
+
+	cnonce := make([]byte, digestCNonceSize)
+	if _, err := rand.Read(cnonce); err != nil {
+		return "", "", err
+	}
+
+	d.nc++
+	params := *d.params
+	params.URI = url.RequestURI()
+	params.CNonce = hex.EncodeToString(cnonce)
+	params.NC = fmt.Sprintf("%08x", d.nc)
+
+	digest := Digest{
+		Username:   d.Username,
+		Password:   d.Password,
+		Parameters: params,
+	}
+
+	return digest.Header(method, url, body)
+}
+
+// Headers implements the HeadersAuthenticator interface, no header is set
+// until a first challenge is received.
+func (d *DigestChallenge) Headers(method string, url *url.URL, _ http.Header,
+	body []byte,
+) (http.Header, error) {
+	if d.params == nil {
+		return nil, nil
+	}
+
+	_, value, err := d.Header(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	return http.Header{DigestHeaderName: {value}}, nil
+}
+
+// Challenge reads the Digest challenges of the unauthorized response
+// headers. It reports if a new nonce was received, the request must then
+// be sent again. The same nonce means the credentials were refused.
+func (d *DigestChallenge) Challenge(header http.Header) (bool, error) {
+	var errs []error
+	for _, challenge := range header.Values(DigestChallengeHeaderName) {
+		params, err := ParseDigestChallenge(challenge)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		if d.params != nil && d.params.Nonce == params.Nonce {
+			return false, nil
+		}
+
+		d.params = &params
+		d.nc = 0
+
+		return true, nil
+	}
+
+	return false, errors.Join(errs...)
+}
+
+// Clone creates a deep copy of the instance.
+func (d *DigestChallenge) Clone() Authenticator {
+	clone := &DigestChallenge{
+		Username: d.Username,
+		Password: d.Password,
+		nc:       d.nc,
+	}
+	if d.params != nil {
+		params := *d.params
+		clone.params = &params
+	}
+
+	return clone
+}
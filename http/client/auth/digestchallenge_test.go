@@ -0,0 +1,100 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package auth_test
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"gitlab.com/iglou.eu/goulc/http/client/auth"
+)
+
+func TestDigestChallenge(t *testing.T) {
+	if _, err := auth.NewDigestChallenge("", "boo"); !errors.Is(err, auth.ErrNoUserID) {
+		t.Errorf("NewDigestChallenge() error = %v, want %v", err, auth.ErrNoUserID)
+	}
+
+	digest, err := auth.NewDigestChallenge("minsc", "boo")
+	if err != nil {
+		t.Fatalf("NewDigestChallenge() error = %v", err)
+	}
+	u, _ := url.Parse("https://rasheman.example/witches?circle=3")
+
+	// Nothing is sent before the first challenge
+	if headers, err := digest.Headers(http.MethodGet, u, nil, nil); err != nil || headers != nil {
+		t.Errorf("Headers() = %v, %v, want none before a challenge", headers, err)
+	}
+
+	challenge := http.Header{}
+	challenge.Add(auth.DigestChallengeHeaderName, `Basic realm="rasheman"`)
+	challenge.Add(auth.DigestChallengeHeaderName,
+		`Digest realm="rasheman", qop="auth", algorithm=SHA-256, nonce="abc", opaque="xyz"`)
+	if retry, err := digest.Challenge(challenge); err != nil || !retry {
+		t.Fatalf("Challenge() = %v, %v, want a retry", retry, err)
+	}
+
+	// The nonce count grows with each request, the response matches the
+	// one of a static Digest built with the same parameters
+	for _, nc := range []string{"00000001", "00000002"} {
+		headers, err := digest.Headers(http.MethodGet, u, nil, nil)
+		if err != nil {
+			t.Fatalf("Headers() error = %v", err)
+		}
+		value := headers.Get(auth.DigestHeaderName)
+
+		params, _ := auth.ParseDigestChallenge(challenge.Values(auth.DigestChallengeHeaderName)[1])
+		params.URI = "/witches?circle=3"
+		params.NC = nc
+		params.CNonce = regexp.MustCompile(`cnonce="([^"]+)"`).FindStringSubmatch(value)[1]
+		static, _ := auth.NewDigest("minsc", "boo", params)
+		_, want, _ := static.Header(http.MethodGet, u, nil)
+
+		if value != want || !strings.Contains(value, "nc="+nc) {
+			t.Errorf("Headers() = %q, want %q", value, want)
+		}
+	}
+
+	// The same nonce means the credentials were refused
+	if retry, err := digest.Challenge(challenge); err != nil || retry {
+		t.Errorf("Challenge() = %v, %v, want no retry for the same nonce", retry, err)
+	}
+
+	invalid := http.Header{}
+	invalid.Set(auth.DigestChallengeHeaderName, `Digest nonce="def"`)
+	if retry, err := digest.Challenge(invalid); !errors.Is(err, auth.ErrNoRealm) || retry {
+		t.Errorf("Challenge() = %v, %v, want %v", retry, err, auth.ErrNoRealm)
+	}
+
+	cloned := digest.Clone().(*auth.DigestChallenge)
+	if cloned == &digest || cloned.Username != digest.Username {
+		t.Errorf("Clone() = %+v, want a copy of %+v", cloned, digest)
+	}
+	if headers, _ := cloned.Headers(http.MethodGet, u, nil, nil); headers == nil {
+		t.Error("Clone() did not keep the challenge")
+	}
+
+	if digest.Name() != auth.DigestChallengeName || digest.Update() != nil {
+		t.Errorf("DigestChallenge.Name() = %v, want %v", digest.Name(), auth.DigestChallengeName)
+	}
+}
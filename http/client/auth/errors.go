@@ -34,4 +34,10 @@ var (
 	ErrNoURI = errors.New("you must provide a URI parameter")
 	// ErrUnknownAlgorithm is returned when the algorithm is unknown
 	ErrUnknownAlgorithm = errors.New("unknown algorithm provided")
+	// ErrInvalidChallenge is returned when a challenge cannot be parsed
+	ErrInvalidChallenge = errors.New("invalid authentication challenge")
+	// ErrNoToken is returned when the token or the key is empty
+	ErrNoToken = errors.New("you must provide a token")
+	// ErrNoHeaderName is returned when the header name is empty
+	ErrNoHeaderName = errors.New("you must provide a header name")
 )
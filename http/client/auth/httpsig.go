@@ -0,0 +1,421 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/iglou.eu/goulc/hided"
+)
+
+// SignatureAlgorithm is an HTTP message signature algorithm, as registered
+// in RFC 9421 §6.2.2: https://www.rfc-editor.org/rfc/rfc9421#section-6.2.2
+type SignatureAlgorithm string
+
+const (
+	// HTTPSignatureName is the identifier for this authentication method
+	HTTPSignatureName = "auth.HTTPSignature"
+	// HTTPSignatureHeader is the header holding the signature
+	HTTPSignatureHeader = "Signature"
+	// HTTPSignatureInputHeader is the header holding the signature parameters
+	HTTPSignatureInputHeader = "Signature-Input"
+	// HTTPSignatureDigestHeader is the header holding the body digest
+	HTTPSignatureDigestHeader = "Content-Digest"
+	// HTTPSignatureDefaultLabel identifies the signature in the headers
+	HTTPSignatureDefaultLabel = "sig1"
+
+	SigHMACSHA256      SignatureAlgorithm = "hmac-sha256"
+	SigEd25519         SignatureAlgorithm = "ed25519"
+	SigECDSAP256SHA256 SignatureAlgorithm = "ecdsa-p256-sha256"
+	SigRSAPSSSHA512    SignatureAlgorithm = "rsa-pss-sha512"
+
+	// httpSigContentDigest is the covered component of the body digest
+	httpSigContentDigest = "content-digest"
+)
+
+var (
+	// ErrNoKeyID is returned when the key identifier is empty
+	ErrNoKeyID = errors.New("you must provide a key ID")
+	// ErrInvalidSigningKey is returned when the key does not match
+	// the signature algorithm
+	ErrInvalidSigningKey = errors.New("invalid signing key for the algorithm")
+	// ErrMissingComponent is returned when a covered component is not
+	// present in the request
+	ErrMissingComponent = errors.New("missing covered component")
+)
+
+// Verify HTTPSignature implements HeadersAuthenticator interface
+var _ HeadersAuthenticator = &HTTPSignature{}
+
+// HTTPSignature implements the HTTP Message Signatures of RFC 9421.
+// RFC 9421: https://www.rfc-editor.org/rfc/rfc9421
+type HTTPSignature struct {
+	// KeyID identifies the key to the verifier
+	KeyID string
+	// Algorithm is the signature algorithm
+	Algorithm SignatureAlgorithm
+	// Secret is the shared secret of SigHMACSHA256 in hidden mode
+	Secret hided.String
+	// Key is the private key of the asymmetric algorithms: ed25519.PrivateKey,
+	// *ecdsa.PrivateKey on P-256 or *rsa.PrivateKey
+	Key crypto.Signer
+
+	// Components are the covered components, derived ones such as "@method"
+	// or lowercase header names. Listing "content-digest" adds the
+	// Content-Digest header computed from the body.
+	// Default: "@method", "@target-uri", and "content-digest" with a body
+	Components []string
+
+	// Label identifies the signature in the headers.
+	// Default: "sig1"
+	Label string
+	// Expires sets the signature validity, zero for no expiration.
+	// Default: 0
+	Expires time.Duration
+	// Tag is the optional application specific tag.
+	// Default: ""
+	Tag string
+
+	// Now returns the signing time, it allows to correct a clock skew.
+	// Default: time.Now
+	Now func() time.Time
+}
+
+// NewHTTPSignature creates a new HTTPSignature authentication instance.
+// The `key` is a hided.String for SigHMACSHA256, or a crypto.Signer for
+// the asymmetric algorithms.
+//
+// Returns an error if the key identifier is missing or the key does not
+// match the algorithm.
+func NewHTTPSignature(
+	keyID string, algorithm SignatureAlgorithm, key any, components []string,
+) (HTTPSignature, error) {
+	if keyID == "" {
+		return HTTPSignature{}, ErrNoKeyID
+	}
+
+	sig := HTTPSignature{
+		KeyID:      keyID,
+		Algorithm:  algorithm,
+		Components: components,
+	}
+
+	switch k := key.(type) {
+	case hided.String:
+		sig.Secret = k
+	case crypto.Signer:
+		sig.Key = k
+	}
+
+	if err := sig.validate(); err != nil {
+		return HTTPSignature{}, err
+	}
+
+	return sig, nil
+}
+
+// Name returns the identifier for this authentication method.
+func (_ *HTTPSignature) Name() string {
+	return HTTPSignatureName
+}
+
+// Update implements the Authenticator interface.
+// This method is a no-op as there is no state to update.
+func (_ *HTTPSignature) Update() error {
+	return nil
+}
+
+// Header implements the Authenticator interface. The signature cannot be
+// carried by a single header, it always returns ErrHeadersRequired.
+func (_ *HTTPSignature) Header(_ string, _ *url.URL, _ []byte,
+) (headerKey, headerValue string, err error) {
+	return "", "", ErrHeadersRequired
+}
+
+// Headers signs the request and returns the Signature-Input and Signature
+// headers, plus Content-Digest when it is covered.
+func (s *HTTPSignature) Headers(method string, u *url.URL, header http.Header,
+	body []byte,
+) (http.Header, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+
+	components := s.Components
+	if len(components) == 0 {
+		components = []string{"@method", "@target-uri"}
+		if len(body) > 0 {
+			components = append(components, httpSigContentDigest)
+		}
+	}
+
+	headers := make(http.Header)
+
+	// Content digest of RFC 9530 §2
+	// https://www.rfc-editor.org/rfc/rfc9530#section-2
+	var digest string
+	for _, component := range components {
+		if component == httpSigContentDigest {
+			hash := sha256.Sum256(body)
+			digest = "sha-256=:" +
+				base64.StdEncoding.EncodeToString(hash[:]) + ":"
+			headers.Set(HTTPSignatureDigestHeader, digest)
+			break
+		}
+	}
+
+	params := s.params(components)
+
+	// Signature base of RFC 9421 §2.5
+	// https://www.rfc-editor.org/rfc/rfc9421#section-2.5
+	var base strings.Builder
+	for _, component := range components {
+		value, err := httpSigComponent(component, method, u, header, body, digest)
+		if err != nil {
+			return nil, err
+		}
+		base.WriteString(strconv.Quote(component) + ": " + value + "\n")
+	}
+	base.WriteString(`"@signature-params": ` + params)
+
+	signature, err := s.sign([]byte(base.String()))
+	if err != nil {
+		return nil, err
+	}
+
+	label := s.label()
+	headers.Set(HTTPSignatureInputHeader, label+"="+params)
+	headers.Set(HTTPSignatureHeader, label+"=:"+
+		base64.StdEncoding.EncodeToString(signature)+":")
+
+	return headers, nil
+}
+
+// Clone creates a deep copy of the instance.
+// This ensures that modifications don't affect the original instance.
+// The key is shared as it is immutable.
+func (s *HTTPSignature) Clone() Authenticator {
+	clone := *s
+	clone.Components = append([]string(nil), s.Components...)
+
+	return &clone
+}
+
+// label returns the signature label.
+func (s *HTTPSignature) label() string {
+	if s.Label == "" {
+		return HTTPSignatureDefaultLabel
+	}
+
+	return s.Label
+}
+
+// params serializes the covered components and the signature parameters.
+// RFC 9421 §2.3: https://www.rfc-editor.org/rfc/rfc9421#section-2.3
+func (s *HTTPSignature) params(components []string) string {
+	quoted := make([]string, 0, len(components))
+	for _, component := range components {
+		quoted = append(quoted, strconv.Quote(component))
+	}
+
+	now := time.Now()
+	if s.Now != nil {
+		now = s.Now()
+	}
+
+	params := "(" + strings.Join(quoted, " ") + ")" +
+		";created=" + strconv.FormatInt(now.Unix(), 10)
+	if s.Expires > 0 {
+		params += ";expires=" +
+			strconv.FormatInt(now.Add(s.Expires).Unix(), 10)
+	}
+	params += ";keyid=" + strconv.Quote(s.KeyID) +
+		";alg=" + strconv.Quote(string(s.Algorithm))
+	if s.Tag != "" {
+		params += ";tag=" + strconv.Quote(s.Tag)
+	}
+
+	return params
+}
+
+// validate checks the key matches the algorithm.
+func (s *HTTPSignature) validate() error {
+	var valid bool
+	switch s.Algorithm {
+	case SigHMACSHA256:
+		valid = !s.Secret.IsEmpty()
+	case SigEd25519:
+		_, valid = s.publicKey().(ed25519.PublicKey)
+	case SigECDSAP256SHA256:
+		key, ok := s.publicKey().(*ecdsa.PublicKey)
+		valid = ok && key.Curve == elliptic.P256()
+	case SigRSAPSSSHA512:
+		_, valid = s.publicKey().(*rsa.PublicKey)
+	default:
+		return errors.Join(ErrUnknownAlgorithm,
+			errors.New("got "+string(s.Algorithm)))
+	}
+
+	if !valid {
+		return errors.Join(ErrInvalidSigningKey,
+			errors.New("for "+string(s.Algorithm)))
+	}
+
+	return nil
+}
+
+// publicKey returns the public key of the signer, nil without signer.
+func (s *HTTPSignature) publicKey() crypto.PublicKey {
+	if s.Key == nil {
+		return nil
+	}
+
+	return s.Key.Public()
+}
+
+// sign signs the signature base.
+// RFC 9421 §3.3: https://www.rfc-editor.org/rfc/rfc9421#section-3.3
+func (s *HTTPSignature) sign(base []byte) ([]byte, error) {
+	switch s.Algorithm {
+	case SigHMACSHA256:
+		mac := hmac.New(sha256.New, []byte(s.Secret))
+		mac.Write(base)
+		return mac.Sum(nil), nil
+
+	case SigEd25519:
+		return s.Key.Sign(rand.Reader, base, crypto.Hash(0))
+
+	case SigECDSAP256SHA256:
+		hash := sha256.Sum256(base)
+		der, err := s.Key.Sign(rand.Reader, hash[:], crypto.SHA256)
+		if err != nil {
+			return nil, err
+		}
+
+		// The signature is the fixed size concatenation of r and s
+		var rs struct{ R, S *big.Int }
+		if _, err := asn1.Unmarshal(der, &rs); err != nil {
+			return nil, err
+		}
+		signature := make([]byte, 64)
+		rs.R.FillBytes(signature[:32])
+		rs.S.FillBytes(signature[32:])
+		return signature, nil
+
+	default: // SigRSAPSSSHA512, the algorithm is validated
+		hash := sha512.Sum512(base)
+		return s.Key.Sign(rand.Reader, hash[:], &rsa.PSSOptions{
+			SaltLength: sha512.Size,
+			Hash:       crypto.SHA512,
+		})
+	}
+}
+
+// httpSigComponent returns the value of a covered component.
+// RFC 9421 §2.1 and §2.2: https://www.rfc-editor.org/rfc/rfc9421#section-2.1
+func httpSigComponent(
+	component, method string, u *url.URL, header http.Header,
+	body []byte, digest string,
+) (string, error) {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	switch component {
+	case "@method":
+		return strings.ToUpper(method), nil
+	case "@target-uri":
+		return u.String(), nil
+	case "@authority":
+		return httpSigAuthority(u), nil
+	case "@scheme":
+		return strings.ToLower(u.Scheme), nil
+	case "@request-target":
+		if u.RawQuery != "" {
+			return path + "?" + u.RawQuery, nil
+		}
+		return path, nil
+	case "@path":
+		return path, nil
+	case "@query":
+		return "?" + u.RawQuery, nil
+	case httpSigContentDigest:
+		return digest, nil
+	case "content-length":
+		if values := header.Values(component); len(values) > 0 {
+			return httpSigFieldValue(values), nil
+		}
+		return strconv.Itoa(len(body)), nil
+	}
+
+	if strings.HasPrefix(component, "@") {
+		return "", errors.Join(ErrMissingComponent,
+			errors.New("unsupported derived component "+component))
+	}
+
+	values := header.Values(component)
+	if len(values) == 0 {
+		return "", errors.Join(ErrMissingComponent,
+			errors.New("no "+component+" header"))
+	}
+
+	return httpSigFieldValue(values), nil
+}
+
+// httpSigFieldValue trims and combines the values of a field.
+func httpSigFieldValue(values []string) string {
+	trimmed := make([]string, 0, len(values))
+	for _, v := range values {
+		trimmed = append(trimmed, strings.TrimSpace(v))
+	}
+
+	return strings.Join(trimmed, ", ")
+}
+
+// httpSigAuthority returns the lowercase host, without the default port.
+func httpSigAuthority(u *url.URL) string {
+	host := strings.ToLower(u.Host)
+	switch {
+	case u.Scheme == "https" && strings.HasSuffix(host, ":443"):
+		return strings.TrimSuffix(host, ":443")
+	case u.Scheme == "http" && strings.HasSuffix(host, ":80"):
+		return strings.TrimSuffix(host, ":80")
+	}
+
+	return host
+}
@@ -162,8 +162,14 @@ func (g *ClientCredentials) Clone() auth.Authenticator {
 func (g *ClientCredentials) newToken() error {
 	var tokenResp Response
 
-	// New request to Auth
+	// New request to Auth, the client of a closed parent is nil
+	if g.http == nil {
+		return client.ErrClientClosed
+	}
 	c := g.http.NewChild(g.Config.Endpoint.Auth)
+	if c == nil {
+		return client.ErrClientClosed
+	}
 
 	// Build the request body
 	// RFC 6749 §4.4.2: https://www.rfc-editor.org/rfc/rfc6749#section-4.4.2
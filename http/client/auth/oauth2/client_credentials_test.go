@@ -21,6 +21,7 @@ package oauth2_test
 
 import (
 	"context"
+	"errors"
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
@@ -188,6 +189,31 @@ func TestClientCredentials_Update(t *testing.T) {
 	}
 }
 
+func TestClientCredentials_UpdateClosed(t *testing.T) {
+	httpClient, err := client.New(context.Background(), "https://auth.example", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("Failed to create HTTP client: %v", err)
+	}
+
+	cc, err := oauth2.NewClientCredentials(oauth2.ClientInHeader, oauth2.Config{
+		ClientID:     "test-client",
+		ClientSecret: hided.String("test-secret"),
+		Endpoint:     oauth2.Endpoint{URL: "https://auth.example"},
+	}, slog.Default(), &httpClient)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// The token cannot be requested through a closed client
+	httpClient.Close()
+	if err := cc.Update(); !errors.Is(err, client.ErrClientClosed) {
+		t.Errorf("Update() error = %v, want %v", err, client.ErrClientClosed)
+	}
+	if err := cc.Clone().(*oauth2.ClientCredentials).Update(); !errors.Is(err, client.ErrClientClosed) {
+		t.Errorf("Clone().Update() error = %v, want %v", err, client.ErrClientClosed)
+	}
+}
+
 func TestClientCredentials_Header(t *testing.T) {
 	logger := slog.Default()
 	config := oauth2.Config{
@@ -0,0 +1,408 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/iglou.eu/goulc/hided"
+)
+
+const (
+	// SigV4Name is the identifier for this authentication method
+	SigV4Name = "auth.SigV4"
+	// SigV4Algorithm is the signing algorithm identifier
+	SigV4Algorithm = "AWS4-HMAC-SHA256"
+	// SigV4HeaderName is the HTTP header name for authentication
+	SigV4HeaderName = "Authorization"
+	// SigV4DateHeader is the header holding the signing time
+	SigV4DateHeader = "X-Amz-Date"
+	// SigV4ContentHeader is the header holding the payload hash
+	SigV4ContentHeader = "X-Amz-Content-Sha256"
+	// SigV4TokenHeader is the header holding the session token
+	SigV4TokenHeader = "X-Amz-Security-Token"
+	// SigV4UnsignedPayload replaces the payload hash when it is not signed
+	SigV4UnsignedPayload = "UNSIGNED-PAYLOAD"
+	// SigV4TimeFormat is the format of the signing time
+	SigV4TimeFormat = "20060102T150405Z"
+	// SigV4MaxExpires is the longest validity of a presigned URL
+	SigV4MaxExpires = 7 * 24 * time.Hour
+
+	// sigV4Terminator ends the credential scope
+	sigV4Terminator = "aws4_request"
+	// sigV4S3 is the service name of S3, its paths are encoded only once
+	sigV4S3 = "s3"
+)
+
+var (
+	// ErrNoSecretKey is returned when the secret access key is empty
+	ErrNoSecretKey = errors.New("you must provide a secret access key")
+	// ErrNoRegion is returned when the region is empty
+	ErrNoRegion = errors.New("you must provide a region")
+	// ErrNoService is returned when the service is empty
+	ErrNoService = errors.New("you must provide a service")
+	// ErrInvalidExpires is returned when a presigned URL validity is
+	// not between one second and seven days
+	ErrInvalidExpires = errors.New("invalid presigned URL expiration")
+	// ErrHeadersRequired is returned by SigV4.Header, the signature
+	// requires several headers set by SigV4.Headers
+	ErrHeadersRequired = errors.New(
+		"this authenticator sets several headers, use Headers")
+)
+
+// sigV4IgnoredHeaders are not signed as they may be modified in transit.
+var sigV4IgnoredHeaders = []string{
+	"authorization",
+	"connection",
+	"expect",
+	"user-agent",
+	"x-amzn-trace-id",
+}
+
+// Verify SigV4 implements HeadersAuthenticator interface
+var _ HeadersAuthenticator = &SigV4{}
+
+// SigV4 implements the AWS Signature Version 4, used by the AWS APIs and
+// S3-compatible services such as MinIO.
+// AWS SigV4: https://docs.aws.amazon.com/IAM/latest/UserGuide/reference_sigv-create-signed-request.html
+type SigV4 struct {
+	// AccessKeyID is the access key identifier
+	AccessKeyID string
+	// SecretAccessKey is the secret access key in hidden mode
+	SecretAccessKey hided.String
+	// SessionToken is the optional token of temporary credentials
+	SessionToken hided.String
+
+	// Region is the region of the service, such as "us-east-1"
+	Region string
+	// Service is the signing name of the service, such as "s3"
+	Service string
+
+	// UnsignedPayload skips the payload hash, as allowed by S3
+	UnsignedPayload bool
+
+	// Now returns the signing time, it allows to correct a clock skew.
+	// Default: time.Now
+	Now func() time.Time
+}
+
+// NewSigV4 creates a new SigV4 authentication instance with the provided
+// credentials and scope. The session token may be empty.
+//
+// Returns an error if any of the required fields are missing.
+func NewSigV4(
+	accessKeyID string, secretAccessKey, sessionToken hided.String,
+	region, service string,
+) (SigV4, error) {
+	if accessKeyID == "" {
+		return SigV4{}, ErrNoUserID
+	}
+
+	if secretAccessKey.IsEmpty() {
+		return SigV4{}, ErrNoSecretKey
+	}
+
+	if region == "" {
+		return SigV4{}, ErrNoRegion
+	}
+
+	if service == "" {
+		return SigV4{}, ErrNoService
+	}
+
+	return SigV4{
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+		SessionToken:    sessionToken,
+		Region:          region,
+		Service:         service,
+	}, nil
+}
+
+// Name returns the identifier for this authentication method.
+func (_ *SigV4) Name() string {
+	return SigV4Name
+}
+
+// Update implements the Authenticator interface.
+// This method is a no-op as there is no state to update.
+func (_ *SigV4) Update() error {
+	return nil
+}
+
+// Header implements the Authenticator interface. The signature cannot be
+// carried by a single header, it always returns ErrHeadersRequired.
+func (_ *SigV4) Header(_ string, _ *url.URL, _ []byte,
+) (headerKey, headerValue string, err error) {
+	return "", "", ErrHeadersRequired
+}
+
+// Headers signs the request and returns the X-Amz-Date,
+// X-Amz-Security-Token and Authorization headers, plus X-Amz-Content-Sha256
+// for S3 or an unsigned payload. The host, the query and all the request
+// headers are signed, except those that may be modified in transit such
+// as User-Agent.
+func (s *SigV4) Headers(method string, u *url.URL, header http.Header,
+	body []byte,
+) (http.Header, error) {
+	now := s.now()
+	date := now.Format(SigV4TimeFormat)
+
+	payloadHash := SigV4UnsignedPayload
+	if !s.UnsignedPayload {
+		payloadHash = sigV4Hash(body)
+	}
+
+	headers := make(http.Header)
+	headers.Set(SigV4DateHeader, date)
+	if s.Service == sigV4S3 || s.UnsignedPayload {
+		headers.Set(SigV4ContentHeader, payloadHash)
+	}
+	if !s.SessionToken.IsEmpty() {
+		headers.Set(SigV4TokenHeader, string(s.SessionToken))
+	}
+
+	// The signed headers are the request ones plus the generated ones
+	signed := header.Clone()
+	if signed == nil {
+		signed = make(http.Header)
+	}
+	for name, values := range headers {
+		signed[name] = values
+	}
+
+	canonicalHeaders, signedHeaders := sigV4CanonicalHeaders(u.Host, signed)
+	canonicalRequest := strings.Join([]string{
+		method,
+		s.canonicalURI(u),
+		sigV4CanonicalQuery(u.Query()),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	signature := s.signature(now, canonicalRequest)
+	headers.Set(SigV4HeaderName, SigV4Algorithm+
+		" Credential="+s.AccessKeyID+"/"+s.scope(now)+
+		", SignedHeaders="+signedHeaders+
+		", Signature="+signature)
+
+	return headers, nil
+}
+
+// Presign returns a copy of `u` carrying the signature in its query, usable
+// without credentials until it expires. Only the host header is signed and
+// the payload is not.
+func (s *SigV4) Presign(
+	method string, u *url.URL, expires time.Duration,
+) (*url.URL, error) {
+	if expires < time.Second || expires > SigV4MaxExpires {
+		return nil, errors.Join(ErrInvalidExpires,
+			errors.New("got "+expires.String()))
+	}
+
+	now := s.now()
+	presigned := *u
+
+	query := presigned.Query()
+	query.Set("X-Amz-Algorithm", SigV4Algorithm)
+	query.Set("X-Amz-Credential", s.AccessKeyID+"/"+s.scope(now))
+	query.Set("X-Amz-Date", now.Format(SigV4TimeFormat))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+	if !s.SessionToken.IsEmpty() {
+		query.Set(SigV4TokenHeader, string(s.SessionToken))
+	}
+
+	canonicalHeaders, signedHeaders := sigV4CanonicalHeaders(u.Host, nil)
+	canonicalRequest := strings.Join([]string{
+		method,
+		s.canonicalURI(u),
+		sigV4CanonicalQuery(query),
+		canonicalHeaders,
+		signedHeaders,
+		SigV4UnsignedPayload,
+	}, "\n")
+
+	query.Set("X-Amz-Signature", s.signature(now, canonicalRequest))
+	presigned.RawQuery = sigV4CanonicalQuery(query)
+
+	return &presigned, nil
+}
+
+// Clone creates a deep copy of the instance.
+// This ensures that modifications don't affect the original instance.
+func (s *SigV4) Clone() Authenticator {
+	return &SigV4{
+		AccessKeyID:     s.AccessKeyID,
+		SecretAccessKey: s.SecretAccessKey,
+		SessionToken:    s.SessionToken,
+		Region:          s.Region,
+		Service:         s.Service,
+		UnsignedPayload: s.UnsignedPayload,
+		Now:             s.Now,
+	}
+}
+
+// now returns the signing time in UTC.
+func (s *SigV4) now() time.Time {
+	if s.Now != nil {
+		return s.Now().UTC()
+	}
+
+	return time.Now().UTC()
+}
+
+// scope returns the credential scope of the signing day.
+func (s *SigV4) scope(now time.Time) string {
+	return now.Format("20060102") + "/" + s.Region + "/" + s.Service + "/" +
+		sigV4Terminator
+}
+
+// signature derives the signing key and signs the canonical request.
+func (s *SigV4) signature(now time.Time, canonicalRequest string) string {
+	stringToSign := SigV4Algorithm + "\n" +
+		now.Format(SigV4TimeFormat) + "\n" +
+		s.scope(now) + "\n" +
+		sigV4Hash([]byte(canonicalRequest))
+
+	key := sigV4HMAC([]byte("AWS4"+string(s.SecretAccessKey)),
+		now.Format("20060102"))
+	key = sigV4HMAC(key, s.Region)
+	key = sigV4HMAC(key, s.Service)
+	key = sigV4HMAC(key, sigV4Terminator)
+
+	return hex.EncodeToString(sigV4HMAC(key, stringToSign))
+}
+
+// canonicalURI encodes the path sent on the wire, twice except for S3.
+// The segments are encoded one by one, so an encoded slash stays encoded.
+func (s *SigV4) canonicalURI(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if unescaped, err := url.PathUnescape(segment); err == nil {
+			segment = unescaped
+		}
+		segments[i] = sigV4Escape(segment, true)
+	}
+
+	uri := strings.Join(segments, "/")
+	if s.Service != sigV4S3 {
+		uri = sigV4Escape(uri, false)
+	}
+
+	return uri
+}
+
+// sigV4CanonicalQuery encodes the query sorted by name then by value.
+func sigV4CanonicalQuery(query url.Values) string {
+	params := make([]string, 0, len(query))
+	for name, values := range query {
+		for _, value := range values {
+			params = append(params,
+				sigV4Escape(name, true)+"="+sigV4Escape(value, true))
+		}
+	}
+	slices.Sort(params)
+
+	return strings.Join(params, "&")
+}
+
+// sigV4CanonicalHeaders returns the canonical headers and the list of the
+// signed header names. The values are trimmed and their inner spaces
+// collapsed.
+func sigV4CanonicalHeaders(host string, header http.Header) (string, string) {
+	values := map[string]string{"host": host}
+	for name, vals := range header {
+		name = strings.ToLower(name)
+		if name == "host" || slices.Contains(sigV4IgnoredHeaders, name) {
+			continue
+		}
+
+		trimmed := make([]string, 0, len(vals))
+		for _, v := range vals {
+			trimmed = append(trimmed, strings.Join(strings.Fields(v), " "))
+		}
+		values[name] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name + ":" + values[name] + "\n")
+	}
+
+	return canonical.String(), strings.Join(names, ";")
+}
+
+// sigV4Escape percent-encodes every byte except the unreserved characters,
+// and the slashes unless `slash` is set.
+func sigV4Escape(s string, slash bool) string {
+	const upperhex = "0123456789ABCDEF"
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := range len(s) {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && !slash:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(upperhex[c>>4])
+			b.WriteByte(upperhex[c&0x0F])
+		}
+	}
+
+	return b.String()
+}
+
+// sigV4Hash returns the hexadecimal SHA-256 of data.
+func sigV4Hash(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// sigV4HMAC returns the HMAC-SHA256 of data with key.
+func sigV4HMAC(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
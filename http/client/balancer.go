@@ -0,0 +1,602 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.com/iglou.eu/goulc/http/client/auth"
+	"gitlab.com/iglou.eu/goulc/http/utils"
+)
+
+// BalanceStrategy defines how the Balancer selects an endpoint per request.
+type BalanceStrategy uint8
+
+const (
+	// RoundRobin selects the endpoints one after the other.
+	RoundRobin BalanceStrategy = iota
+	// LeastInFlight selects the endpoint with the fewest active requests.
+	LeastInFlight
+	// Weighted selects the endpoints proportionally to their weight, using
+	// a smooth weighted round-robin.
+	Weighted
+)
+
+var (
+	// ErrNoEndpoint is returned when a Balancer is created without endpoint
+	ErrNoEndpoint = errors.New("at least one endpoint must be provided")
+
+	// ErrInvalidWeight is returned when an endpoint weight is negative
+	ErrInvalidWeight = errors.New("invalid endpoint weight")
+
+	// ErrNilBalancer is returned when a nil Balancer is provided
+	ErrNilBalancer = errors.New("nil balancer was provided")
+)
+
+// BalancerDefault defines the default balancing options
+// - Round-robin selection
+// - Ejection after 3 consecutive failures
+// - 30s ejection before the endpoint is tried again
+var BalancerDefault = BalancerOptions{
+	Strategy:      RoundRobin,
+	MaxFails:      3,
+	EjectDuration: 30 * time.Second,
+}
+
+// Endpoint describes a base URL served by the Balancer.
+type Endpoint struct {
+	// URL is the base URL, it must include the scheme and path.
+	URL string
+
+	// Weight is only used by the Weighted strategy.
+	// Default: 1
+	Weight int
+}
+
+// HealthCheck configures the active probing of the endpoints.
+type HealthCheck struct {
+	// Path is appended to the endpoint base URL to build the probe URL.
+	Path string
+
+	// Interval is the delay between two probes of an endpoint.
+	Interval time.Duration
+
+	// Timeout is the maximum duration of a single probe.
+	Timeout time.Duration
+
+	// Transport is used to perform the probes. If nil, the transport of the
+	// first client built with NewBalanced is used, so the probes share its
+	// dialer, TLS configuration and dial guard.
+	Transport http.RoundTripper
+}
+
+// BalancerOptions configures the behavior of the Balancer.
+type BalancerOptions struct {
+	// Strategy is the endpoint selection algorithm.
+	// Default: RoundRobin
+	Strategy BalanceStrategy
+
+	// MaxFails is the number of consecutive failures (transport error or
+	// 5xx status) after which an endpoint is passively ejected.
+	// Default: 3
+	MaxFails int
+
+	// EjectDuration is the time an ejected endpoint is kept out of the
+	// selection before being tried again.
+	// Default: 30s
+	EjectDuration time.Duration
+
+	// HealthCheck enables active probing when not nil. A failed probe
+	// ejects the endpoint and a successful one restores it.
+	// Default: nil
+	HealthCheck *HealthCheck
+}
+
+// EndpointStatus is a snapshot of the state of an endpoint.
+type EndpointStatus struct {
+	URL      string
+	Weight   int
+	InFlight int
+	Fails    int
+	Healthy  bool
+}
+
+// endpoint holds the state of a single base URL.
+type endpoint struct {
+	url    url.URL
+	weight int
+
+	inFlight     int
+	fails        int
+	ejectedUntil time.Time
+	current      int // smooth weighted round-robin accumulator
+}
+
+// Balancer dispatches the requests of a client tree over a set of
+// endpoints. It is safe for concurrent use and is shared by a client
+// and all its children.
+type Balancer struct {
+	mu     sync.Mutex
+	logger *slog.Logger
+
+	opt       BalancerOptions
+	endpoints []*endpoint
+	next      int
+
+	// probeTransport and onlyHTTPS come from the first balanced client,
+	// the probes are sent like its requests
+	probeTransport http.RoundTripper
+	onlyHTTPS      bool
+	bound          bool
+}
+
+// NewBalancer creates a Balancer for the given endpoints. If `opt` is nil,
+// `BalancerDefault` is used. When a health check is configured, probing
+// runs in background until `ctx` is canceled. If `ctx` is nil,
+// `context.Background()` is used.
+func NewBalancer(
+	ctx context.Context, endpoints []Endpoint, opt *BalancerOptions,
+	logger *slog.Logger,
+) (*Balancer, error) {
+	if len(endpoints) == 0 {
+		return nil, ErrNoEndpoint
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	if opt == nil {
+		opt = &BalancerDefault
+	}
+
+	b := &Balancer{
+		logger:    logger,
+		opt:       *opt,
+		endpoints: make([]*endpoint, 0, len(endpoints)),
+	}
+
+	if b.opt.MaxFails <= 0 {
+		b.opt.MaxFails = 1
+	}
+
+	for _, e := range endpoints {
+		if e.Weight < 0 {
+			return nil, errors.Join(ErrInvalidWeight,
+				errors.New("weight must be >= 0, got "+strconv.Itoa(e.Weight)))
+		}
+
+		if e.URL == "" {
+			return nil, ErrEmptyServerURL
+		}
+
+		parsedURL, err := url.Parse(e.URL)
+		if err != nil {
+			return nil, errors.Join(ErrInvalidURL,
+				errors.New("failed to parse URL "+e.URL), err)
+		}
+		parsedURL.Path = utils.PathFormatting(parsedURL.Path)
+
+		weight := e.Weight
+		if weight == 0 {
+			weight = 1
+		}
+
+		b.endpoints = append(b.endpoints, &endpoint{
+			url:    *parsedURL,
+			weight: weight,
+		})
+	}
+
+	if b.opt.HealthCheck != nil && b.opt.HealthCheck.Interval > 0 {
+		go b.probeLoop(ctx)
+	}
+
+	return b, nil
+}
+
+// NewBalanced creates a Client that dispatches its requests over the
+// endpoints of the Balancer. The client is built like with New using the
+// first endpoint URL, so paths added with NewChild, headers and
+// authentication behave the same. On each request, the client path is
+// rebased on the selected endpoint. With OnlyHTTPS, the requests to the
+// http:// endpoints are upgraded to https, like the first endpoint URL.
+//
+// Failover is transparent: when an endpoint fails with a transport error
+// or a 5xx status, the request is retried on another endpoint. Requests
+// with a non-idempotent method are only retried when the connection could
+// not be established, unless they carry an Idempotency-Key header.
+func NewBalanced(
+	ctx context.Context, balancer *Balancer, authenticator auth.Authenticator,
+	opt *Options, logger *slog.Logger,
+) (Client, error) {
+	if balancer == nil {
+		return Client{}, ErrNilBalancer
+	}
+
+	main, err := New(ctx, balancer.endpoints[0].url.String(),
+		authenticator, opt, logger)
+	if err != nil {
+		return Client{}, err
+	}
+
+	main.balancer = balancer
+	main.basePath = main.URL.EscapedPath()
+	balancer.bind(&main)
+
+	return main, nil
+}
+
+// Status returns a snapshot of the state of each endpoint.
+func (b *Balancer) Status() []EndpointStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	status := make([]EndpointStatus, 0, len(b.endpoints))
+	for _, e := range b.endpoints {
+		status = append(status, EndpointStatus{
+			URL:      e.url.String(),
+			Weight:   e.weight,
+			InFlight: e.inFlight,
+			Fails:    e.fails,
+			Healthy:  !e.ejectedUntil.After(now),
+		})
+	}
+
+	return status
+}
+
+// pick selects an endpoint according to the strategy, skipping the ones
+// already tried. Ejected endpoints are only selected when all the others
+// are ejected too, the one with the closest end of ejection is then used.
+// It returns nil when every endpoint has been tried.
+func (b *Balancer) pick(tried map[*endpoint]bool) *endpoint {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	candidates := make([]*endpoint, 0, len(b.endpoints))
+	var fallback *endpoint
+
+	for i := range b.endpoints {
+		// Start from the round-robin cursor to spread ties
+		e := b.endpoints[(b.next+i)%len(b.endpoints)]
+		if tried[e] {
+			continue
+		}
+
+		if e.ejectedUntil.After(now) {
+			if fallback == nil || e.ejectedUntil.Before(fallback.ejectedUntil) {
+				fallback = e
+			}
+			continue
+		}
+
+		candidates = append(candidates, e)
+	}
+
+	var selected *endpoint
+	switch {
+	case len(candidates) == 0:
+		selected = fallback
+	case b.opt.Strategy == LeastInFlight:
+		selected = candidates[0]
+		for _, e := range candidates[1:] {
+			if e.inFlight < selected.inFlight {
+				selected = e
+			}
+		}
+	case b.opt.Strategy == Weighted:
+		total := 0
+		for _, e := range candidates {
+			e.current += e.weight
+			total += e.weight
+			if selected == nil || e.current > selected.current {
+				selected = e
+			}
+		}
+		selected.current -= total
+	default:
+		selected = candidates[0]
+	}
+
+	if selected == nil {
+		return nil
+	}
+
+	b.next = (b.next + 1) % len(b.endpoints)
+	selected.inFlight++
+
+	return selected
+}
+
+// release marks the end of a request on the endpoint.
+func (b *Balancer) release(e *endpoint) {
+	b.mu.Lock()
+	e.inFlight--
+	b.mu.Unlock()
+}
+
+// report updates the endpoint health according to the request outcome.
+// An endpoint is ejected after MaxFails consecutive failures.
+func (b *Balancer) report(e *endpoint, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !failed {
+		e.fails = 0
+		e.ejectedUntil = time.Time{}
+		return
+	}
+
+	e.fails++
+	if e.fails >= b.opt.MaxFails {
+		e.ejectedUntil = time.Now().Add(b.opt.EjectDuration)
+		b.logger.Warn("endpoint ejected",
+			"endpoint", e.url.String(),
+			"fails", e.fails,
+			"eject_duration", b.opt.EjectDuration)
+	}
+}
+
+// target rebases the client URL on the endpoint base URL.
+func (e *endpoint) target(u url.URL, basePath string) url.URL {
+	rel := u.EscapedPath()
+	if basePath != "/" {
+		rel = strings.TrimPrefix(rel, basePath)
+	}
+
+	u.Scheme = e.url.Scheme
+	u.Host = e.url.Host
+	u.User = e.url.User
+
+	base := e.url.EscapedPath()
+	switch {
+	case base == "/":
+		setEscapedPath(&u, utils.PathFormatting(rel))
+	case rel == "" || rel == "/":
+		setEscapedPath(&u, base)
+	default:
+		setEscapedPath(&u, base+rel)
+	}
+
+	return u
+}
+
+// bind records the transport and the scheme upgrade of the first balanced
+// client, used by the probes.
+func (b *Balancer) bind(c *Client) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.bound {
+		return
+	}
+
+	b.bound = true
+	b.probeTransport = c.transport()
+	b.onlyHTTPS = c.Options.OnlyHTTPS
+}
+
+// probeClient returns the HTTP client performing the probes.
+func (b *Balancer) probeClient() (*http.Client, bool) {
+	hc := b.opt.HealthCheck
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	transport := hc.Transport
+	if transport == nil {
+		transport = b.probeTransport
+	}
+
+	return &http.Client{
+		Timeout:   hc.Timeout,
+		Transport: transport,
+	}, b.onlyHTTPS
+}
+
+// probeLoop actively checks the endpoints until the context is canceled.
+func (b *Balancer) probeLoop(ctx context.Context) {
+	ticker := time.NewTicker(b.opt.HealthCheck.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			client, onlyHTTPS := b.probeClient()
+			for _, e := range b.endpoints {
+				b.probe(ctx, client, onlyHTTPS, e)
+			}
+		}
+	}
+}
+
+// probe performs a single health check of the endpoint. Any status lower
+// than 500 is considered as healthy.
+func (b *Balancer) probe(
+	ctx context.Context, client *http.Client, onlyHTTPS bool, e *endpoint,
+) {
+	probeURL := e.target(
+		url.URL{Path: utils.PathFormatting(b.opt.HealthCheck.Path)}, "/")
+	if onlyHTTPS && probeURL.Scheme == "http" {
+		probeURL.Scheme = "https"
+	}
+
+	healthy := false
+	req, err := http.NewRequestWithContext(ctx,
+		http.MethodGet, probeURL.String(), nil)
+	if err == nil {
+		var res *http.Response
+		if res, err = client.Do(req); err == nil {
+			_, _ = io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+			healthy = res.StatusCode < http.StatusInternalServerError
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wasHealthy := !e.ejectedUntil.After(time.Now())
+	if healthy {
+		e.fails = 0
+		e.ejectedUntil = time.Time{}
+	} else {
+		e.ejectedUntil = time.Now().Add(b.opt.EjectDuration)
+	}
+
+	if wasHealthy != healthy {
+		b.logger.Info("endpoint health changed",
+			"endpoint", e.url.String(),
+			"healthy", healthy,
+			"error", err)
+	}
+}
+
+// releaseBody releases the endpoint once the response body is closed.
+type releaseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+// Close closes the underlying body and releases the endpoint.
+func (r *releaseBody) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(r.release)
+	return err
+}
+
+// sendBalanced performs the request on an endpoint selected by the
+// balancer, failing over to the other endpoints when allowed.
+func (c *Client) sendBalanced(
+	method string, body []byte,
+) (*http.Response, []Redirects, error) {
+	b := c.balancer
+	tried := make(map[*endpoint]bool, len(b.endpoints))
+
+	for {
+		e := b.pick(tried)
+		if e == nil {
+			return nil, nil, ErrNoEndpoint
+		}
+		tried[e] = true
+
+		target := e.target(c.URL, c.basePath)
+
+		// The endpoints are upgraded like the first one by New
+		if c.Options.OnlyHTTPS && target.Scheme == "http" {
+			target.Scheme = "https"
+		}
+
+		res, trace, err := c.send(method, target, body)
+
+		failed := c.context.Err() == nil && isEndpointFailure(res, err)
+		b.report(e, failed)
+
+		if res != nil {
+			res.Body = &releaseBody{
+				ReadCloser: res.Body,
+				release:    func() { b.release(e) },
+			}
+		} else {
+			b.release(e)
+		}
+
+		if !failed || len(tried) == len(b.endpoints) ||
+			!canFailover(method, c.Header.Get(IdempotencyKeyHeader) != "", err) {
+			return res, trace, err
+		}
+
+		c.logger.Warn("endpoint failed, failing over",
+			"endpoint", e.url.String(),
+			"error", err)
+
+		if res != nil {
+			_, _ = io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+	}
+}
+
+// isEndpointFailure reports if the request outcome must be considered as
+// a failure of the endpoint: a network or TLS error, a timeout, a connection
+// closed by the server or a 5xx status. The errors raised by the client
+// itself, such as the redirect policy, the dial guard or the response
+// limits, are not endpoint failures.
+func isEndpointFailure(res *http.Response, err error) bool {
+	if err == nil {
+		return res.StatusCode >= http.StatusInternalServerError
+	}
+
+	if errors.Is(err, ErrDestinationDenied) {
+		return false
+	}
+
+	var (
+		opErr   *net.OpError
+		netErr  net.Error
+		certErr *tls.CertificateVerificationError
+		recErr  tls.RecordHeaderError
+	)
+
+	return errors.As(err, &opErr) ||
+		(errors.As(err, &netErr) && netErr.Timeout()) ||
+		errors.As(err, &certErr) || errors.As(err, &recErr) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// canFailover reports if a failed request can be sent again to another
+// endpoint. Idempotent methods and requests carrying an idempotency key can
+// always be retried, the others only when the connection was never
+// established.
+func canFailover(method string, keyed bool, err error) bool {
+	if keyed {
+		return true
+	}
+
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions,
+		http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+
+	var opErr *net.OpError
+	return err != nil && errors.As(err, &opErr) && opErr.Op == "dial"
+}
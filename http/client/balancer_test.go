@@ -0,0 +1,469 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitlab.com/iglou.eu/goulc/http/client"
+)
+
+// balancedServer is a test server counting its hits
+type balancedServer struct {
+	*httptest.Server
+	hits   atomic.Int32
+	status atomic.Int32
+	path   atomic.Value
+}
+
+func newBalancedServer(t *testing.T) *balancedServer {
+	t.Helper()
+
+	s := &balancedServer{}
+	s.status.Store(http.StatusOK)
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/healthz" {
+			w.WriteHeader(int(s.status.Load()))
+			return
+		}
+
+		s.hits.Add(1)
+		s.path.Store(r.URL.Path)
+		w.WriteHeader(int(s.status.Load()))
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func newBalancedClient(
+	t *testing.T, opt *client.BalancerOptions, endpoints ...client.Endpoint,
+) (*client.Balancer, *client.Client) {
+	t.Helper()
+
+	b, err := client.NewBalancer(context.Background(), endpoints, opt, nil)
+	if err != nil {
+		t.Fatalf("NewBalancer() error = %v", err)
+	}
+
+	c, err := client.NewBalanced(context.Background(), b, nil, &client.Options{
+		Timeout:     time.Second,
+		Follow:      true,
+		MaxRedirect: 2,
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewBalanced() error = %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	return b, &c
+}
+
+func TestNewBalancer(t *testing.T) {
+	tests := []struct {
+		name      string
+		endpoints []client.Endpoint
+		wantErrIs error
+	}{
+		{
+			name:      "no endpoint",
+			wantErrIs: client.ErrNoEndpoint,
+		},
+		{
+			name:      "empty URL",
+			endpoints: []client.Endpoint{{URL: ""}},
+			wantErrIs: client.ErrEmptyServerURL,
+		},
+		{
+			name:      "invalid URL",
+			endpoints: []client.Endpoint{{URL: "://baldur"}},
+			wantErrIs: client.ErrInvalidURL,
+		},
+		{
+			name:      "negative weight",
+			endpoints: []client.Endpoint{{URL: "https://baldur.gate", Weight: -1}},
+			wantErrIs: client.ErrInvalidWeight,
+		},
+		{
+			name: "valid endpoints",
+			endpoints: []client.Endpoint{
+				{URL: "https://baldur.gate"},
+				{URL: "https://candlekeep.faerun/api", Weight: 3},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := client.NewBalancer(context.Background(), tt.endpoints, nil, nil)
+			if !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("NewBalancer() error = %v, want %v", err, tt.wantErrIs)
+			}
+		})
+	}
+
+	if _, err := client.NewBalanced(context.Background(), nil, nil, nil, nil); !errors.Is(err, client.ErrNilBalancer) {
+		t.Errorf("NewBalanced() error = %v, want %v", err, client.ErrNilBalancer)
+	}
+}
+
+func TestBalancer_Strategies(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy client.BalanceStrategy
+		weights  [2]int
+		want     [2]int32
+	}{
+		{
+			name:     "round robin",
+			strategy: client.RoundRobin,
+			want:     [2]int32{5, 5},
+		},
+		{
+			name:     "least in flight",
+			strategy: client.LeastInFlight,
+			want:     [2]int32{5, 5},
+		},
+		{
+			name:     "weighted",
+			strategy: client.Weighted,
+			weights:  [2]int{4, 1},
+			want:     [2]int32{8, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s1, s2 := newBalancedServer(t), newBalancedServer(t)
+
+			opt := client.BalancerDefault
+			opt.Strategy = tt.strategy
+			_, c := newBalancedClient(t, &opt,
+				client.Endpoint{URL: s1.URL, Weight: tt.weights[0]},
+				client.Endpoint{URL: s2.URL, Weight: tt.weights[1]})
+
+			for range 10 {
+				if _, err := c.Do(http.MethodGet, nil, nil); err != nil {
+					t.Fatalf("Do() error = %v", err)
+				}
+			}
+
+			if got := [2]int32{s1.hits.Load(), s2.hits.Load()}; got != tt.want {
+				t.Errorf("hits = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBalancer_ChildPath(t *testing.T) {
+	s1, s2 := newBalancedServer(t), newBalancedServer(t)
+
+	_, c := newBalancedClient(t, nil,
+		client.Endpoint{URL: s1.URL + "/api"},
+		client.Endpoint{URL: s2.URL + "/v2/api"})
+
+	child := c.NewChild("/waterdeep/docks")
+	for range 2 {
+		if _, err := child.Do(http.MethodGet, nil, nil); err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+	}
+
+	if got := s1.path.Load(); got != "/api/waterdeep/docks" {
+		t.Errorf("first endpoint path = %v, want %v", got, "/api/waterdeep/docks")
+	}
+	if got := s2.path.Load(); got != "/v2/api/waterdeep/docks" {
+		t.Errorf("second endpoint path = %v, want %v", got, "/v2/api/waterdeep/docks")
+	}
+}
+
+func TestBalancer_OnlyHTTPS(t *testing.T) {
+	var tlsHits atomic.Int32
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil {
+			tlsHits.Add(1)
+		}
+	}))
+	defer ts.Close()
+
+	// The same TLS server, declared with both schemes
+	plainURL := "http://" + ts.Listener.Addr().String()
+	b, err := client.NewBalancer(context.Background(), []client.Endpoint{
+		{URL: ts.URL}, {URL: plainURL},
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("NewBalancer() error = %v", err)
+	}
+
+	for _, onlyHTTPS := range []bool{true, false} {
+		tlsHits.Store(0)
+		c, err := client.NewBalanced(context.Background(), b, nil, &client.Options{
+			Timeout:          time.Second,
+			OnlyHTTPS:        onlyHTTPS,
+			DisableTLSVerify: true,
+		}, nil)
+		if err != nil {
+			t.Fatalf("NewBalanced() error = %v", err)
+		}
+
+		var rejected int
+		for range 4 {
+			resp, err := c.Do(http.MethodGet, nil, nil)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			if resp.StatusCode == http.StatusBadRequest {
+				rejected++
+			}
+		}
+		c.Close()
+
+		// The TLS server rejects the cleartext requests
+		if onlyHTTPS && (tlsHits.Load() != 4 || rejected != 0) {
+			t.Errorf("OnlyHTTPS: %d TLS requests and %d cleartext, want 4 and 0",
+				tlsHits.Load(), rejected)
+		}
+		if !onlyHTTPS && rejected != 2 {
+			t.Errorf("cleartext requests = %d, want 2", rejected)
+		}
+	}
+}
+
+func TestBalancer_Failover(t *testing.T) {
+	s1, s2 := newBalancedServer(t), newBalancedServer(t)
+	s1.status.Store(http.StatusBadGateway)
+
+	opt := client.BalancerDefault
+	opt.MaxFails = 1
+	b, c := newBalancedClient(t, &opt,
+		client.Endpoint{URL: s1.URL},
+		client.Endpoint{URL: s2.URL})
+
+	for range 4 {
+		resp, err := c.Do(http.MethodGet, nil, nil)
+		if err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Do() status = %v, want %v", resp.StatusCode, http.StatusOK)
+		}
+	}
+
+	// The failing endpoint is ejected after its first failure
+	if got := s1.hits.Load(); got != 1 {
+		t.Errorf("failing endpoint hits = %v, want 1", got)
+	}
+
+	status := b.Status()
+	if status[0].Healthy || !status[1].Healthy {
+		t.Errorf("Status() = %+v, want first ejected and second healthy", status)
+	}
+
+	// Non-idempotent requests are not replayed on a 5xx
+	s1.status.Store(http.StatusOK)
+	s2.status.Store(http.StatusServiceUnavailable)
+	resp, err := c.Do(http.MethodPost, []byte(`{"spell":"fireball"}`), nil)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Do() status = %v, want %v", resp.StatusCode, http.StatusServiceUnavailable)
+	}
+
+	// Unreachable endpoint fails over even for non-idempotent requests
+	s3 := newBalancedServer(t)
+	s3.Close()
+	_, c = newBalancedClient(t, nil,
+		client.Endpoint{URL: s3.URL},
+		client.Endpoint{URL: s1.URL})
+
+	resp, err = c.Do(http.MethodPost, []byte(`{"spell":"fireball"}`), nil)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Do() status = %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestBalancer_HealthCheck(t *testing.T) {
+	s1, s2 := newBalancedServer(t), newBalancedServer(t)
+	s1.status.Store(http.StatusInternalServerError)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b, err := client.NewBalancer(ctx, []client.Endpoint{{URL: s1.URL}, {URL: s2.URL}},
+		&client.BalancerOptions{
+			MaxFails:      1,
+			EjectDuration: time.Minute,
+			HealthCheck: &client.HealthCheck{
+				Path:     "/healthz",
+				Interval: 10 * time.Millisecond,
+				Timeout:  time.Second,
+			},
+		}, nil)
+	if err != nil {
+		t.Fatalf("NewBalancer() error = %v", err)
+	}
+
+	waitHealth := func(want bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if b.Status()[0].Healthy == want {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatalf("endpoint healthy = %v, want %v", !want, want)
+	}
+
+	waitHealth(false)
+	s1.status.Store(http.StatusOK)
+	waitHealth(true)
+}
+
+func TestBalancer_ClientErrors(t *testing.T) {
+	var hits atomic.Int32
+	redirect := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		http.Redirect(w, r, "http://megaton.wasteland/", http.StatusFound)
+	})
+	s1, s2 := httptest.NewServer(redirect), httptest.NewServer(redirect)
+	defer s1.Close()
+	defer s2.Close()
+
+	guard, err := client.NewDialGuard(nil, nil)
+	if err != nil {
+		t.Fatalf("NewDialGuard() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		opt     client.Options
+		wantErr error
+	}{
+		{
+			name: "redirect refused",
+			opt: client.Options{Follow: true, MaxRedirect: 2,
+				Redirect: &client.RedirectPolicy{AllowedHosts: []string{"vault.wasteland"}}},
+			wantErr: client.ErrRedirectNotAllowed,
+		},
+		{
+			name:    "destination denied",
+			opt:     client.Options{DialGuard: guard},
+			wantErr: client.ErrDestinationDenied,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits.Store(0)
+			b, err := client.NewBalancer(context.Background(),
+				[]client.Endpoint{{URL: s1.URL}, {URL: s2.URL}},
+				&client.BalancerOptions{MaxFails: 1, EjectDuration: time.Minute}, nil)
+			if err != nil {
+				t.Fatalf("NewBalancer() error = %v", err)
+			}
+
+			tt.opt.Timeout = time.Second
+			c, err := client.NewBalanced(context.Background(), b, nil, &tt.opt, nil)
+			if err != nil {
+				t.Fatalf("NewBalanced() error = %v", err)
+			}
+			defer c.Close()
+
+			// The errors raised by the client neither eject nor fail over
+			if _, err := c.Do(http.MethodGet, nil, nil); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Do() error = %v, want %v", err, tt.wantErr)
+			}
+			if hits.Load() > 1 {
+				t.Errorf("server hits = %d, want at most 1", hits.Load())
+			}
+			for _, status := range b.Status() {
+				if !status.Healthy || status.Fails != 0 {
+					t.Errorf("Status() = %+v, want healthy", status)
+				}
+			}
+		})
+	}
+}
+
+func TestBalancer_HealthCheckTransport(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusInternalServerError)
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The endpoint is declared in cleartext, the client upgrades it
+	b, err := client.NewBalancer(ctx,
+		[]client.Endpoint{{URL: "http://" + ts.Listener.Addr().String()}},
+		&client.BalancerOptions{
+			MaxFails:      1,
+			EjectDuration: time.Minute,
+			HealthCheck: &client.HealthCheck{
+				Path:     "/healthz",
+				Interval: 10 * time.Millisecond,
+				Timeout:  time.Second,
+			},
+		}, nil)
+	if err != nil {
+		t.Fatalf("NewBalancer() error = %v", err)
+	}
+
+	// The probes use the TLS configuration of the client
+	c, err := client.NewBalanced(ctx, b, nil, &client.Options{
+		Timeout:          time.Second,
+		OnlyHTTPS:        true,
+		DisableTLSVerify: true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewBalanced() error = %v", err)
+	}
+	defer c.Close()
+
+	waitHealth := func(want bool) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for time.Now().Before(deadline) {
+			if b.Status()[0].Healthy == want {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatalf("endpoint healthy = %v, want %v", !want, want)
+	}
+
+	waitHealth(false)
+	status.Store(http.StatusOK)
+	waitHealth(true)
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"gitlab.com/iglou.eu/goulc/bytesize"
+)
+
+const (
+	// bandwidthMaxChunk bounds the bytes transferred between two waits
+	bandwidthMaxChunk = 32 * 1024
+
+	// bandwidthSlices is the number of chunks per second at low rates,
+	// to keep the transfer smooth
+	bandwidthSlices = 20
+)
+
+var (
+	// ErrInvalidBandwidth is returned when the bandwidth rate is not positive
+	ErrInvalidBandwidth = errors.New("bandwidth rate must be positive")
+)
+
+// Bandwidth caps the bytes per second transferred by the request and
+// response bodies. A Bandwidth set in Options is shared by the client and
+// all its children; set a new one on a child to give it its own cap.
+type Bandwidth struct {
+	mu   sync.Mutex
+	rate bytesize.Size
+
+	// next is the time at which all the reserved bytes are transferred
+	next time.Time
+}
+
+// NewBandwidth creates a Bandwidth allowing `rate` bytes per second,
+// for example bytesize.New("512KiB").
+func NewBandwidth(rate bytesize.Size) (*Bandwidth, error) {
+	if rate.Bytes() <= 0 {
+		return nil, errors.Join(ErrInvalidBandwidth,
+			errors.New("got "+rate.String()))
+	}
+
+	return &Bandwidth{rate: rate}, nil
+}
+
+// Rate returns the bytes per second allowed.
+func (b *Bandwidth) Rate() bytesize.Size {
+	return b.rate
+}
+
+// WaitN blocks until `n` bytes can be transferred or the context is
+// canceled. The bytes are reserved even when the context is canceled.
+func (b *Bandwidth) WaitN(ctx context.Context, n int) error {
+	b.mu.Lock()
+	now := time.Now()
+	if b.next.Before(now) {
+		b.next = now
+	}
+	b.next = b.next.Add(
+		time.Duration(float64(n) / b.rate.Exact() * float64(time.Second)))
+	wait := b.next.Sub(now)
+	b.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// chunk returns the maximum bytes to transfer between two waits.
+func (b *Bandwidth) chunk() int {
+	return int(max(1, min(bandwidthMaxChunk, b.rate.Bytes()/bandwidthSlices)))
+}
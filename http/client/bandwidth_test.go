@@ -0,0 +1,154 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/iglou.eu/goulc/bytesize"
+	"gitlab.com/iglou.eu/goulc/http/client"
+)
+
+func TestClient_Bandwidth(t *testing.T) {
+	const size = 64 * 1024
+	payload := bytes.Repeat([]byte("M"), size)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			received, _ := io.ReadAll(r.Body)
+			if len(received) != size {
+				t.Errorf("server received %d bytes, want %d", len(received), size)
+			}
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(size))
+		_, _ = w.Write(payload)
+	}))
+	defer ts.Close()
+
+	rate, _ := bytesize.New("256KiB")
+	bandwidth, err := client.NewBandwidth(rate)
+	if err != nil {
+		t.Fatalf("NewBandwidth() error = %v", err)
+	}
+
+	var mu sync.Mutex
+	var uploads, downloads []client.Progress
+
+	c, err := client.New(context.Background(), ts.URL, nil, &client.Options{
+		Timeout:   5 * time.Second,
+		Bandwidth: bandwidth,
+		UploadProgress: func(p client.Progress) {
+			mu.Lock()
+			uploads = append(uploads, p)
+			mu.Unlock()
+		},
+		DownloadProgress: func(p client.Progress) {
+			mu.Lock()
+			downloads = append(downloads, p)
+			mu.Unlock()
+		},
+	}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	// 64KiB at 256KiB/s takes about 250ms
+	minDuration := 200 * time.Millisecond
+
+	start := time.Now()
+	if _, err := c.Do(http.MethodPost, payload, nil); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < minDuration {
+		t.Errorf("upload took %v, want at least %v", elapsed, minDuration)
+	}
+
+	start = time.Now()
+	resp, err := c.Do(http.MethodGet, nil, nil)
+	if err != nil || len(resp.Body) != size {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < minDuration {
+		t.Errorf("download took %v, want at least %v", elapsed, minDuration)
+	}
+
+	mu.Lock()
+	for name, reports := range map[string][]client.Progress{"upload": uploads, "download": downloads} {
+		if len(reports) == 0 {
+			t.Errorf("no %s progress reported", name)
+			continue
+		}
+		last := reports[len(reports)-1]
+		if last.Transferred.Bytes() != size || last.Total.Bytes() != size || last.Rate.Bytes() <= 0 {
+			t.Errorf("last %s progress = %+v, want %d bytes transferred", name, last, size)
+		}
+	}
+	mu.Unlock()
+
+	// The bandwidth is shared by the children
+	c.Options.UploadProgress = nil
+	c.Options.DownloadProgress = nil
+
+	start = time.Now()
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := c.NewChild("/").Do(http.MethodPost, payload, nil); err != nil {
+				t.Errorf("Do() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 2*minDuration {
+		t.Errorf("shared uploads took %v, want at least %v", elapsed, 2*minDuration)
+	}
+
+	// A child given its own bandwidth is not slowed by its parent
+	fast, _ := bytesize.New("64MiB")
+	child := c.NewChild("/")
+	child.Options.Bandwidth, _ = client.NewBandwidth(fast)
+
+	start = time.Now()
+	if _, err := child.Do(http.MethodPost, payload, nil); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= minDuration {
+		t.Errorf("child upload took %v, want less than %v", elapsed, minDuration)
+	}
+}
+
+func TestNewBandwidth(t *testing.T) {
+	if _, err := client.NewBandwidth(bytesize.NewInt(0)); !errors.Is(err, client.ErrInvalidBandwidth) {
+		t.Errorf("NewBandwidth() error = %v, want %v", err, client.ErrInvalidBandwidth)
+	}
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gitlab.com/iglou.eu/goulc/http/client"
+)
+
+func newBenchClient(b *testing.B) *client.Client {
+	b.Helper()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"name":"minsc"}`))
+	}))
+	b.Cleanup(ts.Close)
+
+	c, err := client.New(context.Background(), ts.URL+"/api?lang=en", &mockAuthenticator{
+		name: "mock", header: "Authorization", value: "Bearer boo",
+	}, &client.Options{Timeout: time.Second}, nil)
+	if err != nil {
+		b.Fatalf("New() error = %v", err)
+	}
+	b.Cleanup(func() { c.Close() })
+	c.Header.Set("X-Party", "minsc")
+
+	return &c
+}
+
+func BenchmarkClient_Do(b *testing.B) {
+	c := newBenchClient(b)
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := c.Do(http.MethodGet, nil, nil); err != nil {
+			b.Fatalf("Do() error = %v", err)
+		}
+	}
+}
+
+func BenchmarkClient_DoParallel(b *testing.B) {
+	c := newBenchClient(b)
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := c.Do(http.MethodGet, nil, nil); err != nil {
+				b.Errorf("Do() error = %v", err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkRequest_Do(b *testing.B) {
+	c := newBenchClient(b)
+	r := c.R().Path("/users/42").Query("fields", "name").Header("X-Request-ID", "boo")
+
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := r.Do(http.MethodGet, nil); err != nil {
+			b.Fatalf("Do() error = %v", err)
+		}
+	}
+}
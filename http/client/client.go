@@ -108,7 +108,9 @@ var OptDefault = Options{
 // It sets up a client for making HTTP requests or creating child clients
 // that inherit its configuration.
 //
-// The `serverURL` parameter must include the scheme and path.
+// The `serverURL` parameter must include the scheme and path. A Unix domain
+// socket can be targeted with the `unix://<socket>:<path>` form, for example
+// `unix:///var/run/docker.sock:/v1.43`.
 // The `authenticator` parameter can be nil if no authentication is required.
 // The `opt` parameter allows customization of client behavior through
 // the `Options` struct. The `logger` parameter specifies a custom logger;
@@ -149,6 +151,15 @@ func New(
 			errors.New("failed to parse URL "+serverURL), err)
 	}
 
+	// Unix domain socket URLs are rewritten to plain HTTP URLs,
+	// the socket path being used by the dialer
+	var socketPath string
+	if parsedURL.Scheme == UnixScheme {
+		if socketPath, err = parseUnixURL(parsedURL); err != nil {
+			return Client{}, err
+		}
+	}
+
 	// Set default logger and context
 	if logger == nil {
 		logger = slog.Default()
@@ -173,23 +184,44 @@ func New(
 				errors.New("redirect limit must be >= 0, got "+
 					strconv.Itoa(opt.MaxRedirect)))
 		}
+
+		// Validate protocol
+		if err := opt.Protocol.validate(opt.OnlyHTTPS); err != nil {
+			return Client{}, err
+		}
+
+		// Validate response size limits
+		if err := validateLimits(opt); err != nil {
+			return Client{}, err
+		}
 	} else {
 		opt = &OptDefault
 	}
 
 	// Initialize the new client
 	main := Client{
-		Mu:      &sync.RWMutex{},
-		logger:  logger,
-		Options: *opt,
-		Header:  make(http.Header),
-		Query:   make(url.Values),
+		Mu:         &sync.RWMutex{},
+		authMu:     &sync.Mutex{},
+		stats:      newStatsEngine(),
+		flights:    newFlightGroup(),
+		transports: newTransportCache(),
+		logger:     logger,
+		Options:    *opt,
+		Header:     make(http.Header),
+		Query:      make(url.Values),
 	}
 
 	main.URL = *parsedURL
 	main.URL.Path = utils.PathFormatting(main.URL.Path)
 	main.context, main.cancel = context.WithCancel(ctx)
 
+	if socketPath != "" {
+		main.socketPath = socketPath
+		if main.Options.DialContext == nil {
+			main.Options.DialContext = unixDialer(socketPath)
+		}
+	}
+
 	if authenticator != nil {
 		main.Auth = authenticator
 	}
@@ -199,9 +231,11 @@ func New(
 			errors.New("failed to parse query "+main.URL.RawQuery), err)
 	}
 
-	if main.Options.OnlyHTTPS && parsedURL.Scheme == "http" {
+	// Unix domain sockets are local, there is no need to upgrade them
+	if main.Options.OnlyHTTPS && main.URL.Scheme == "http" &&
+		main.socketPath == "" {
 		main.logger.Debug("Scheme updated to HTTPS due to OnlyHTTPS option")
-		parsedURL.Scheme = "https"
+		main.URL.Scheme = "https"
 	}
 
 	return main, nil
@@ -213,7 +247,8 @@ func New(
 //
 // The path parameter is appended to the parent's URL path. If empty,
 // the parent's path remains unchanged. The path is automatically formatted
-// to ensure proper URL structure.
+// to ensure proper URL structure. To insert identifiers in the path, prefer
+// NewChildTemplate which escapes them. It returns nil if the parent is closed.
 //
 // Example:
 //
@@ -222,15 +257,12 @@ func New(
 // // child URL will be https://api.example.com/v1/users
 func (c *Client) NewChild(path string) *Client {
 	child := c.Clone()
+	if child == nil {
+		return nil
+	}
 
 	if path != "" {
-		newPath := utils.PathFormatting(path)
-
-		if child.URL.Path == "/" {
-			child.URL.Path = newPath
-		} else {
-			child.URL.Path += newPath
-		}
+		appendEscapedPath(&child.URL, escapePath(path))
 	}
 
 	c.logger.Debug("new child client created",
@@ -239,12 +271,89 @@ func (c *Client) NewChild(path string) *Client {
 	return child
 }
 
+// snapshot returns a shallow copy of the client holding the configuration of
+// a single request, taken under the read lock. The options, URL, query,
+// authentication and shared components are read-only, only the headers are
+// copied as the request may set some. The path, query and headers of the
+// Request builder `r`, if any, are applied on the copy, like its
+// authenticator.
+//
+// Unlike Clone, the snapshot is not registered in the client: it shares the
+// client context and does not need to be closed.
+func (c *Client) snapshot(r *Request) *Client {
+	c.Mu.RLock()
+	defer c.Mu.RUnlock()
+
+	if c.closed {
+		return nil
+	}
+
+	s := &Client{
+		logger:  c.logger,
+		Mu:      c.Mu, // the request path does not lock the snapshot
+		Options: c.Options,
+		Header:  c.Header.Clone(),
+		Auth:    c.Auth,
+		URL:     c.URL,
+
+		context:    c.context,
+		authMu:     c.authMu,
+		balancer:   c.balancer,
+		basePath:   c.basePath,
+		socketPath: c.socketPath,
+		stats:      c.stats,
+		statsPath:  c.URL.Path,
+		flights:    c.flights,
+		transports: c.transports,
+	}
+
+	query := c.Query
+	if r != nil {
+		if r.path != "" {
+			appendEscapedPath(&s.URL, r.path)
+		}
+
+		if len(r.query) > 0 {
+			query = maps.Clone(query)
+			if query == nil {
+				query = make(url.Values, len(r.query))
+			}
+			for key, values := range r.query {
+				query[key] = append(slices.Clone(query[key]), values...)
+			}
+		}
+
+		if s.Header == nil {
+			s.Header = make(http.Header, len(r.header))
+		}
+		for key, values := range r.header {
+			s.Header[key] = values
+		}
+
+		if r.auth != nil {
+			s.Auth = r.auth
+		}
+	}
+
+	// The query is encoded now, the map must not be read once unlocked
+	if len(query) > 0 {
+		c.logger.Debug("encoding query parameters", "query", query)
+		s.URL.RawQuery = query.Encode()
+	}
+
+	return s
+}
+
 // Clone creates and returns a new Client that is a copy of the original.
 // The cloned Client shares the same logger and RateLimiter as the original but
-// has its own mutex, context, headers, parameters, and error history.
+// has its own mutex, context, headers and parameters. The request
+// statistics are shared with the original.
 // If the original Client is closed, the cloned Client is also marked as
 // closed. The new Client’s context is derived from the original’s context,
 // and authentication is cloned if it exists.
+//
+// The clone is closed with the original Client, it deregisters from it once
+// closed so the clones of long-lived clients do not accumulate.
 func (c *Client) Clone() *Client {
 	c.Mu.RLock()
 
@@ -257,7 +366,6 @@ func (c *Client) Clone() *Client {
 		closed:         c.closed,
 		activeRequests: 0,
 		logger:         c.logger, // keep original pointer
-		closer:         []func() error{},
 
 		Mu: &sync.RWMutex{},
 		Options: Options{
@@ -269,11 +377,30 @@ func (c *Client) Clone() *Client {
 			Timeout:          c.Options.Timeout,
 			DisableTLSVerify: c.Options.DisableTLSVerify,
 			RateLimiter:      c.Options.RateLimiter, // keep original pointer
+			DialContext:      c.Options.DialContext,
+			DialGuard:        c.Options.DialGuard, // keep original pointer
+			Protocol:         c.Options.Protocol,
+			Jar:              c.Options.Jar, // keep original pointer
+
+			MaxResponseSize:     c.Options.MaxResponseSize,
+			MaxDecompressedSize: c.Options.MaxDecompressedSize,
+			Bandwidth:           c.Options.Bandwidth, // keep original pointer
+			UploadProgress:      c.Options.UploadProgress,
+			DownloadProgress:    c.Options.DownloadProgress,
+			Idempotency:         c.Options.Idempotency,
+			Coalesce:            c.Options.Coalesce,
+			Redirect:            c.Options.Redirect, // keep original pointer
 		},
-		Header:       c.Header.Clone(),
-		URL:          c.URL,
-		Query:        maps.Clone(c.Query),
-		ErrorHistory: []ErrorHistory{},
+		Header: c.Header.Clone(),
+		URL:    c.URL,
+		Query:  maps.Clone(c.Query),
+
+		balancer:   c.balancer, // keep original pointer
+		basePath:   c.basePath,
+		socketPath: c.socketPath,
+		stats:      c.stats, // keep original pointer
+		flights:    newFlightGroup(),
+		transports: c.transports, // keep original pointer
 	}
 
 	clone.context, clone.cancel = context.WithCancel(c.context)
@@ -286,18 +413,56 @@ func (c *Client) Clone() *Client {
 	if c.Auth != nil {
 		clone.Auth = c.Auth.Clone()
 	}
+	clone.authMu = &sync.Mutex{}
 
 	c.Mu.RUnlock()
 
-	// Add the new client to the closer list of the parent
-	// This ensure that the child client is closed when the parent is closed
-	c.Mu.Lock()
-	c.closer = append(c.closer, clone.Close)
-	c.Mu.Unlock()
+	// Register the new client in the parent, this ensures that the child
+	// client is closed when the parent is closed
+	release, ok := c.register(clone.Close)
+	if !ok {
+		clone.cancel()
+		return nil
+	}
+
+	clone.Mu.Lock()
+	closed := clone.closed
+	clone.release = release
+	clone.Mu.Unlock()
+
+	// The parent was closed meanwhile and already closed the clone
+	if closed {
+		release()
+	}
 
 	return clone
 }
 
+// register adds `closer` to the functions called when the client is closed.
+// It returns the function removing it, to call once `closer` is no longer
+// needed, or false if the client is already closed.
+func (c *Client) register(closer func() error) (func(), bool) {
+	c.Mu.Lock()
+	defer c.Mu.Unlock()
+
+	if c.closed {
+		return nil, false
+	}
+
+	if c.closer == nil {
+		c.closer = make(map[uint64]func() error)
+	}
+	c.closerSeq++
+	id := c.closerSeq
+	c.closer[id] = closer
+
+	return func() {
+		c.Mu.Lock()
+		delete(c.closer, id)
+		c.Mu.Unlock()
+	}, true
+}
+
 // FlushHeader safely clears all HTTP headers stored in the Client. This allows
 // resetting the headers without creating a new instance.
 //
@@ -335,44 +500,18 @@ func (c *Client) FlushQuery() *Client {
 	return c
 }
 
-// calculateErrorRate determines the error rate for the specified status code,
-// removing entries older than one minute to ensure accuracy.
-func (c *Client) calculateErrorRate(statusCode int) float64 {
-	c.Mu.Lock()
-	defer c.Mu.Unlock()
-
-	// Clean old entries (older than 1 minute)
-	now := time.Now()
-	minTime := now.Add(-time.Minute)
-	var newHistory []ErrorHistory
-	for _, entry := range c.ErrorHistory {
-		if entry.Timestamp.After(minTime) {
-			newHistory = append(newHistory, entry)
-		}
+// logCookies logs the cookies set by a response, their values are secrets
+// so only their names are logged.
+func (c *Client) logCookies(res *http.Response) {
+	if res == nil {
+		return
 	}
 
-	// Add current request
-	newHistory = append(newHistory, ErrorHistory{
-		URL:        c.URL.String(),
-		StatusCode: statusCode,
-		Timestamp:  now,
-		IsError:    statusCode >= http.StatusBadRequest,
-	})
-	c.ErrorHistory = newHistory
-
-	// Calculate error rate
-	if len(newHistory) == 0 {
-		return 0
+	if cookies := res.Cookies(); len(cookies) > 0 {
+		c.logger.Debug("cookies received",
+			"url", res.Request.URL.String(),
+			"cookies", maskCookies(cookies))
 	}
-
-	var errorCount int
-	for _, entry := range newHistory {
-		if entry.IsError {
-			errorCount++
-		}
-	}
-
-	return float64(errorCount) / float64(len(newHistory)) * percent
 }
 
 // FollowRedirects returns a RedirectFunc that follows HTTP redirects according
@@ -380,6 +519,15 @@ func (c *Client) calculateErrorRate(statusCode int) float64 {
 // parameter.
 func (c *Client) FollowRedirects(
 	trace *[]Redirects,
+) func(req *http.Request, via []*http.Request) error {
+	return c.followRedirects(trace, nil)
+}
+
+// followRedirects returns the RedirectFunc of FollowRedirects, `body` being
+// the body of the original request, signed again by the authenticator when
+// the redirect policy forwards the authentication.
+func (c *Client) followRedirects(
+	trace *[]Redirects, body []byte,
 ) func(req *http.Request, via []*http.Request) error {
 	return func(req *http.Request, via []*http.Request) error {
 		if !c.Options.Follow {
@@ -396,9 +544,16 @@ func (c *Client) FollowRedirects(
 		}
 
 		// Remove auth headers when redirecting to different host
-		// This prevents credential leakage
-		if req.URL.Host != c.URL.Host && !c.Options.FollowAuth {
-			req.Header.Del("Authorization")
+		// This prevents credential leakage. The original request host is used
+		// as reference since it may differ from the client URL when balancing.
+		// The redirect policy decides it instead, if any
+		originHost := c.URL.Host
+		if len(via) > 0 {
+			originHost = via[0].URL.Host
+		}
+		if c.Options.Redirect == nil && req.URL.Host != originHost &&
+			!c.Options.FollowAuth && len(via) > 0 {
+			stripAuth(req, via[0])
 		}
 
 		// Track this redirect
@@ -416,6 +571,8 @@ func (c *Client) FollowRedirects(
 			Timestamp:  time.Now(),
 		})
 
+		c.logCookies(req.Response)
+
 		// Check redirect count to prevent infinite loops
 		nb := len(via)
 		if nb >= c.Options.MaxRedirect {
@@ -423,11 +580,20 @@ func (c *Client) FollowRedirects(
 				errors.New("stopped after "+strconv.Itoa(nb)+" redirects"))
 		}
 
-		// Enforce HTTPS on redirects if configured
-		if c.Options.OnlyHTTPS && req.URL.Scheme == "http" {
+		// Enforce HTTPS on redirects if configured,
+		// except for the Unix domain socket host
+		if c.Options.OnlyHTTPS && req.URL.Scheme == "http" &&
+			(c.socketPath == "" || req.URL.Host != c.URL.Host) {
 			req.URL.Scheme = "https"
 		}
 
+		// Apply the redirect policy to the final URL of the hop
+		if c.Options.Redirect != nil && len(via) > 0 {
+			if err := c.Options.Redirect.apply(c, req, via, body); err != nil {
+				return err
+			}
+		}
+
 		// Apply rate limiting to redirect requests if configured
 		if c.Options.RateLimiter != nil {
 			if err := c.Options.RateLimiter.Wait(c.context); err != nil {
@@ -485,6 +651,12 @@ func (c *Client) Close() error {
 	case <-done:
 		c.logger.Debug("http client closed successfully")
 	case <-ctx.Done():
+		// Requests may have completed meanwhile, e.g. with a zero timeout
+		if atomic.LoadInt32(&c.activeRequests) == 0 {
+			c.logger.Debug("http client closed successfully")
+			break
+		}
+
 		c.logger.Warn("http client close timed out with active requests",
 			"active_requests", atomic.LoadInt32(&c.activeRequests),
 			"ctx_err", ctx.Err())
@@ -492,7 +664,6 @@ func (c *Client) Close() error {
 
 	// Clean up resources
 	c.Mu.Lock()
-	defer c.Mu.Unlock()
 
 	if c.cancel != nil {
 		c.cancel()
@@ -503,11 +674,17 @@ func (c *Client) Close() error {
 	c.Auth = nil
 	c.URL = url.URL{}
 	c.Query = nil
-	c.ErrorHistory = nil
+
+	// The children deregister from this client when closed,
+	// so they are closed without holding the lock
+	closers, release, logger := c.closer, c.release, c.logger
+	c.closer = nil
+	c.release = nil
+	c.Mu.Unlock()
 
 	// Close all child clients
 	wg := sync.WaitGroup{}
-	for _, closer := range c.closer {
+	for _, closer := range closers {
 		wg.Add(1)
 		go func(closer func() error) {
 			defer wg.Done()
@@ -516,14 +693,20 @@ func (c *Client) Close() error {
 			}
 
 			if err := closer(); err != nil {
-				c.logger.Error("error closing child client", "error", err)
+				logger.Error("error closing child client", "error", err)
 			}
 		}(closer)
 	}
 	wg.Wait()
 
-	c.closer = nil
+	// Deregister from the parent
+	if release != nil {
+		release()
+	}
+
+	c.Mu.Lock()
 	c.logger = nil
+	c.Mu.Unlock()
 
 	return nil
 }
@@ -533,31 +716,11 @@ func (c *Client) Close() error {
 func (main *Client) DoWithMarshal(
 	method string, body Marshaler, resp Unmarshaler,
 ) (*Response, error) {
-	// Check if client is closed
-	if main.IsClosed() {
-		return nil, ErrClientClosed
-	}
-
-	// Create a copy of the client to avoid modifying the original
-	// and potential race conditions
-	c := main.Clone() // Clone are thread-safe
-
 	if body == nil {
-		return c.Do(method, nil, resp)
-	}
-
-	c.logger.Debug("http client marshalling body",
-		"marshaller", body.Name(),
-		"content_type", body.ContentType())
-
-	bodyData, err := body.Marshal()
-	if err != nil {
-		return nil, err
+		return main.Do(method, nil, resp)
 	}
 
-	c.Header.Set("Content-Type", body.ContentType())
-
-	return c.Do(method, bodyData, resp)
+	return main.R().Marshal(body).Do(method, resp)
 }
 
 // Do performs an HTTP request with the specified method and body. It manages
@@ -565,6 +728,10 @@ func (main *Client) DoWithMarshal(
 // options. This method is thread-safe and can be invoked concurrently from
 // multiple goroutines.
 //
+// The configuration is read once per call, under the read lock, and shared
+// read-only with the concurrent calls: the client is not cloned. The fields
+// of the client must therefore be modified with Mu locked.
+//
 // Example:
 //
 //	resp, err := client.Do(http.MethodGet, nil, &MyResponseType{})
@@ -583,19 +750,26 @@ func (main *Client) DoWithMarshal(
 //   - An error if the request fails or the client is closed.
 func (main *Client) Do(
 	method string, body []byte, respUml Unmarshaler,
+) (*Response, error) {
+	return main.do(method, body, respUml, nil)
+}
+
+// do performs the request on a snapshot of the client, with the path, query
+// and headers of the Request builder if any.
+func (main *Client) do(
+	method string, body []byte, respUml Unmarshaler, r *Request,
 ) (*Response, error) {
 	// Check if client is closed
 	if main.IsClosed() {
 		return nil, ErrClientClosed
 	}
 
-	// Create a copy of the client to avoid modifying the original
-	// and potential race conditions
-	c := main.Clone() // Clone are thread-safe
-	defer func() {
-		c.Close()
-		c = nil
-	}() // Release resources when done
+	// Take a snapshot of the configuration, concurrent requests share the
+	// client state read-only instead of cloning it
+	c := main.snapshot(r)
+	if c == nil {
+		return nil, ErrClientClosed
+	}
 
 	// Increment main active requests counter
 	atomic.AddInt32(&main.activeRequests, 1)
@@ -606,22 +780,133 @@ func (main *Client) Do(
 		return nil, errors.Join(ErrInvalidMethod, ErrEmptyMethod)
 	}
 
-	// Add query to URL
-	if len(c.Query) > 0 {
-		c.logger.Debug("encoding query parameters", "query", c.Query)
-		c.URL.RawQuery = c.Query.Encode()
+	if r != nil && r.marshal != nil {
+		c.logger.Debug("http client marshalling body",
+			"marshaller", r.marshal.Name(),
+			"content_type", r.marshal.ContentType())
+
+		var err error
+		if body, err = r.marshal.Marshal(); err != nil {
+			return nil, err
+		}
+		c.Header.Set("Content-Type", r.marshal.ContentType())
 	}
 
-	// Create request with context for cancellation/timeout support
+	// The key is set once, retries and redirects send the same one
+	idempotencyKey, err := c.idempotencyKey(method)
+	if err != nil {
+		return nil, errors.Join(ErrRequestFailed, err)
+	}
+
+	var resp *Response
+	if c.coalescable(method, body, r) {
+		resp, err = c.flights.do(c.context, c.flightKey(method),
+			func() (*Response, error) {
+				return c.roundTrip(method, body, idempotencyKey)
+			})
+	} else {
+		resp, err = c.roundTrip(method, body, idempotencyKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal response body if an unmarshaler is provided
+	// This allows automatic parsing of JSON/XML/etc into structs
+	// The unmarshaler has access to both the status code and body
+	// to handle different response formats based on status
+	if respUml != nil {
+		c.logger.Debug("unmarshaling response body",
+			"unmarshaler", respUml.Name(),
+			"body_size", len(resp.Body))
+
+		resp.BodyUml = respUml
+		if err := resp.BodyUml.Unmarshal(
+			resp.StatusCode, resp.Header, resp.Body,
+		); err != nil {
+			return nil, errors.Join(ErrRequestFailed, err)
+		}
+	}
+
+	return resp, nil
+}
+
+// roundTrip sends the request and reads the response body, the response is
+// not unmarshaled.
+func (c *Client) roundTrip(
+	method string, body []byte, idempotencyKey string,
+) (*Response, error) {
+	start := time.Now()
+
+	httpRes, redirectsVia, err := c.dispatch(method, body)
+	if err != nil {
+		c.recordStats(0, err, time.Since(start))
+		return nil, err
+	}
+	defer httpRes.Body.Close()
+
+	responseTime := time.Since(start)
+
+	// Create response object with essential info
+	resp := &Response{
+		Success:      httpRes.StatusCode < http.StatusBadRequest,
+		StatusCode:   httpRes.StatusCode,
+		Status:       httpRes.Status,
+		Proto:        httpRes.Proto,
+		Header:       httpRes.Header, // the raw response is not exposed
+		Request:      httpRes.Request,
+		raw:          httpRes,
+		ResponseTime: responseTime,
+		Trace:        redirectsVia,
+		ErrorRate:    c.recordStats(httpRes.StatusCode, nil, responseTime),
+
+		IdempotencyKey: idempotencyKey,
+	}
+
+	// The attributes allocate, they are only built when logged
+	if c.logger.Enabled(c.context, slog.LevelDebug) {
+		c.logger.Debug("HTTP request",
+			"success", resp.Success,
+			"method", httpRes.Request.Method,
+			"path", httpRes.Request.URL.Path,
+			"status", resp.Status,
+			"trace", resp.Trace,
+			"response_time", resp.ResponseTime,
+			"error_rate", resp.ErrorRate,
+			"idempotency_key", resp.IdempotencyKey)
+	}
+
+	if httpRes.ContentLength == 0 {
+		c.logger.Debug("empty response body received")
+		return resp, nil
+	}
+	c.logger.Debug("reading response body",
+		"status_code", resp.StatusCode,
+		"content_length", httpRes.ContentLength)
+
+	resp.Body, err = c.readBody(httpRes, resp.Header)
+	if err != nil {
+		return nil, errors.Join(ErrRequestFailed, err)
+	}
+
+	return resp, nil
+}
+
+// newRequest builds the HTTP request for the given target URL, copying the
+// client headers and adding the authentication header if any.
+func (c *Client) newRequest(
+	method string, target url.URL, body []byte,
+) (*http.Request, error) {
 	var err error
 	var req *http.Request
 
+	// Create request with context for cancellation/timeout support
 	if body != nil {
 		req, err = http.NewRequestWithContext(c.context,
-			method, c.URL.String(), bytes.NewReader(body))
+			method, target.String(), bytes.NewReader(body))
 	} else {
 		req, err = http.NewRequestWithContext(c.context,
-			method, c.URL.String(), nil)
+			method, target.String(), nil)
 	}
 
 	if err != nil {
@@ -640,121 +925,231 @@ func (main *Client) Do(
 	}
 
 	// Handle authentication if configured
-	// Some auth methods might need to read the body to generate the auth header
-	// (e.g., for signing the request)
-	if c.Auth != nil {
-		c.logger.Debug("adding authentication header",
-			"auth_name", c.Auth.Name())
+	names, err := c.authenticate(req, body)
+	if err != nil {
+		return nil, err
+	}
 
-		if err := c.Auth.Update(); err != nil {
-			return nil, err
-		}
+	// The redirects strip the authentication headers on other hosts
+	if len(names) > 0 {
+		req = req.WithContext(
+			context.WithValue(req.Context(), authHeadersKey{}, names))
+	}
+
+	return req, nil
+}
 
-		name, value, err := c.Auth.Header(method, req.URL, body)
+// authenticate sets the authentication headers of the request, if an
+// authenticator is configured, and returns their names. Some auth methods
+// read the body to generate the header (e.g., for signing the request).
+func (c *Client) authenticate(req *http.Request, body []byte) ([]string, error) {
+	if c.Auth == nil {
+		return nil, nil
+	}
+
+	c.logger.Debug("adding authentication header",
+		"auth_name", c.Auth.Name())
+
+	// The authenticator is shared by the concurrent requests
+	if c.authMu != nil {
+		c.authMu.Lock()
+		defer c.authMu.Unlock()
+	}
+
+	if err := c.Auth.Update(); err != nil {
+		return nil, err
+	}
+
+	// Signatures may cover the request headers and set several ones
+	if signer, ok := c.Auth.(auth.HeadersAuthenticator); ok {
+		headers, err := signer.Headers(req.Method, req.URL, req.Header, body)
 		if err != nil {
 			return nil, err
 		}
-		req.Header.Set(name, value)
+		for name, values := range headers {
+			req.Header[name] = values
+		}
+
+		return slices.Collect(maps.Keys(headers)), nil
+	}
+
+	name, value, err := c.Auth.Header(req.Method, req.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(name, value)
+
+	return []string{name}, nil
+}
+
+// dispatch sends the request to the client URL, or to one of the balancer
+// endpoints when the client is balanced. The response body is not read.
+func (c *Client) dispatch(
+	method string, body []byte,
+) (*http.Response, []Redirects, error) {
+	if c.balancer != nil {
+		return c.sendBalanced(method, body)
 	}
 
-	// Initialize redirects tracking
-	redirectsVia := make([]Redirects, 0, 1)
+	return c.send(method, c.URL, body)
+}
+
+// send performs a single HTTP exchange against the target URL, following
+// redirects according to the client options. An unauthorized response is
+// sent again once when the authenticator answers its challenge. It returns
+// the raw response, the redirects trace and any transport error.
+func (c *Client) send(
+	method string, target url.URL, body []byte,
+) (*http.Response, []Redirects, error) {
+	httpRes, redirectsVia, err := c.exchange(method, target, body)
+	if err != nil || httpRes.StatusCode != http.StatusUnauthorized ||
+		!c.challenge(httpRes) {
+		return httpRes, redirectsVia, err
+	}
+
+	_, _ = io.Copy(io.Discard, httpRes.Body)
+	httpRes.Body.Close()
+
+	return c.exchange(method, target, body)
+}
+
+// challenge gives the unauthorized response to the authenticator, if it
+// answers challenges. It reports if the request must be sent again.
+func (c *Client) challenge(res *http.Response) bool {
+	challenger, ok := c.Auth.(auth.ChallengeAuthenticator)
+	if !ok {
+		return false
+	}
+
+	if c.authMu != nil {
+		c.authMu.Lock()
+		defer c.authMu.Unlock()
+	}
+
+	retry, err := challenger.Challenge(res.Header)
+	if err != nil {
+		c.logger.Debug("authentication challenge refused",
+			"auth_name", c.Auth.Name(),
+			"error", err)
+	}
+
+	return retry
+}
+
+// exchange sends the request to the target URL, following redirects
+// according to the client options.
+func (c *Client) exchange(
+	method string, target url.URL, body []byte,
+) (*http.Response, []Redirects, error) {
+	req, err := c.newRequest(method, target, body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Request gzip explicitly to decompress it within the limit, the
+	// transport would otherwise decompress it transparently
+	if c.decompressing() && method != http.MethodHead &&
+		req.Header.Get("Range") == "" {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+
+	c.meterUpload(req, body)
+
+	// Initialize redirects tracking, allocated on the first redirect
+	var redirectsVia []Redirects
 
 	// Create HTTP client with configured timeout and redirect
 	client := &http.Client{
 		Timeout:       c.Options.Timeout,
-		CheckRedirect: c.FollowRedirects(&redirectsVia),
+		CheckRedirect: c.followRedirects(&redirectsVia, body),
+		Jar:           c.Options.Jar,
 	}
 
-	// Configure TLS if needed
-	if c.Options.DisableTLSVerify {
-		c.logger.Debug("TLS verification disabled",
-			"warning", "insecure connection",
-			"host", c.URL.Hostname(),
-			"proto", "http/1.1")
-		client.Transport = &http.Transport{
-			TLSClientConfig: &tls.Config{
-				InsecureSkipVerify: true,
-				ServerName:         c.URL.Hostname(),
-				NextProtos:         []string{"http/1.1"},
-			},
-		}
-	}
-
-	c.logger.Debug("executing HTTP request",
-		"method", req.Method,
-		"url", req.URL.String(),
-		"headers", slices.Sorted(maps.Keys(req.Header)))
+	client.Transport = c.transport()
 
-	start := time.Now()
+	if c.logger.Enabled(c.context, slog.LevelDebug) {
+		c.logger.Debug("executing HTTP request",
+			"method", req.Method,
+			"url", req.URL.String(),
+			"headers", slices.Sorted(maps.Keys(req.Header)))
+	}
 
 	// Apply rate limiting to request
 	if c.Options.RateLimiter != nil {
 		if err := c.Options.RateLimiter.Wait(c.context); err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
 	httpRes, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	defer httpRes.Body.Close()
 
-	// Create response object with essential info
-	resp := &Response{
-		Success:      httpRes.StatusCode < http.StatusBadRequest,
-		StatusCode:   httpRes.StatusCode,
-		Status:       httpRes.Status,
-		Proto:        httpRes.Proto,
-		Header:       httpRes.Header.Clone(),
-		Request:      httpRes.Request,
-		raw:          httpRes,
-		ResponseTime: time.Since(start),
-		Trace:        redirectsVia,
-		ErrorRate:    c.calculateErrorRate(httpRes.StatusCode),
+	c.logCookies(httpRes)
+
+	return httpRes, redirectsVia, nil
+}
+
+// transport returns the RoundTripper matching the client options, nil when
+// the default transport can be used. The transports are built once per set
+// of options and shared by the snapshots and the children of the client,
+// so their connections are reused.
+func (c *Client) transport() http.RoundTripper {
+	if !c.Options.DisableTLSVerify && c.Options.DialContext == nil &&
+		c.Options.DialGuard == nil && c.Options.Protocol == ProtoAuto {
+		return nil
 	}
 
-	c.logger.Debug("HTTP request",
-		"success", resp.Success,
-		"method", req.Method,
-		"path", req.URL.Path,
-		"status", resp.Status,
-		"trace", resp.Trace,
-		"response_time", resp.ResponseTime,
-		"error_rate", resp.ErrorRate)
+	if c.transports == nil {
+		return c.newTransport()
+	}
 
-	if httpRes.ContentLength == 0 {
-		c.logger.Debug("empty response body received")
-		return resp, nil
+	return c.transports.get(c.transportKey(), c.newTransport)
+}
+
+// newTransport builds a transport matching the client options.
+func (c *Client) newTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if c.Options.DialContext != nil {
+		c.logger.Debug("custom dialer in use",
+			"unix_socket", c.socketPath)
+		transport.DialContext = c.Options.DialContext
 	}
-	c.logger.Debug("reading response body",
-		"status_code", resp.StatusCode,
-		"content_length", httpRes.ContentLength)
 
-	resp.Body, err = io.ReadAll(httpRes.Body)
-	if err != nil {
-		return nil, errors.Join(ErrRequestFailed, err)
+	// The guard checks the destination itself, a proxy would bypass it
+	if c.Options.DialGuard != nil && c.socketPath == "" {
+		c.logger.Debug("dial guard in use")
+		transport.DialContext = c.Options.DialGuard.DialContext(
+			c.Options.DialContext)
+		transport.Proxy = nil
 	}
 
-	// Unmarshal response body if an unmarshaler is provided
-	// This allows automatic parsing of JSON/XML/etc into structs
-	// The unmarshaler has access to both the status code and body
-	// to handle different response formats based on status
-	if respUml != nil {
-		c.logger.Debug("unmarshaling response body",
-			"unmarshaler", respUml.Name(),
-			"body_size", len(resp.Body))
+	if protocols := c.Options.Protocol.protocols(); protocols != nil {
+		c.logger.Debug("HTTP protocol restricted",
+			"protocol", c.Options.Protocol.String())
+		transport.Protocols = protocols
+	}
 
-		resp.BodyUml = respUml
-		if err := resp.BodyUml.Unmarshal(
-			resp.StatusCode, resp.Header, resp.Body,
-		); err != nil {
-			return nil, errors.Join(ErrRequestFailed, err)
+	// Configure TLS if needed, the server name is set per connection by
+	// the transport
+	if c.Options.DisableTLSVerify {
+		c.logger.Debug("TLS verification disabled",
+			"warning", "insecure connection",
+			"protocol", c.Options.Protocol.String())
+		transport.TLSClientConfig = &tls.Config{
+			InsecureSkipVerify: true,
+		}
+
+		// Keep HTTP/1.1 unless a protocol is explicitly requested
+		if c.Options.Protocol == ProtoAuto {
+			transport.TLSClientConfig.NextProtos = []string{"http/1.1"}
+			transport.ForceAttemptHTTP2 = false
 		}
 	}
 
-	return resp, nil
+	return transport
 }
 
 // IsClosed checks if the client is closed.
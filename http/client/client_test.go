@@ -2,6 +2,8 @@ package client_test
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"log/slog"
@@ -9,7 +11,10 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"runtime"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -236,6 +241,11 @@ func TestClient_NewChild(t *testing.T) {
 	if child.URL.String() != "https://vault13.wasteland/api/v1" {
 		t.Errorf("Child URL = %v, want %v", child.URL, "https://example.com/api/v1")
 	}
+
+	parent.Close()
+	if child = parent.NewChild("/v2"); child != nil {
+		t.Errorf("NewChild() on a closed parent = %v, want nil", child)
+	}
 }
 
 //gocyclo:ignore
@@ -499,6 +509,137 @@ func TestClient_Close(t *testing.T) {
 	}
 }
 
+func TestClient_CloseReleasesChildren(t *testing.T) {
+	if testing.Short() {
+		t.Skip("long-running test")
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	main, err := client.New(context.Background(), ts.URL, nil, &client.Options{Timeout: time.Second}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer main.Close()
+
+	heap := func() uint64 {
+		var stats runtime.MemStats
+		runtime.GC()
+		runtime.ReadMemStats(&stats)
+		return stats.HeapAlloc
+	}
+
+	// Warm up the connections before measuring
+	for range 100 {
+		if _, err := main.Do(http.MethodGet, nil, nil); err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+	}
+	before := heap()
+
+	const requests, clones = 20_000, 1 << 20
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range requests / 8 {
+				if _, err := main.Do(http.MethodGet, nil, nil); err != nil {
+					t.Errorf("Do() error = %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for range clones {
+		main.Clone().Close()
+	}
+
+	if n := client.Children(&main); n != 0 {
+		t.Errorf("registered children = %d, want 0", n)
+	}
+
+	// A retained clone weighs more than a kilobyte, a leak would
+	// grow the heap by more than a gigabyte
+	if after := heap(); after > before+8<<20 {
+		t.Errorf("heap grew from %d to %d bytes over %d requests and %d clones",
+			before, after, requests, clones)
+	}
+
+	// Open children are still closed with their parent
+	child := main.NewChild("/vault")
+	if n := client.Children(&main); n != 1 {
+		t.Errorf("registered children = %d, want 1", n)
+	}
+	main.Close()
+	if !child.IsClosed() {
+		t.Error("Close() parent did not close the child")
+	}
+}
+
+func TestClient_DoSnapshot(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Party", r.Header.Get("X-Party"))
+	}))
+	defer ts.Close()
+
+	authenticator := &mockAuthenticator{name: "mock", header: "Authorization", value: "Bearer boo"}
+	c, err := client.New(context.Background(), ts.URL, authenticator, &client.Options{Timeout: time.Second}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	// Requests run while the configuration is modified under the lock,
+	// each one sees a consistent snapshot
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 50 {
+				if i == 0 {
+					c.Mu.Lock()
+					c.Header.Set("X-Party", "jaheira")
+					c.Query.Set("page", "2")
+					c.Mu.Unlock()
+					continue
+				}
+
+				if _, err := c.Do(http.MethodGet, nil, nil); err != nil {
+					t.Errorf("Do() error = %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The requests neither register children nor clone the authenticator,
+	// so its state is kept between requests
+	if n := client.Children(&c); n != 0 {
+		t.Errorf("registered children = %d, want 0", n)
+	}
+	c.Mu.RLock()
+	updated := c.Auth.(*mockAuthenticator).updated
+	c.Mu.RUnlock()
+	if !updated {
+		t.Error("Do() did not update the client authenticator")
+	}
+
+	resp, err := c.Do(http.MethodGet, nil, nil)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if got := resp.Header.Get("X-Party"); got != "jaheira" {
+		t.Errorf("X-Party = %q, want %q", got, "jaheira")
+	}
+}
+
 func TestClient_FlushHeader(t *testing.T) {
 	c, err := client.New(context.Background(), "https://example.com", nil, nil, nil)
 	if err != nil {
@@ -851,3 +992,88 @@ func TestClient_FollowRedirects(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_DoWithHeadersAuthenticator(t *testing.T) {
+	body := []byte(`{"bucket":"candlekeep"}`)
+	payloadHash := sha256.Sum256(body)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization := r.Header.Get("Authorization")
+		if !strings.HasPrefix(authorization, auth.SigV4Algorithm+" Credential=AKID/") ||
+			!strings.Contains(authorization, "SignedHeaders=content-type;host;x-amz-content-sha256;x-amz-date;x-custom,") ||
+			r.Header.Get("X-Amz-Date") == "" ||
+			r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(payloadHash[:]) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	signer, err := auth.NewSigV4("AKID", "secret", "", "us-east-1", "s3")
+	if err != nil {
+		t.Fatalf("NewSigV4() error = %v", err)
+	}
+
+	c, err := client.New(context.Background(), ts.URL, &signer, &client.Options{Timeout: time.Second}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+	c.Header.Set("X-Custom", "Boo")
+
+	resp, err := c.Do(http.MethodPut, body, nil)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Do() status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestClient_DoWithChallengeAuthenticator(t *testing.T) {
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		authorization := r.Header.Get("Authorization")
+		if strings.HasSuffix(r.URL.Path, "/refused") || !strings.Contains(authorization, `nonce="abc"`) {
+			w.Header().Set("WWW-Authenticate", `Digest realm="vault", qop="auth", nonce="abc"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if !strings.Contains(authorization, `uri="`+r.URL.RequestURI()+`"`) {
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer ts.Close()
+
+	digest, _ := auth.NewDigestChallenge("minsc", "boo")
+	c, err := client.New(context.Background(), ts.URL+"/vault", &digest, &client.Options{Timeout: time.Second}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	// The first request answers the challenge, the next ones reuse it
+	for i, want := range []int32{2, 3} {
+		resp, err := c.Do(http.MethodGet, nil, nil)
+		if err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+		if resp.StatusCode != http.StatusOK || hits.Load() != want {
+			t.Errorf("Do() #%d status = %d after %d requests, want %d after %d",
+				i, resp.StatusCode, hits.Load(), http.StatusOK, want)
+		}
+	}
+
+	// A refused answer is not sent again, the child keeps the challenge
+	hits.Store(0)
+	resp, err := c.NewChild("/refused").Do(http.MethodGet, nil, nil)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if resp.StatusCode != http.StatusUnauthorized || hits.Load() != 1 {
+		t.Errorf("Do() status = %d after %d requests, want %d after 1",
+			resp.StatusCode, hits.Load(), http.StatusUnauthorized)
+	}
+}
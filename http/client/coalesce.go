@@ -0,0 +1,168 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// errFlightAborted is returned to the coalesced requests when the request
+// they wait for panicked.
+var errFlightAborted = errors.New("coalesced request aborted")
+
+// flightGroup holds the in-flight coalesced requests by key.
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// flight is an in-flight request, its response is set once done is closed.
+type flight struct {
+	done chan struct{}
+	dups int
+	resp *Response
+	err  error
+}
+
+func newFlightGroup() *flightGroup {
+	return &flightGroup{flights: make(map[string]*flight)}
+}
+
+// coalescable reports whether the request can be shared: a GET or HEAD
+// request without body, authenticated by the client authenticator.
+func (c *Client) coalescable(method string, body []byte, r *Request) bool {
+	if !c.Options.Coalesce || c.flights == nil || len(body) != 0 {
+		return false
+	}
+	if method != http.MethodGet && method != http.MethodHead {
+		return false
+	}
+
+	// The authenticators given per request are not compared
+	return r == nil || r.auth == nil
+}
+
+// flightKey returns the key of the request: its method, URL and headers.
+func (c *Client) flightKey(method string) string {
+	names := make([]string, 0, len(c.Header))
+	for name := range c.Header {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var key strings.Builder
+	key.WriteString(method)
+	key.WriteByte(' ')
+	key.WriteString(c.URL.String())
+	for _, name := range names {
+		for _, value := range c.Header[name] {
+			key.WriteByte('\n')
+			key.WriteString(name)
+			key.WriteString(": ")
+			key.WriteString(value)
+		}
+	}
+
+	return key.String()
+}
+
+// do runs `send` for the first request of `key`, the requests of the same
+// key made meanwhile wait for its response, or for ctx to be done. Every
+// caller gets its own copy of the response.
+//
+// The cancellation or the timeout of the first request is its own, the
+// waiting requests whose ctx is not done send the request again, one of
+// them leading the new flight.
+func (g *flightGroup) do(
+	ctx context.Context, key string, send func() (*Response, error),
+) (*Response, error) {
+	for {
+		g.mu.Lock()
+		f, ok := g.flights[key]
+		if !ok {
+			break
+		}
+		f.dups++
+		g.mu.Unlock()
+
+		select {
+		case <-f.done:
+		case <-ctx.Done():
+			return nil, errors.Join(ErrRequestFailed, ctx.Err())
+		}
+
+		if !f.interrupted() || ctx.Err() != nil {
+			return f.result()
+		}
+	}
+
+	f := &flight{done: make(chan struct{})}
+	g.flights[key] = f
+	g.mu.Unlock()
+
+	g.run(key, f, send)
+	return f.result()
+}
+
+// run sends the request of the flight, then releases the waiting requests,
+// even if `send` panics.
+func (g *flightGroup) run(key string, f *flight, send func() (*Response, error)) {
+	f.err = errFlightAborted
+	defer func() {
+		g.mu.Lock()
+		delete(g.flights, key)
+		if f.resp != nil {
+			f.resp.Shared = f.dups > 0
+		}
+		g.mu.Unlock()
+
+		close(f.done)
+	}()
+
+	f.resp, f.err = send()
+}
+
+// interrupted reports whether the request of the flight was canceled or
+// timed out.
+func (f *flight) interrupted() bool {
+	return errors.Is(f.err, context.Canceled) ||
+		errors.Is(f.err, context.DeadlineExceeded)
+}
+
+// result returns a copy of the response of the flight, so the callers do
+// not share the slices and maps they may modify.
+func (f *flight) result() (*Response, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	resp := *f.resp
+	resp.Header = f.resp.Header.Clone()
+	resp.Body = bytes.Clone(f.resp.Body)
+	resp.Trace = slices.Clone(f.resp.Trace)
+
+	return &resp, nil
+}
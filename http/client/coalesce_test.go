@@ -0,0 +1,222 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitlab.com/iglou.eu/goulc/http/client"
+)
+
+// jsonBody is an Unmarshaler recording the body it was given.
+type jsonBody struct{ body string }
+
+func (jsonBody) Name() string { return "jsonBody" }
+
+func (j *jsonBody) Unmarshal(_ int, _ http.Header, body []byte) error {
+	j.body = string(body)
+	return nil
+}
+
+func TestOptions_Coalesce(t *testing.T) {
+	var hits atomic.Int32
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if r.URL.Path == "/jwks" {
+			<-release
+		}
+		w.Header().Set("X-Vault", r.Header.Get("X-Vault"))
+		w.Write([]byte(`{"keys":[]}`))
+	}))
+	defer ts.Close()
+
+	c, err := client.New(context.Background(), ts.URL, nil,
+		&client.Options{Coalesce: true}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	const callers = 8
+	responses := make([]*client.Response, callers)
+	bodies := make([]jsonBody, callers)
+	errs := make([]error, callers)
+
+	var wg sync.WaitGroup
+	for i := range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			responses[i], errs[i] = c.R().Path("/jwks").Do(http.MethodGet, &bodies[i])
+		}()
+	}
+
+	// Every caller waits for the first one before the server answers
+	for client.FlightWaiters(&c) != callers-1 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if n := hits.Load(); n != 1 {
+		t.Errorf("server hits = %d, want 1", n)
+	}
+	for i, resp := range responses {
+		if errs[i] != nil {
+			t.Fatalf("Do() error = %v", errs[i])
+		}
+		if !resp.Shared || string(resp.Body) != `{"keys":[]}` ||
+			bodies[i].body != `{"keys":[]}` || resp.BodyUml != &bodies[i] {
+			t.Errorf("Do() response %d = %+v", i, resp)
+		}
+	}
+
+	// Every caller gets its own copy
+	responses[0].Body[0] = '['
+	responses[0].Header.Set("X-Vault", "13")
+	if responses[1].Body[0] != '{' || responses[1].Header.Get("X-Vault") != "" {
+		t.Error("Do() responses share their body or headers")
+	}
+
+	// A request alone is not shared, nor the other methods
+	for _, method := range []string{http.MethodGet, http.MethodPost} {
+		resp, err := c.Do(method, nil, nil)
+		if err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+		if resp.Shared {
+			t.Errorf("Do() %s response shared alone", method)
+		}
+	}
+}
+
+func TestOptions_CoalesceInterrupted(t *testing.T) {
+	var hits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The first request never gets an answer
+		if hits.Add(1) == 1 {
+			<-r.Context().Done()
+			return
+		}
+		w.Write([]byte(`{"keys":[]}`))
+	}))
+	defer ts.Close()
+
+	c, err := client.New(context.Background(), ts.URL, nil,
+		&client.Options{Coalesce: true, Timeout: 100 * time.Millisecond}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	leaderErr := make(chan error, 1)
+	go func() {
+		_, err := c.Do(http.MethodGet, nil, nil)
+		leaderErr <- err
+	}()
+	for hits.Load() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	waiter := make(chan *client.Response, 1)
+	go func() {
+		resp, err := c.Do(http.MethodGet, nil, nil)
+		if err != nil {
+			t.Errorf("Do() waiter error = %v", err)
+		}
+		waiter <- resp
+	}()
+	for client.FlightWaiters(&c) != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The timeout of the first request is not given to the waiter, it
+	// sends the request again
+	if err := <-leaderErr; err == nil {
+		t.Error("Do() first request error = nil, want a timeout")
+	}
+	if resp := <-waiter; resp == nil || string(resp.Body) != `{"keys":[]}` || hits.Load() != 2 {
+		t.Errorf("Do() waiter = %+v after %d requests, want the second answer", resp, hits.Load())
+	}
+}
+
+func TestOptions_CoalesceKey(t *testing.T) {
+	var hits atomic.Int32
+	var arrived sync.WaitGroup
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		arrived.Done()
+		<-release
+		w.Write([]byte(r.URL.RawQuery + " " + r.Header.Get("X-Vault")))
+	}))
+	defer ts.Close()
+
+	c, err := client.New(context.Background(), ts.URL, nil,
+		&client.Options{Coalesce: true}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	// The requests differing by query, header or method are not coalesced
+	requests := []struct {
+		req    client.Request
+		method string
+		want   string
+	}{
+		{c.R().Query("id", "1"), http.MethodGet, "id=1 "},
+		{c.R().Query("id", "2"), http.MethodGet, "id=2 "},
+		{c.R().Query("id", "1").Header("X-Vault", "13"), http.MethodGet, "id=1 13"},
+		{c.R().Query("id", "1"), http.MethodHead, ""},
+	}
+
+	arrived.Add(len(requests))
+	var wg sync.WaitGroup
+	for _, r := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := r.req.Do(r.method, nil)
+			if err != nil {
+				t.Errorf("Do() error = %v", err)
+				return
+			}
+			if resp.Shared || string(resp.Body) != r.want {
+				t.Errorf("Do() = %q, shared %v, want %q", resp.Body, resp.Shared, r.want)
+			}
+		}()
+	}
+
+	arrived.Wait()
+	close(release)
+	wg.Wait()
+
+	if n := hits.Load(); n != int32(len(requests)) {
+		t.Errorf("server hits = %d, want %d", n, len(requests))
+	}
+}
@@ -0,0 +1,399 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+// Package config loads the configuration of an http client from JSON, YAML
+// and environment variables, so services do not have to declare their own
+// configuration struct and copy its fields to client.Options.
+//
+// Example of YAML configuration:
+//
+//	url: https://api.example.com/v1
+//	timeout: 10s
+//	max_response_size: 10MiB
+//	header:
+//	  User-Agent: vault-tec/1.0
+//	auth:
+//	  type: oauth2_client_credentials
+//	  client_id: overseer
+//	  # client_secret is set with APP_AUTH_CLIENT_SECRET
+//	  endpoint:
+//	    url: https://auth.example.com
+//	    auth: /oauth/token
+//
+// Any value can be overridden by an environment variable named after the
+// path of its key, such as `APP_AUTH_CLIENT_SECRET` with the `APP` prefix.
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gitlab.com/iglou.eu/goulc/bytesize"
+	"gitlab.com/iglou.eu/goulc/duration"
+	"gitlab.com/iglou.eu/goulc/hided"
+	"gitlab.com/iglou.eu/goulc/http/client"
+	"gitlab.com/iglou.eu/goulc/http/client/auth"
+	"gitlab.com/iglou.eu/goulc/http/client/auth/oauth2"
+)
+
+const (
+	// AuthBasic builds an auth.Basic authenticator
+	AuthBasic = "basic"
+	// AuthDigest builds an auth.DigestChallenge authenticator, answering
+	// the challenges of the server
+	AuthDigest = "digest"
+	// AuthOAuth2ClientCredentials builds an oauth2.ClientCredentials
+	// authenticator
+	AuthOAuth2ClientCredentials = "oauth2_client_credentials"
+)
+
+var (
+	// ErrInvalidConfig is returned when the configuration cannot be decoded
+	ErrInvalidConfig = errors.New("invalid http client configuration")
+
+	// ErrUnsupportedFormat is returned when the file extension is unknown
+	ErrUnsupportedFormat = errors.New("unsupported configuration format")
+
+	// ErrUnknownAuthType is returned when the authentication type is unknown
+	ErrUnknownAuthType = errors.New("unknown authentication type")
+
+	// ErrNoEnvPrefix is returned when the environment is read without
+	// a prefix, the generic variables such as URL or TIMEOUT are never read
+	ErrNoEnvPrefix = errors.New("an environment variable prefix is required")
+)
+
+// Config is the serializable configuration of a client, its fields mirror
+// client.Options.
+type Config struct {
+	// URL is the server URL, see client.New
+	URL string `json:"url"`
+
+	// Header are the headers sent with every request
+	Header map[string]string `json:"header,omitempty"`
+
+	OnlyHTTPS        bool              `json:"only_https"`
+	Follow           bool              `json:"follow"`
+	FollowAuth       bool              `json:"follow_auth"`
+	FollowReferer    bool              `json:"follow_referer"`
+	MaxRedirect      int               `json:"max_redirect"`
+	Timeout          duration.Duration `json:"timeout"`
+	DisableTLSVerify bool              `json:"disable_tls_verify"`
+	Idempotency      bool              `json:"idempotency"`
+	Coalesce         bool              `json:"coalesce"`
+
+	// Protocol is the name of a client.Protocol: auto, http/1.1,
+	// prefer-h2, h2 or h2c
+	Protocol string `json:"protocol"`
+
+	MaxResponseSize     bytesize.Size `json:"max_response_size"`
+	MaxDecompressedSize bytesize.Size `json:"max_decompressed_size"`
+
+	// Bandwidth caps the bytes per second when not zero
+	Bandwidth bytesize.Size `json:"bandwidth"`
+
+	// DialGuard enables the SSRF guard with its allow and deny lists
+	DialGuard *DialGuard `json:"dial_guard,omitempty"`
+
+	// Cookies enables a cookie jar, persisted in CookieFile if set
+	Cookies    bool   `json:"cookies"`
+	CookieFile string `json:"cookie_file,omitempty"`
+
+	// Auth configures the authenticator, if any
+	Auth *Auth `json:"auth,omitempty"`
+}
+
+// Auth declares an authenticator by its type, only the fields of the type
+// are used.
+type Auth struct {
+	// Type is AuthBasic, AuthDigest or AuthOAuth2ClientCredentials
+	Type string `json:"type"`
+
+	// Username and Password are used by the basic and digest types
+	Username string       `json:"username,omitempty"`
+	Password hided.String `json:"password,omitempty"`
+
+	// ClientID, ClientSecret, Scopes and Endpoint are used by
+	// the oauth2 type
+	ClientID     string       `json:"client_id,omitempty"`
+	ClientSecret hided.String `json:"client_secret,omitempty"`
+	Scopes       []string     `json:"scopes,omitempty"`
+	Endpoint     *Endpoint    `json:"endpoint,omitempty"`
+
+	// ClientAuth is where the client credentials are sent, "header"
+	// (default) or "body"
+	ClientAuth string `json:"client_auth,omitempty"`
+}
+
+// DialGuard lists the CIDRs of client.NewDialGuard.
+type DialGuard struct {
+	Allow []string `json:"allow,omitempty"`
+	Deny  []string `json:"deny,omitempty"`
+}
+
+// Endpoint mirrors oauth2.Endpoint.
+type Endpoint struct {
+	URL     string `json:"url"`
+	Auth    string `json:"auth"`
+	Refresh string `json:"refresh,omitempty"`
+}
+
+// Default returns the configuration matching client.OptDefault.
+func Default() Config {
+	opt := client.OptDefault
+
+	return Config{
+		OnlyHTTPS:        opt.OnlyHTTPS,
+		Follow:           opt.Follow,
+		FollowAuth:       opt.FollowAuth,
+		FollowReferer:    opt.FollowReferer,
+		MaxRedirect:      opt.MaxRedirect,
+		Timeout:          duration.Duration{Duration: opt.Timeout},
+		DisableTLSVerify: opt.DisableTLSVerify,
+		Protocol:         opt.Protocol.String(),
+	}
+}
+
+// FromJSON decodes a JSON configuration over the default one.
+// Unknown keys are rejected.
+func FromJSON(data []byte) (Config, error) {
+	cfg := Default()
+	return cfg, cfg.decodeJSON(data)
+}
+
+// FromYAML decodes a YAML configuration over the default one.
+// Unknown keys are rejected, anchors, aliases and merge keys are supported.
+func FromYAML(data []byte) (Config, error) {
+	cfg := Default()
+	return cfg, cfg.decodeYAML(data)
+}
+
+// FromEnv decodes the environment variables with the given prefix over the
+// default configuration, see Config.ApplyEnv. The prefix must not be empty.
+func FromEnv(prefix string) (Config, error) {
+	cfg := Default()
+	return cfg, cfg.ApplyEnv(prefix)
+}
+
+// Load reads the configuration file, JSON or YAML according to its
+// extension, then applies the environment variables with the given prefix.
+// An empty filename only reads the environment, an empty prefix skips it.
+func Load(filename, envPrefix string) (Config, error) {
+	cfg := Default()
+
+	if filename != "" {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return Config{}, errors.Join(ErrInvalidConfig, err)
+		}
+
+		switch strings.ToLower(filepath.Ext(filename)) {
+		case ".json":
+			err = cfg.decodeJSON(data)
+		case ".yaml", ".yml":
+			err = cfg.decodeYAML(data)
+		default:
+			err = errors.Join(ErrUnsupportedFormat,
+				errors.New("unknown extension of "+filename))
+		}
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	if envPrefix != "" {
+		if err := cfg.ApplyEnv(envPrefix); err != nil {
+			return Config{}, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// decodeJSON decodes the JSON data over the configuration.
+func (c *Config) decodeJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(c); err != nil {
+		return errors.Join(ErrInvalidConfig, err)
+	}
+
+	return nil
+}
+
+// decodeYAML decodes the YAML data over the configuration.
+func (c *Config) decodeYAML(data []byte) error {
+	tree, err := parseYAML(data)
+	if err != nil {
+		return errors.Join(ErrInvalidConfig, err)
+	}
+
+	if err := decodeTree(c, tree); err != nil {
+		return errors.Join(ErrInvalidConfig, err)
+	}
+
+	return nil
+}
+
+// Options returns the client options of the configuration.
+func (c Config) Options() (*client.Options, error) {
+	protocol, err := parseProtocol(c.Protocol)
+	if err != nil {
+		return nil, err
+	}
+
+	opt := &client.Options{
+		OnlyHTTPS:           c.OnlyHTTPS,
+		Follow:              c.Follow,
+		FollowAuth:          c.FollowAuth,
+		FollowReferer:       c.FollowReferer,
+		MaxRedirect:         c.MaxRedirect,
+		Timeout:             c.Timeout.Duration,
+		DisableTLSVerify:    c.DisableTLSVerify,
+		Protocol:            protocol,
+		MaxResponseSize:     c.MaxResponseSize,
+		MaxDecompressedSize: c.MaxDecompressedSize,
+		Idempotency:         c.Idempotency,
+		Coalesce:            c.Coalesce,
+	}
+
+	if c.Bandwidth.Bytes() != 0 {
+		if opt.Bandwidth, err = client.NewBandwidth(c.Bandwidth); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.DialGuard != nil {
+		if opt.DialGuard, err = client.NewDialGuard(
+			c.DialGuard.Allow, c.DialGuard.Deny); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.Cookies || c.CookieFile != "" {
+		if opt.Jar, err = client.NewCookieJar(&client.CookieJarOptions{
+			Filename: c.CookieFile,
+			AutoSave: c.CookieFile != "",
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return opt, nil
+}
+
+// Authenticator builds the authenticator of the configuration, it returns
+// nil when no authentication is configured. The logger is used by the
+// OAuth2 authenticator, if nil the default logger is used.
+func (c Config) Authenticator(logger *slog.Logger) (auth.Authenticator, error) {
+	if c.Auth == nil || c.Auth.Type == "" {
+		return nil, nil
+	}
+	a := c.Auth
+
+	switch a.Type {
+	case AuthBasic:
+		basic, err := auth.NewBasic(a.Username, a.Password)
+		if err != nil {
+			return nil, err
+		}
+		return &basic, nil
+
+	case AuthDigest:
+		digest, err := auth.NewDigestChallenge(a.Username, string(a.Password))
+		if err != nil {
+			return nil, err
+		}
+		return &digest, nil
+
+	case AuthOAuth2ClientCredentials:
+		clientAuth := oauth2.ClientInHeader
+		switch a.ClientAuth {
+		case "", "header":
+		case "body":
+			clientAuth = oauth2.ClientInBody
+		default:
+			return nil, errors.Join(ErrInvalidConfig,
+				errors.New("unknown client_auth "+a.ClientAuth))
+		}
+
+		var endpoint oauth2.Endpoint
+		if a.Endpoint != nil {
+			endpoint = oauth2.Endpoint{
+				URL:     a.Endpoint.URL,
+				Auth:    a.Endpoint.Auth,
+				Refresh: a.Endpoint.Refresh,
+			}
+		}
+
+		return oauth2.NewClientCredentials(clientAuth, oauth2.Config{
+			ClientID:     a.ClientID,
+			ClientSecret: a.ClientSecret,
+			Scopes:       a.Scopes,
+			Endpoint:     endpoint,
+		}, logger, nil)
+	}
+
+	return nil, errors.Join(ErrUnknownAuthType, errors.New("got "+a.Type))
+}
+
+// New creates a client from the configuration, see client.New.
+func (c Config) New(ctx context.Context, logger *slog.Logger) (client.Client, error) {
+	opt, err := c.Options()
+	if err != nil {
+		return client.Client{}, err
+	}
+
+	authenticator, err := c.Authenticator(logger)
+	if err != nil {
+		return client.Client{}, err
+	}
+
+	main, err := client.New(ctx, c.URL, authenticator, opt, logger)
+	if err != nil {
+		return client.Client{}, err
+	}
+
+	for key, value := range c.Header {
+		main.Header.Set(key, value)
+	}
+
+	return main, nil
+}
+
+// parseProtocol returns the client.Protocol named `name`.
+func parseProtocol(name string) (client.Protocol, error) {
+	if name == "" {
+		return client.ProtoAuto, nil
+	}
+
+	for p := client.ProtoAuto; p <= client.ProtoH2C; p++ {
+		if strings.EqualFold(p.String(), name) {
+			return p, nil
+		}
+	}
+
+	return 0, errors.Join(client.ErrInvalidProtocol, errors.New("got "+name))
+}
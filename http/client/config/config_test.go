@@ -0,0 +1,331 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package config_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/iglou.eu/goulc/http/client"
+	"gitlab.com/iglou.eu/goulc/http/client/auth"
+	"gitlab.com/iglou.eu/goulc/http/client/auth/oauth2"
+	"gitlab.com/iglou.eu/goulc/http/client/config"
+)
+
+const jsonConfig = `{
+	"url": "https://vault13.wasteland/api",
+	"header": {"User-Agent": "pip-boy/3000"},
+	"timeout": "10s",
+	"max_redirect": 5,
+	"protocol": "h2",
+	"max_response_size": "10MiB",
+	"bandwidth": "1MiB",
+	"idempotency": true,
+	"coalesce": true,
+	"auth": {
+		"type": "basic",
+		"username": "overseer",
+		"password": "water-chip"
+	}
+}`
+
+const yamlConfig = `
+# Vault 13 API
+url: https://vault13.wasteland/api
+header:
+  User-Agent: "pip-boy/3000"
+timeout: 10s
+max_redirect: 5
+protocol: h2   # HTTP/2 only
+max_response_size: 10MiB
+bandwidth: 1MiB
+idempotency: true
+coalesce: true
+auth:
+  type: basic
+  username: overseer
+  password: 'water-chip'
+`
+
+func TestFromJSON(t *testing.T) {
+	cfg, err := config.FromJSON([]byte(jsonConfig))
+	if err != nil {
+		t.Fatalf("FromJSON() error = %v", err)
+	}
+
+	opt, err := cfg.Options()
+	if err != nil {
+		t.Fatalf("Options() error = %v", err)
+	}
+
+	// The unset options keep their default value
+	if !opt.OnlyHTTPS || !opt.Follow || opt.MaxRedirect != 5 ||
+		opt.Timeout != 10*time.Second || opt.Protocol != client.ProtoHTTP2Only ||
+		opt.MaxResponseSize.Bytes() != 10<<20 || opt.Bandwidth.Rate().Bytes() != 1<<20 ||
+		!opt.Idempotency || !opt.Coalesce || opt.Jar != nil {
+		t.Errorf("Options() = %+v", opt)
+	}
+
+	a, err := cfg.Authenticator(nil)
+	if err != nil {
+		t.Fatalf("Authenticator() error = %v", err)
+	}
+	basic, ok := a.(*auth.Basic)
+	if !ok || basic.UserID != "overseer" || basic.Password != "water-chip" {
+		t.Errorf("Authenticator() = %#v, want basic overseer", a)
+	}
+
+	// The secrets are hidden when printed
+	if s := fmt.Sprintf("%v", *cfg.Auth); strings.Contains(s, "water-chip") {
+		t.Errorf("printed Auth = %s, leaks the password", s)
+	}
+}
+
+func TestFromYAML(t *testing.T) {
+	fromJSON, _ := config.FromJSON([]byte(jsonConfig))
+
+	cfg, err := config.FromYAML([]byte(yamlConfig))
+	if err != nil {
+		t.Fatalf("FromYAML() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(cfg, fromJSON) {
+		t.Errorf("FromYAML() = %+v, want %+v", cfg, fromJSON)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "client.yml")
+	_ = os.WriteFile(filename, []byte(`
+url: https://vault13.wasteland
+auth:
+  type: oauth2_client_credentials
+  client_id: overseer
+  scopes:
+    - water
+    - chip
+  endpoint:
+    url: https://auth.wasteland
+    auth: /token
+`), 0o600)
+
+	t.Setenv("VAULT_TIMEOUT", "3s")
+	t.Setenv("VAULT_MAX_DECOMPRESSED_SIZE", "2048")
+	t.Setenv("VAULT_HEADER", "X-Vault=13, X-Dweller=101")
+	t.Setenv("VAULT_AUTH_CLIENT_SECRET", "garden-of-eden")
+	t.Setenv("VAULT_AUTH_CLIENT_AUTH", "body")
+
+	cfg, err := config.Load(filename, "vault")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	if cfg.Timeout.Duration != 3*time.Second || cfg.MaxDecompressedSize.Bytes() != 2048 ||
+		cfg.Header["X-Vault"] != "13" || cfg.Header["X-Dweller"] != "101" {
+		t.Errorf("Load() = %+v", cfg)
+	}
+
+	a, err := cfg.Authenticator(nil)
+	if err != nil {
+		t.Fatalf("Authenticator() error = %v", err)
+	}
+	cc, ok := a.(*oauth2.ClientCredentials)
+	if !ok || cc.Config.ClientID != "overseer" || cc.Config.ClientSecret != "garden-of-eden" ||
+		!reflect.DeepEqual(cc.Config.Scopes, []string{"water", "chip"}) ||
+		cc.Config.Endpoint.Auth != "/token" || cc.ClientAuth != oauth2.ClientInBody {
+		t.Errorf("Authenticator() = %+v, want the oauth2 client credentials", a)
+	}
+
+	// The environment alone declares an authenticator
+	t.Setenv("VAULT_AUTH_CLIENT_AUTH", "")
+	cfg, err = config.Load("", "VAULT")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Auth == nil || cfg.Auth.ClientSecret != "garden-of-eden" {
+		t.Errorf("Load().Auth = %+v, want the secret from the environment", cfg.Auth)
+	}
+}
+
+func TestConfig_Authenticator(t *testing.T) {
+	tests := []struct {
+		name      string
+		auth      *config.Auth
+		want      string
+		wantErrIs error
+	}{
+		{name: "none", auth: nil},
+		{
+			name: "digest",
+			auth: &config.Auth{Type: config.AuthDigest, Username: "harold", Password: "bob"},
+			want: auth.DigestChallengeName,
+		},
+		{
+			name:      "missing digest username",
+			auth:      &config.Auth{Type: config.AuthDigest, Password: "bob"},
+			wantErrIs: auth.ErrNoUserID,
+		},
+		{
+			name:      "missing password",
+			auth:      &config.Auth{Type: config.AuthBasic, Username: "harold"},
+			wantErrIs: auth.ErrNoPassword,
+		},
+		{
+			name:      "unknown type",
+			auth:      &config.Auth{Type: "kerberos"},
+			wantErrIs: config.ErrUnknownAuthType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.Auth = tt.auth
+
+			a, err := cfg.Authenticator(nil)
+			if !errors.Is(err, tt.wantErrIs) {
+				t.Fatalf("Authenticator() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if tt.wantErrIs != nil {
+				return
+			}
+
+			if (a == nil) != (tt.want == "") || a != nil && a.Name() != tt.want {
+				t.Errorf("Authenticator() = %v, want %q", a, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_New(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if !ok || user != "overseer" || password != "water-chip" ||
+			r.Header.Get("User-Agent") != "pip-boy/3000" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer ts.Close()
+
+	cfg, err := config.FromYAML([]byte(yamlConfig))
+	if err != nil {
+		t.Fatalf("FromYAML() error = %v", err)
+	}
+	cfg.URL = ts.URL
+	cfg.OnlyHTTPS = false
+	cfg.Protocol = "auto"
+	cfg.Bandwidth = cfg.MaxDecompressedSize
+
+	c, err := cfg.New(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.Do(http.MethodGet, nil, nil)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Do() status = %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestConfig_Errors(t *testing.T) {
+	dir := t.TempDir()
+	toml := filepath.Join(dir, "client.toml")
+	_ = os.WriteFile(toml, []byte(`url = "https://vault13.wasteland"`), 0o600)
+
+	if _, err := config.FromJSON([]byte(`{"tiemout": "1s"}`)); !errors.Is(err, config.ErrInvalidConfig) {
+		t.Errorf("FromJSON() unknown key error = %v, want %v", err, config.ErrInvalidConfig)
+	}
+	if _, err := config.FromJSON([]byte(`{"timeout": "soon"}`)); !errors.Is(err, config.ErrInvalidConfig) {
+		t.Errorf("FromJSON() invalid duration error = %v, want %v", err, config.ErrInvalidConfig)
+	}
+	if _, err := config.Load(toml, ""); !errors.Is(err, config.ErrUnsupportedFormat) {
+		t.Errorf("Load() error = %v, want %v", err, config.ErrUnsupportedFormat)
+	}
+
+	// The generic variables are never read
+	t.Setenv("URL", "https://elsewhere.example")
+	if _, err := config.FromEnv(""); !errors.Is(err, config.ErrNoEnvPrefix) {
+		t.Errorf("FromEnv() error = %v, want %v", err, config.ErrNoEnvPrefix)
+	}
+	if cfg, err := config.Load("", ""); err != nil || cfg.URL != "" {
+		t.Errorf("Load() = %q, %v, want the environment skipped", cfg.URL, err)
+	}
+
+	t.Setenv("VAULT_MAX_REDIRECT", "many")
+	if _, err := config.FromEnv("VAULT"); !errors.Is(err, config.ErrInvalidConfig) {
+		t.Errorf("FromEnv() error = %v, want %v", err, config.ErrInvalidConfig)
+	}
+
+	cfg := config.Default()
+	cfg.Protocol = "spdy"
+	if _, err := cfg.Options(); !errors.Is(err, client.ErrInvalidProtocol) {
+		t.Errorf("Options() error = %v, want %v", err, client.ErrInvalidProtocol)
+	}
+
+	cfg = config.Default()
+	cfg.DialGuard = &config.DialGuard{Deny: []string{"vault-13"}}
+	if _, err := cfg.Options(); !errors.Is(err, client.ErrInvalidCIDR) {
+		t.Errorf("Options() error = %v, want %v", err, client.ErrInvalidCIDR)
+	}
+}
+
+func TestConfig_DialGuard(t *testing.T) {
+	cfg, err := config.FromYAML([]byte(`
+dial_guard:
+  allow: [10.13.0.0/16]
+  deny:
+    - 203.0.113.0/24
+`))
+	if err != nil {
+		t.Fatalf("FromYAML() error = %v", err)
+	}
+
+	opt, err := cfg.Options()
+	if err != nil {
+		t.Fatalf("Options() error = %v", err)
+	}
+
+	for addr, denied := range map[string]bool{
+		"10.13.0.1":   false,
+		"10.14.0.1":   true,
+		"203.0.113.7": true,
+		"8.8.8.8":     false,
+	} {
+		err := opt.DialGuard.Check(netip.MustParseAddr(addr))
+		if errors.Is(err, client.ErrDestinationDenied) != denied {
+			t.Errorf("DialGuard.Check(%s) error = %v, want denied %v", addr, err, denied)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var jsonUnmarshaler = reflect.TypeFor[json.Unmarshaler]()
+
+// ApplyEnv overrides the configuration with the environment variables
+// named after the path of the keys, upper-cased and joined with "_", and
+// prefixed with `prefix`: APP_TIMEOUT, APP_AUTH_TYPE,
+// APP_AUTH_ENDPOINT_URL... The lists are comma-separated and the maps are
+// comma-separated key=value pairs, such as
+// APP_HEADER="User-Agent=vault-tec/1.0,X-Vault=13". The prefix must not
+// be empty.
+func (c *Config) ApplyEnv(prefix string) error {
+	if prefix == "" {
+		return ErrNoEnvPrefix
+	}
+
+	if err := applyEnv(reflect.ValueOf(c).Elem(), strings.ToUpper(prefix)); err != nil {
+		return errors.Join(ErrInvalidConfig, err)
+	}
+
+	return nil
+}
+
+// applyEnv sets the fields of the struct `v` from the environment.
+func applyEnv(v reflect.Value, prefix string) error {
+	t := v.Type()
+	for i := range t.NumField() {
+		key := keyName(t.Field(i))
+		if key == "" {
+			continue
+		}
+
+		name := prefix + "_" + strings.ToUpper(key)
+
+		field := v.Field(i)
+		if isBlock(field.Type()) {
+			if err := applyEnvBlock(field, name); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if value, ok := os.LookupEnv(name); ok {
+			if err := decodeScalar(field, value, name); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// applyEnvBlock sets a nested block from the environment, a nil block is
+// only allocated when one of its fields is set.
+func applyEnvBlock(field reflect.Value, prefix string) error {
+	if field.Kind() != reflect.Pointer {
+		return applyEnv(field, prefix)
+	}
+
+	if !field.IsNil() {
+		return applyEnv(field.Elem(), prefix)
+	}
+
+	block := reflect.New(field.Type().Elem())
+	if err := applyEnv(block.Elem(), prefix); err != nil {
+		return err
+	}
+	if !block.Elem().IsZero() {
+		field.Set(block)
+	}
+
+	return nil
+}
+
+// decodeTree decodes the tree built by parseYAML into `v`, a pointer.
+// The scalars of the tree are strings converted according to the type of
+// the destination, or nil for the null values.
+func decodeTree(v any, tree any) error {
+	return decodeValue(reflect.ValueOf(v).Elem(), tree, "")
+}
+
+// decodeValue decodes a node of the tree into `v`.
+func decodeValue(v reflect.Value, node any, path string) error {
+	if node == nil {
+		v.SetZero()
+		return nil
+	}
+
+	if v.Kind() == reflect.Pointer && !v.Type().Implements(jsonUnmarshaler) {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return decodeValue(v.Elem(), node, path)
+	}
+
+	switch node := node.(type) {
+	case map[string]any:
+		return decodeMap(v, node, path)
+
+	case []any:
+		if v.Kind() != reflect.Slice {
+			return errors.New(path + ": unexpected list")
+		}
+
+		list := reflect.MakeSlice(v.Type(), len(node), len(node))
+		for i, item := range node {
+			if err := decodeValue(list.Index(i), item,
+				path+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+		v.Set(list)
+
+	case string:
+		return decodeScalar(v, node, path)
+	}
+
+	return nil
+}
+
+// decodeMap decodes a mapping into a struct or a map.
+func decodeMap(v reflect.Value, node map[string]any, path string) error {
+	switch {
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		if v.IsNil() {
+			v.Set(reflect.MakeMapWithSize(v.Type(), len(node)))
+		}
+
+		for key, item := range node {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := decodeValue(elem, item, joinPath(path, key)); err != nil {
+				return err
+			}
+			v.SetMapIndex(reflect.ValueOf(key).Convert(v.Type().Key()), elem)
+		}
+
+	case isBlock(v.Type()):
+		t := v.Type()
+	keys:
+		for key, item := range node {
+			for i := range t.NumField() {
+				if keyName(t.Field(i)) == key {
+					if err := decodeValue(v.Field(i), item, joinPath(path, key)); err != nil {
+						return err
+					}
+					continue keys
+				}
+			}
+
+			return errors.New(joinPath(path, key) + ": unknown key")
+		}
+
+	default:
+		return errors.New(path + ": unexpected mapping")
+	}
+
+	return nil
+}
+
+// decodeScalar decodes a scalar into `v`. The lists and maps can be given
+// as comma-separated values, as done by the environment variables.
+func decodeScalar(v reflect.Value, s, path string) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+
+	// Durations and sizes are decoded by their JSON decoder
+	if u, ok := v.Addr().Interface().(json.Unmarshaler); ok {
+		data := strconv.Quote(s)
+		if _, err := strconv.ParseFloat(s, 64); err == nil {
+			data = s
+		}
+
+		if err := u.UnmarshalJSON([]byte(data)); err != nil {
+			return errors.Join(errors.New(path+": invalid value "+s), err)
+		}
+		return nil
+	}
+
+	var err error
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+
+	case reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(s); err == nil {
+			v.SetBool(b)
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		if i, err = strconv.ParseInt(s, 10, v.Type().Bits()); err == nil {
+			v.SetInt(i)
+		}
+
+	case reflect.Slice:
+		var items []any
+		for item := range strings.SplitSeq(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		return decodeValue(v, items, path)
+
+	case reflect.Map:
+		items := make(map[string]any)
+		for pair := range strings.SplitSeq(s, ",") {
+			key, value, found := strings.Cut(pair, "=")
+			if !found {
+				return errors.New(path + ": expected key=value pairs")
+			}
+			items[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+		return decodeMap(v, items, path)
+
+	default:
+		return errors.New(path + ": unsupported type " + v.Type().String())
+	}
+
+	if err != nil {
+		return errors.Join(errors.New(path+": invalid value "+s), err)
+	}
+
+	return nil
+}
+
+// isBlock reports if the type is a nested configuration block: a struct,
+// or a pointer to a struct, not decoded from a scalar.
+func isBlock(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Struct &&
+		!reflect.PointerTo(t).Implements(jsonUnmarshaler)
+}
+
+// keyName returns the configuration key of the field, from its JSON tag.
+func keyName(f reflect.StructField) string {
+	if !f.IsExported() {
+		return ""
+	}
+
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return f.Name
+	}
+
+	return name
+}
+
+// joinPath joins the keys of a path.
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package config
+
+import (
+	"errors"
+	"strconv"
+
+	"go.yaml.in/yaml/v3"
+)
+
+const (
+	// yamlMaxNodes bounds the nodes of a document once its aliases are
+	// expanded, to refuse the documents crafted to exhaust the memory
+	yamlMaxNodes = 1 << 16
+
+	// yamlMergeTag is the tag of the "<<" merge keys
+	yamlMergeTag = "!!merge"
+
+	// yamlNullTag is the tag of the null scalars
+	yamlNullTag = "!!null"
+)
+
+// yamlTree converts a YAML document into a tree of map[string]any, []any,
+// string and nil values. The scalars are kept as strings, they are
+// converted according to their destination.
+type yamlTree struct {
+	nodes int
+}
+
+// parseYAML parses a YAML document into the tree decoded by decodeTree.
+// An empty document is an empty mapping.
+func parseYAML(data []byte) (any, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	if doc.Kind == 0 || len(doc.Content) == 0 {
+		return map[string]any{}, nil
+	}
+
+	t := &yamlTree{}
+	return t.convert(doc.Content[0])
+}
+
+func (t *yamlTree) errorf(n *yaml.Node, msg string) error {
+	return errors.New("yaml line " + strconv.Itoa(n.Line) + ": " + msg)
+}
+
+// convert returns the tree of the node, aliases being expanded.
+func (t *yamlTree) convert(n *yaml.Node) (any, error) {
+	if t.nodes++; t.nodes > yamlMaxNodes {
+		return nil, t.errorf(n, "too many nodes")
+	}
+
+	switch n.Kind {
+	case yaml.AliasNode:
+		return t.convert(n.Alias)
+
+	case yaml.ScalarNode:
+		if n.Tag == yamlNullTag {
+			return nil, nil
+		}
+		return n.Value, nil
+
+	case yaml.SequenceNode:
+		list := make([]any, 0, len(n.Content))
+		for _, item := range n.Content {
+			value, err := t.convert(item)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		return list, nil
+
+	case yaml.MappingNode:
+		mapping := make(map[string]any, len(n.Content)/2)
+		if err := t.mapping(mapping, n, false); err != nil {
+			return nil, err
+		}
+		return mapping, nil
+	}
+
+	return nil, t.errorf(n, "unexpected node")
+}
+
+// mapping adds the pairs of the mapping node to `mapping`, then the pairs
+// of its merge keys. The merged pairs never replace the keys already set.
+func (t *yamlTree) mapping(mapping map[string]any, n *yaml.Node, merged bool) error {
+	var merges []*yaml.Node
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+
+		if key.Kind == yaml.ScalarNode && key.Tag == yamlMergeTag {
+			merges = append(merges, value)
+			continue
+		}
+
+		if key.Kind != yaml.ScalarNode {
+			return t.errorf(key, "keys must be scalars")
+		}
+
+		if _, ok := mapping[key.Value]; ok {
+			if merged {
+				continue
+			}
+			return t.errorf(key, "duplicate key "+strconv.Quote(key.Value))
+		}
+
+		tree, err := t.convert(value)
+		if err != nil {
+			return err
+		}
+		mapping[key.Value] = tree
+	}
+
+	for _, value := range merges {
+		if err := t.merge(mapping, value); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// merge adds the pairs of a "<<" merge key, a mapping or a list of
+// mappings, to `mapping`.
+func (t *yamlTree) merge(mapping map[string]any, n *yaml.Node) error {
+	if t.nodes++; t.nodes > yamlMaxNodes {
+		return t.errorf(n, "too many nodes")
+	}
+
+	switch n.Kind {
+	case yaml.AliasNode:
+		return t.merge(mapping, n.Alias)
+
+	case yaml.MappingNode:
+		return t.mapping(mapping, n, true)
+
+	case yaml.SequenceNode:
+		for _, item := range n.Content {
+			if item.Kind == yaml.SequenceNode {
+				return t.errorf(item, "merge lists must contain mappings")
+			}
+			if err := t.merge(mapping, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return t.errorf(n, "merge values must be mappings")
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package config_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gitlab.com/iglou.eu/goulc/http/client/config"
+)
+
+func TestFromYAML_Syntax(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want func(cfg *config.Config)
+	}{
+		{
+			name: "document marker and comments",
+			yaml: "---\n# comment\nurl: \"https://vault13.wasteland/#top\" # trailing\n",
+			want: func(cfg *config.Config) { cfg.URL = "https://vault13.wasteland/#top" },
+		},
+		{
+			name: "sequence at the key indentation",
+			yaml: "auth:\n  type: oauth2_client_credentials\n  scopes:\n  - water\n  - 'chip # 2'\n",
+			want: func(cfg *config.Config) {
+				cfg.Auth = &config.Auth{Type: "oauth2_client_credentials", Scopes: []string{"water", "chip # 2"}}
+			},
+		},
+		{
+			name: "flow sequence and quoted key",
+			yaml: "auth:\n  \"type\": basic\n  scopes: [water, \"chip\"]\n",
+			want: func(cfg *config.Config) {
+				cfg.Auth = &config.Auth{Type: "basic", Scopes: []string{"water", "chip"}}
+			},
+		},
+		{
+			name: "null resets a value",
+			yaml: "protocol: ~\nfollow: false\nmax_redirect: 0\n",
+			want: func(cfg *config.Config) {
+				cfg.Protocol = ""
+				cfg.Follow = false
+				cfg.MaxRedirect = 0
+			},
+		},
+		{
+			name: "multi-line scalar",
+			yaml: "url: >-\n  https://vault13.wasteland\n",
+			want: func(cfg *config.Config) { cfg.URL = "https://vault13.wasteland" },
+		},
+		{
+			name: "anchors and merge keys",
+			yaml: "dial_guard:\n  allow: [&lan 10.0.0.0/8]\n  deny: [10.13.0.0/16]\n" +
+				"auth:\n  <<: {type: digest, username: overseer}\n  type: basic\n  scopes: [*lan]\n",
+			want: func(cfg *config.Config) {
+				cfg.DialGuard = &config.DialGuard{Allow: []string{"10.0.0.0/8"}, Deny: []string{"10.13.0.0/16"}}
+				cfg.Auth = &config.Auth{Type: "basic", Username: "overseer", Scopes: []string{"10.0.0.0/8"}}
+			},
+		},
+		{
+			name: "numbers as strings",
+			yaml: "auth:\n  type: basic\n  password: 1234\n",
+			want: func(cfg *config.Config) { cfg.Auth = &config.Auth{Type: "basic", Password: "1234"} },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := config.FromYAML([]byte(tt.yaml))
+			if err != nil {
+				t.Fatalf("FromYAML() error = %v", err)
+			}
+
+			want := config.Default()
+			tt.want(&want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("FromYAML() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestFromYAML_Errors(t *testing.T) {
+	for name, yaml := range map[string]string{
+		"unknown key":       "tiemout: 1s\n",
+		"bad indentation":   "url: https://vault13.wasteland\n  timeout: 1s\n",
+		"tab indentation":   "auth:\n\ttype: basic\n",
+		"duplicate key":     "url: a\nurl: b\n",
+		"missing separator": "url https://vault13.wasteland\n",
+		"invalid bool":      "follow: maybe\n",
+		"non-scalar key":    "? [url]\n: a\n",
+		"list as scalar":    "url:\n  - a\n",
+		"unterminated":      "url: \"https://vault13.wasteland\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := config.FromYAML([]byte(yaml)); !errors.Is(err, config.ErrInvalidConfig) {
+				t.Errorf("FromYAML() error = %v, want %v", err, config.ErrInvalidConfig)
+			}
+		})
+	}
+}
+
+func TestFromYAML_AliasExpansion(t *testing.T) {
+	// Each level multiplies the nodes by 16 once the aliases are expanded
+	yaml := "a: &a [x, x, x, x, x, x, x, x, x, x, x, x, x, x, x, x]\n" +
+		"b: &b [*a, *a, *a, *a, *a, *a, *a, *a, *a, *a, *a, *a, *a, *a, *a, *a]\n" +
+		"c: &c [*b, *b, *b, *b, *b, *b, *b, *b, *b, *b, *b, *b, *b, *b, *b, *b]\n" +
+		"d: [*c, *c, *c, *c, *c, *c, *c, *c, *c, *c, *c, *c, *c, *c, *c, *c]\n"
+
+	_, err := config.FromYAML([]byte(yaml))
+	if !errors.Is(err, config.ErrInvalidConfig) || !strings.Contains(err.Error(), "too many nodes") {
+		t.Errorf("FromYAML() error = %v, want too many nodes", err)
+	}
+}
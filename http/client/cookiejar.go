@@ -0,0 +1,323 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"maps"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+
+	"gitlab.com/iglou.eu/goulc/hided"
+)
+
+const (
+	// cookieFileMode restricts the cookie file to its owner,
+	// it contains session secrets
+	cookieFileMode = 0o600
+)
+
+var (
+	// ErrCookieFile is returned when the cookie file cannot be read
+	// or written
+	ErrCookieFile = errors.New("cookie jar file error")
+)
+
+// Verify CookieJar implements http.CookieJar interface
+var _ http.CookieJar = &CookieJar{}
+
+// CookieJarOptions configures the CookieJar.
+type CookieJarOptions struct {
+	// Filename is the JSON file used to persist the cookies. If empty,
+	// the cookies are only kept in memory.
+	// Default: ""
+	Filename string
+
+	// AutoSave writes the file each time the jar is updated by a response.
+	// Default: false
+	AutoSave bool
+
+	// PublicSuffixList prevents cookies from being set for public suffixes
+	// such as "co.uk". If nil, golang.org/x/net/publicsuffix.List is used.
+	// Default: publicsuffix.List
+	PublicSuffixList cookiejar.PublicSuffixList
+}
+
+// storedCookie is a cookie persisted in the jar file.
+type storedCookie struct {
+	URL      string        `json:"url"`
+	Name     string        `json:"name"`
+	Value    hided.String  `json:"value"`
+	Domain   string        `json:"domain,omitempty"`
+	Path     string        `json:"path,omitempty"`
+	Expires  time.Time     `json:"expires,omitzero"`
+	Secure   bool          `json:"secure,omitempty"`
+	HttpOnly bool          `json:"http_only,omitempty"`
+	SameSite http.SameSite `json:"same_site,omitempty"`
+}
+
+// CookieJar is an RFC 6265 cookie jar built on net/http/cookiejar that can
+// be persisted to a JSON file. Set it in Options.Jar to share it between
+// a client and all its children.
+type CookieJar struct {
+	mu  sync.Mutex
+	jar *cookiejar.Jar
+	opt CookieJarOptions
+
+	cookies map[string]storedCookie
+}
+
+// NewCookieJar creates a CookieJar. If `opt` is nil, the jar is only kept in
+// memory. When a filename is provided and the file exists, the cookies it
+// contains are loaded, expired ones being dropped.
+func NewCookieJar(opt *CookieJarOptions) (*CookieJar, error) {
+	if opt == nil {
+		opt = &CookieJarOptions{}
+	}
+
+	list := opt.PublicSuffixList
+	if list == nil {
+		list = publicsuffix.List
+	}
+
+	jar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: list})
+	if err != nil {
+		return nil, err
+	}
+
+	j := &CookieJar{
+		jar:     jar,
+		opt:     *opt,
+		cookies: make(map[string]storedCookie),
+	}
+
+	if j.opt.Filename == "" {
+		return j, nil
+	}
+
+	if err := j.load(); err != nil {
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// SetCookies implements the http.CookieJar interface.
+func (j *CookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	j.jar.SetCookies(u, cookies)
+
+	j.mu.Lock()
+	now := time.Now()
+	for _, c := range cookies {
+		stored := storedCookie{
+			URL:      u.Scheme + "://" + u.Host + u.Path,
+			Name:     c.Name,
+			Value:    hided.String(c.Value),
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Expires:  c.Expires,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+			SameSite: c.SameSite,
+		}
+
+		// Max-Age has precedence over Expires
+		// RFC 6265 §5.3: https://www.rfc-editor.org/rfc/rfc6265#section-5.3
+		switch {
+		case c.MaxAge < 0:
+			stored.Expires = now
+		case c.MaxAge > 0:
+			stored.Expires = now.Add(time.Duration(c.MaxAge) * time.Second)
+		}
+
+		key := cookieKey(u, c)
+		if !stored.Expires.IsZero() && !stored.Expires.After(now) {
+			delete(j.cookies, key)
+			continue
+		}
+
+		// Only the cookies kept by the jar are persisted
+		if j.accepted(u, c) {
+			j.cookies[key] = stored
+		}
+	}
+	j.mu.Unlock()
+
+	if j.opt.AutoSave && j.opt.Filename != "" {
+		_ = j.Save()
+	}
+}
+
+// Cookies implements the http.CookieJar interface.
+func (j *CookieJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.jar.Cookies(u)
+}
+
+// Save writes the cookies to the jar file. Expired cookies are dropped.
+// It is a no-op when no filename is configured.
+func (j *CookieJar) Save() error {
+	if j.opt.Filename == "" {
+		return nil
+	}
+
+	j.mu.Lock()
+	now := time.Now()
+	cookies := make([]storedCookie, 0, len(j.cookies))
+	for _, key := range slices.Sorted(maps.Keys(j.cookies)) {
+		c := j.cookies[key]
+		if !c.Expires.IsZero() && !c.Expires.After(now) {
+			delete(j.cookies, key)
+			continue
+		}
+		cookies = append(cookies, c)
+	}
+	j.mu.Unlock()
+
+	data, err := json.MarshalIndent(cookies, "", "  ")
+	if err != nil {
+		return errors.Join(ErrCookieFile, err)
+	}
+
+	// Write to a temporary file first to never leave a truncated jar
+	tmp, err := os.CreateTemp(filepath.Dir(j.opt.Filename),
+		filepath.Base(j.opt.Filename)+".*")
+	if err != nil {
+		return errors.Join(ErrCookieFile, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Join(ErrCookieFile, err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return errors.Join(ErrCookieFile, err)
+	}
+
+	if err := os.Chmod(tmp.Name(), cookieFileMode); err != nil {
+		return errors.Join(ErrCookieFile, err)
+	}
+
+	if err := os.Rename(tmp.Name(), j.opt.Filename); err != nil {
+		return errors.Join(ErrCookieFile, err)
+	}
+
+	return nil
+}
+
+// load reads the cookies from the jar file, a missing file is not an error.
+func (j *CookieJar) load() error {
+	data, err := os.ReadFile(j.opt.Filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return errors.Join(ErrCookieFile, err)
+	}
+
+	var cookies []storedCookie
+	if err := json.Unmarshal(data, &cookies); err != nil {
+		return errors.Join(ErrCookieFile,
+			errors.New("failed to parse "+j.opt.Filename), err)
+	}
+
+	now := time.Now()
+	for _, c := range cookies {
+		if !c.Expires.IsZero() && !c.Expires.After(now) {
+			continue
+		}
+
+		u, err := url.Parse(c.URL)
+		if err != nil {
+			continue
+		}
+
+		cookie := &http.Cookie{
+			Name:     c.Name,
+			Value:    string(c.Value),
+			Domain:   c.Domain,
+			Path:     c.Path,
+			Expires:  c.Expires,
+			Secure:   c.Secure,
+			HttpOnly: c.HttpOnly,
+			SameSite: c.SameSite,
+		}
+
+		j.jar.SetCookies(u, []*http.Cookie{cookie})
+		if j.accepted(u, cookie) {
+			j.cookies[cookieKey(u, cookie)] = c
+		}
+	}
+
+	return nil
+}
+
+// accepted reports whether the jar kept the cookie set for `u`, by looking
+// for it in the cookies the jar would send back.
+func (j *CookieJar) accepted(u *url.URL, c *http.Cookie) bool {
+	target := *u
+	if c.Secure {
+		target.Scheme = "https"
+	}
+	if strings.HasPrefix(c.Path, "/") {
+		target.Path = c.Path
+	}
+
+	for _, kept := range j.jar.Cookies(&target) {
+		if kept.Name == c.Name && kept.Value == c.Value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// cookieKey identifies a cookie by its domain, path and name.
+func cookieKey(u *url.URL, c *http.Cookie) string {
+	domain := strings.TrimPrefix(strings.ToLower(c.Domain), ".")
+	if domain == "" {
+		domain = u.Hostname()
+	}
+
+	return domain + ";" + c.Path + ";" + c.Name
+}
+
+// maskCookies returns the cookies names with their values obfuscated,
+// to be safely logged.
+func maskCookies(cookies []*http.Cookie) []string {
+	masked := make([]string, 0, len(cookies))
+	for _, c := range cookies {
+		masked = append(masked, c.Name+"="+hided.String(c.Value).String())
+	}
+
+	return masked
+}
@@ -0,0 +1,200 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/iglou.eu/goulc/http/client"
+)
+
+func newCookieServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "bhaal-spawn", Path: "/"})
+			http.SetCookie(w, &http.Cookie{Name: "shortlived", Value: "gone", Path: "/", MaxAge: -1})
+			http.Redirect(w, r, "/tavern", http.StatusFound)
+		case "/tavern":
+			if c, err := r.Cookie("session"); err != nil || c.Value != "bhaal-spawn" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func TestClient_CookieJar(t *testing.T) {
+	ts := newCookieServer(t)
+
+	jar, err := client.NewCookieJar(nil)
+	if err != nil {
+		t.Fatalf("NewCookieJar() error = %v", err)
+	}
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	c, err := client.New(context.Background(), ts.URL, nil,
+		&client.Options{Timeout: time.Second, Follow: true, MaxRedirect: 3, Jar: jar}, logger)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	// The cookie is sent back on the redirect of the same request
+	resp, err := c.NewChild("/login").Do(http.MethodGet, nil, nil)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Do() status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	// The jar is shared with the children
+	resp, err = c.NewChild("/tavern").Do(http.MethodGet, nil, nil)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Do() status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	// Values never reach the logs
+	if !strings.Contains(logs.String(), "session=***") {
+		t.Errorf("logs do not contain the masked cookie: %s", logs.String())
+	}
+	if strings.Contains(logs.String(), "bhaal-spawn") {
+		t.Errorf("logs leak the cookie value: %s", logs.String())
+	}
+}
+
+func TestCookieJar_Persistence(t *testing.T) {
+	ts := newCookieServer(t)
+	filename := filepath.Join(t.TempDir(), "cookies.json")
+
+	jar, err := client.NewCookieJar(&client.CookieJarOptions{Filename: filename, AutoSave: true})
+	if err != nil {
+		t.Fatalf("NewCookieJar() error = %v", err)
+	}
+
+	c, err := client.New(context.Background(), ts.URL, nil,
+		&client.Options{Timeout: time.Second, Follow: true, MaxRedirect: 3, Jar: jar}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	if _, err := c.NewChild("/login").Do(http.MethodGet, nil, nil); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatalf("AutoSave did not write the file: %v", err)
+	}
+	if info.Mode().Perm() != 0o600 {
+		t.Errorf("file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	// Reloading restores the session and drops the expired cookies
+	reloaded, err := client.NewCookieJar(&client.CookieJarOptions{Filename: filename})
+	if err != nil {
+		t.Fatalf("NewCookieJar() error = %v", err)
+	}
+
+	u, _ := url.Parse(ts.URL)
+	cookies := reloaded.Cookies(u)
+	if len(cookies) != 1 || cookies[0].Name != "session" || cookies[0].Value != "bhaal-spawn" {
+		t.Errorf("Cookies() = %v, want session=bhaal-spawn", cookies)
+	}
+
+	// Expired cookies are not loaded
+	expired := `[{"url":"` + ts.URL + `","name":"old","value":"x","expires":"2001-01-01T00:00:00Z"}]`
+	if err := os.WriteFile(filename, []byte(expired), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err = client.NewCookieJar(&client.CookieJarOptions{Filename: filename})
+	if err != nil {
+		t.Fatalf("NewCookieJar() error = %v", err)
+	}
+	if cookies := reloaded.Cookies(u); len(cookies) != 0 {
+		t.Errorf("Cookies() = %v, want none", cookies)
+	}
+
+	// A corrupted file is reported
+	if err := os.WriteFile(filename, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.NewCookieJar(&client.CookieJarOptions{Filename: filename}); !errors.Is(err, client.ErrCookieFile) {
+		t.Errorf("NewCookieJar() error = %v, want %v", err, client.ErrCookieFile)
+	}
+}
+
+func TestCookieJar_Rejected(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "cookies.json")
+
+	jar, err := client.NewCookieJar(&client.CookieJarOptions{Filename: filename})
+	if err != nil {
+		t.Fatalf("NewCookieJar() error = %v", err)
+	}
+
+	// The public suffix and foreign domain cookies are rejected by the jar
+	u, _ := url.Parse("https://shop.example.co.uk/cart")
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "suffix", Value: "tracker", Domain: "co.uk"},
+		{Name: "foreign", Value: "tracker", Domain: "other.org"},
+		{Name: "session", Value: "bhaal-spawn", Domain: "example.co.uk", Path: "/"},
+	})
+
+	if err := jar.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "tracker") {
+		t.Errorf("Save() persisted rejected cookies: %s", data)
+	}
+	if !strings.Contains(string(data), `"session"`) {
+		t.Errorf("Save() did not persist the session: %s", data)
+	}
+}
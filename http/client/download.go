@@ -0,0 +1,503 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/iglou.eu/goulc/bytesize"
+)
+
+const (
+	// DownloadPartSuffix is appended to the filename while downloading
+	DownloadPartSuffix = ".part"
+
+	// DownloadValidatorSuffix is appended to the partial filename to store
+	// the validator used to resume the download
+	DownloadValidatorSuffix = ".validator"
+
+	// downloadBufferSize is the size of the copy buffer
+	downloadBufferSize = 32 * 1024
+)
+
+var (
+	// ErrDownloadStatus is returned when the server answers with an
+	// unexpected status
+	ErrDownloadStatus = errors.New("unexpected download status")
+
+	// ErrDownloadRange is returned when the server answers with an
+	// invalid Content-Range header
+	ErrDownloadRange = errors.New("invalid download content range")
+
+	// ErrDownloadIncomplete is returned when the downloaded size does not
+	// match the size announced by the server
+	ErrDownloadIncomplete = errors.New("incomplete download")
+
+	// ErrDownloadChanged is returned when the resource changed while
+	// downloading to a writer that cannot be rewound
+	ErrDownloadChanged = errors.New("downloaded resource changed")
+
+	// ErrDownloadNoValidator is returned when an interrupted download to
+	// a writer that cannot be rewound has no validator to be resumed
+	ErrDownloadNoValidator = errors.New("no validator to resume the download")
+)
+
+// DownloadDefault defines the default options for downloads.
+var DownloadDefault = DownloadOptions{
+	MaxRetries:       5,
+	RetryDelay:       time.Second,
+	IdleTimeout:      30 * time.Second,
+	ProgressInterval: ProgressDefaultInterval,
+}
+
+// DownloadOptions configures Client.Download and Client.DownloadFile.
+type DownloadOptions struct {
+	// MaxRetries is the number of times an interrupted transfer is resumed.
+	// Default: 5
+	MaxRetries int
+
+	// RetryDelay is the delay before resuming an interrupted transfer.
+	// Default: 1s
+	RetryDelay time.Duration
+
+	// IdleTimeout interrupts the transfer when no data is received for this
+	// duration. The client Timeout is not applied to downloads.
+	// Default: 30s
+	IdleTimeout time.Duration
+
+	// ProgressInterval is the minimum delay between two Progress calls.
+	// Default: 500ms
+	ProgressInterval time.Duration
+
+	// Progress is called during the transfer and once it is complete.
+	// The client DownloadProgress option is used when nil.
+	// Default: nil
+	Progress func(Progress)
+}
+
+// download holds the state of a transfer across its attempts.
+type download struct {
+	main *Client
+	opt  DownloadOptions
+	w    io.Writer
+
+	// offset is the number of bytes written
+	offset int64
+
+	// total is the size of the resource, -1 when unknown
+	total int64
+
+	// validator is the strong ETag or the Last-Modified date of the resource
+	validator string
+
+	// rewind restarts the writer from zero, nil if it cannot be rewound
+	rewind func() error
+
+	// saveValidator persists the validator, nil if not needed
+	saveValidator func(string) error
+
+	meter *progressMeter
+}
+
+// Download streams the resource at the client URL to `w`. An interrupted
+// transfer is resumed with a Range request, guarded by If-Range to never
+// mix two versions of the resource. Without a strong ETag or Last-Modified
+// date, the transfer is restarted from the beginning. The final size is
+// checked against the Content-Length or Content-Range announced by the
+// server.
+// If `opt` is nil, DownloadDefault is used.
+//
+// It returns the number of bytes written.
+func (main *Client) Download(w io.Writer, opt *DownloadOptions) (bytesize.Size, error) {
+	d := newDownload(main, w, opt)
+	err := d.run()
+
+	return bytesize.NewInt(d.offset), err
+}
+
+// DownloadFile downloads the resource at the client URL to `filename`.
+// The data is written to a partial file, renamed once the download is
+// complete, so that a later call resumes where an interrupted one stopped.
+// If `opt` is nil, DownloadDefault is used.
+//
+// It returns the size of the downloaded file.
+func (main *Client) DownloadFile(filename string, opt *DownloadOptions) (bytesize.Size, error) {
+	partName := filename + DownloadPartSuffix
+	validatorName := partName + DownloadValidatorSuffix
+
+	f, err := os.OpenFile(partName, os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return bytesize.Size{}, err
+	}
+	defer f.Close()
+
+	d := newDownload(main, f, opt)
+	d.rewind = func() error {
+		if err := f.Truncate(0); err != nil {
+			return err
+		}
+		_, err := f.Seek(0, io.SeekStart)
+		return err
+	}
+	d.saveValidator = func(validator string) error {
+		return os.WriteFile(validatorName, []byte(validator), 0o644)
+	}
+
+	// A partial file is only resumed when its validator is known
+	validator, err := os.ReadFile(validatorName)
+	switch {
+	case err == nil && len(validator) > 0:
+		if d.offset, err = f.Seek(0, io.SeekEnd); err != nil {
+			return bytesize.Size{}, err
+		}
+		d.validator = string(validator)
+		main.logger.Debug("resuming partial download",
+			"filename", partName,
+			"offset", d.offset)
+	case err == nil || errors.Is(err, fs.ErrNotExist):
+		if err := d.rewind(); err != nil {
+			return bytesize.Size{}, err
+		}
+	default:
+		return bytesize.Size{}, err
+	}
+
+	if err := d.run(); err != nil {
+		return bytesize.NewInt(d.offset), err
+	}
+
+	if err := f.Close(); err != nil {
+		return bytesize.NewInt(d.offset), err
+	}
+
+	if err := os.Rename(partName, filename); err != nil {
+		return bytesize.NewInt(d.offset), err
+	}
+	_ = os.Remove(validatorName)
+
+	return bytesize.NewInt(d.offset), nil
+}
+
+// newDownload initializes the download state.
+func newDownload(main *Client, w io.Writer, opt *DownloadOptions) *download {
+	if opt == nil {
+		opt = &DownloadDefault
+	}
+
+	return &download{
+		main:  main,
+		opt:   *opt,
+		w:     w,
+		total: -1,
+	}
+}
+
+// run downloads the resource, resuming it until it is complete or the
+// retries are exhausted.
+func (d *download) run() error {
+	if d.main.IsClosed() {
+		return ErrClientClosed
+	}
+
+	atomic.AddInt32(&d.main.activeRequests, 1)
+	defer atomic.AddInt32(&d.main.activeRequests, -1)
+
+	progress := d.opt.Progress
+	if progress == nil {
+		progress = d.main.Options.DownloadProgress
+	}
+	d.meter = newProgressMeter(progress, d.opt.ProgressInterval, d.offset)
+
+	var err error
+	for attempt := 0; ; attempt++ {
+		var retry bool
+		if retry, err = d.attempt(); err == nil {
+			d.meter.report(d.offset, d.total, true)
+			return nil
+		}
+
+		if !retry || attempt >= d.opt.MaxRetries ||
+			d.main.context.Err() != nil {
+			return err
+		}
+
+		d.main.logger.Debug("download interrupted, resuming",
+			"url", d.main.URL.String(),
+			"offset", d.offset,
+			"attempt", attempt+1,
+			"error", err)
+
+		select {
+		case <-d.main.context.Done():
+			return errors.Join(err, d.main.context.Err())
+		case <-time.After(d.opt.RetryDelay):
+		}
+	}
+}
+
+// attempt performs a single request, it reports if a failure can be resumed.
+func (d *download) attempt() (bool, error) {
+	c := d.main.Clone()
+	if c == nil {
+		return false, ErrClientClosed
+	}
+	defer c.Close()
+
+	// The transfer duration is unknown, the idle timeout applies instead
+	c.Options.Timeout = 0
+	c.Options.MaxDecompressedSize = bytesize.Size{}
+
+	// Without a validator, a range could mix two versions of the resource
+	if d.offset > 0 && d.validator == "" {
+		if err := d.restart(ErrDownloadNoValidator); err != nil {
+			return false, err
+		}
+	}
+
+	// Byte offsets must match the resource as stored by the server
+	c.Header.Set("Accept-Encoding", "identity")
+	if d.offset > 0 {
+		c.Header.Set("Range", "bytes="+strconv.FormatInt(d.offset, 10)+"-")
+		c.Header.Set("If-Range", d.validator)
+	}
+
+	if len(c.Query) > 0 {
+		c.URL.RawQuery = c.Query.Encode()
+	}
+
+	res, _, err := c.dispatch(http.MethodGet, nil)
+	if err != nil {
+		return true, err
+	}
+	defer res.Body.Close()
+
+	var skip int64
+	switch res.StatusCode {
+	case http.StatusPartialContent:
+		start, total, err := parseContentRange(res.Header.Get("Content-Range"))
+		if err != nil || start != d.offset {
+			return false, errors.Join(ErrDownloadRange,
+				errors.New("got "+res.Header.Get("Content-Range")+
+					" for offset "+strconv.FormatInt(d.offset, 10)), err)
+		}
+		d.total = total
+
+	case http.StatusOK:
+		d.total = res.ContentLength
+
+		// The range was ignored, the resource is sent from the beginning
+		if d.offset > 0 {
+			if validator := responseValidator(res); validator != "" &&
+				validator == d.validator {
+				skip = d.offset
+			} else if err := d.restart(ErrDownloadChanged); err != nil {
+				return false, err
+			}
+		}
+
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The resource was already complete
+		_, total, err := parseContentRange(res.Header.Get("Content-Range"))
+		if err == nil && d.offset > 0 && total == d.offset {
+			d.total = total
+			return false, nil
+		}
+
+		if err := d.restart(ErrDownloadChanged); err != nil {
+			return false, err
+		}
+		return true, errors.Join(ErrDownloadStatus,
+			errors.New("got "+res.Status))
+
+	default:
+		return res.StatusCode >= http.StatusInternalServerError,
+			errors.Join(ErrDownloadStatus, errors.New("got "+res.Status))
+	}
+
+	if validator := responseValidator(res); validator != d.validator {
+		d.validator = validator
+		if d.saveValidator != nil {
+			if err := d.saveValidator(validator); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	if err := d.copy(c, res.Body, skip); err != nil {
+		return true, err
+	}
+
+	if d.total >= 0 && d.offset != d.total {
+		return true, errors.Join(ErrDownloadIncomplete,
+			errors.New("got "+strconv.FormatInt(d.offset, 10)+
+				" bytes of "+strconv.FormatInt(d.total, 10)))
+	}
+
+	return false, nil
+}
+
+// restart rewinds the writer when the resource must be downloaded again,
+// `reason` is returned when the writer cannot be rewound.
+func (d *download) restart(reason error) error {
+	if d.offset == 0 {
+		return nil
+	}
+
+	if d.rewind == nil {
+		return errors.Join(reason,
+			errors.New("the writer cannot be rewound"))
+	}
+
+	d.main.logger.Debug("restarting download",
+		"url", d.main.URL.String(),
+		"reason", reason,
+		"discarded", d.offset)
+
+	if err := d.rewind(); err != nil {
+		return err
+	}
+
+	d.offset = 0
+	d.validator = ""
+	d.meter.reset(0)
+
+	return nil
+}
+
+// copy writes the body to the writer, skipping the bytes already written.
+// The attempt is canceled when no data is received for the idle timeout,
+// the bandwidth throttling waits are not counted as idle.
+func (d *download) copy(c *Client, body io.Reader, skip int64) error {
+	bandwidth := c.Options.Bandwidth
+
+	var idle *time.Timer
+	if d.opt.IdleTimeout > 0 {
+		idle = time.AfterFunc(d.opt.IdleTimeout, c.cancel)
+		defer idle.Stop()
+	}
+
+	buf := make([]byte, downloadBufferSize)
+	for {
+		chunk := buf
+		if bandwidth != nil {
+			chunk = buf[:min(len(buf), bandwidth.chunk())]
+		}
+
+		n, err := body.Read(chunk)
+		if n > 0 && bandwidth != nil {
+			if idle != nil {
+				idle.Stop()
+			}
+			if werr := bandwidth.WaitN(c.context, n); werr != nil {
+				return werr
+			}
+		}
+		if idle != nil {
+			idle.Reset(d.opt.IdleTimeout)
+		}
+
+		data := chunk[:n]
+		if skip > 0 {
+			skipped := min(skip, int64(n))
+			data = data[skipped:]
+			skip -= skipped
+		}
+
+		if len(data) > 0 {
+			written, werr := d.w.Write(data)
+			d.offset += int64(written)
+			if werr != nil {
+				return werr
+			}
+			d.meter.report(d.offset, d.total, false)
+		}
+
+		if errors.Is(err, io.EOF) {
+			if skip > 0 {
+				return io.ErrUnexpectedEOF
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// responseValidator returns the validator usable with If-Range.
+// Weak ETags cannot be used to resume a download.
+// RFC 9110 §13.1.5: https://www.rfc-editor.org/rfc/rfc9110#section-13.1.5
+func responseValidator(res *http.Response) string {
+	if etag := res.Header.Get("ETag"); etag != "" {
+		if strings.HasPrefix(etag, "W/") {
+			return ""
+		}
+		return etag
+	}
+
+	return res.Header.Get("Last-Modified")
+}
+
+// parseContentRange parses a "bytes start-end/total" or "bytes */total"
+// header. The total is -1 when unknown.
+// RFC 9110 §14.4: https://www.rfc-editor.org/rfc/rfc9110#section-14.4
+func parseContentRange(header string) (int64, int64, error) {
+	spec, found := strings.CutPrefix(header, "bytes ")
+	if !found {
+		return 0, 0, ErrDownloadRange
+	}
+
+	byteRange, size, found := strings.Cut(spec, "/")
+	if !found {
+		return 0, 0, ErrDownloadRange
+	}
+
+	total := int64(-1)
+	if size != "*" {
+		var err error
+		if total, err = strconv.ParseInt(size, 10, 64); err != nil {
+			return 0, 0, errors.Join(ErrDownloadRange, err)
+		}
+	}
+
+	if byteRange == "*" {
+		return 0, total, nil
+	}
+
+	first, _, found := strings.Cut(byteRange, "-")
+	if !found {
+		return 0, 0, ErrDownloadRange
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, errors.Join(ErrDownloadRange, err)
+	}
+
+	return start, total, nil
+}
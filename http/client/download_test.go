@@ -0,0 +1,291 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitlab.com/iglou.eu/goulc/bytesize"
+	"gitlab.com/iglou.eu/goulc/http/client"
+)
+
+// flakyServer serves the dataset with Range support, the first `failures`
+// requests are interrupted halfway.
+type flakyServer struct {
+	mu       sync.Mutex
+	data     []byte
+	etag     string
+	failures int
+	ranges   []string
+}
+
+func (s *flakyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	data, etag := s.data, s.etag
+	s.ranges = append(s.ranges, r.Header.Get("Range"))
+	fail := s.failures > 0
+	s.failures--
+	s.mu.Unlock()
+
+	w.Header().Set("ETag", etag)
+	if !fail {
+		http.ServeContent(w, r, "dataset.bin", time.Time{}, bytes.NewReader(data))
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	_, _ = w.Write(data[:len(data)/2])
+	w.(http.Flusher).Flush()
+	panic(http.ErrAbortHandler)
+}
+
+func (s *flakyServer) requestedRanges() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.ranges...)
+}
+
+func newDownloadClient(t *testing.T, url string) *client.Client {
+	t.Helper()
+
+	c, err := client.New(context.Background(), url, nil, &client.Options{Timeout: time.Second}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	return &c
+}
+
+func TestClient_DownloadFile(t *testing.T) {
+	dataset := bytes.Repeat([]byte("Baldur's Gate "), 10000)
+	srv := &flakyServer{data: dataset, etag: `"v1"`, failures: 2}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	c := newDownloadClient(t, ts.URL+"/dataset.bin")
+	filename := filepath.Join(t.TempDir(), "dataset.bin")
+
+	var last atomic.Value
+	opt := client.DownloadDefault
+	opt.RetryDelay = time.Millisecond
+	opt.Progress = func(p client.Progress) { last.Store(p) }
+
+	size, err := c.DownloadFile(filename, &opt)
+	if err != nil {
+		t.Fatalf("DownloadFile() error = %v", err)
+	}
+	if size.Bytes() != int64(len(dataset)) {
+		t.Errorf("DownloadFile() size = %v, want %d bytes", size, len(dataset))
+	}
+
+	got, err := os.ReadFile(filename)
+	if err != nil || !bytes.Equal(got, dataset) {
+		t.Fatalf("downloaded file = %d bytes %v, want %d bytes", len(got), err, len(dataset))
+	}
+	if _, err := os.Stat(filename + client.DownloadPartSuffix); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("partial file not removed: %v", err)
+	}
+
+	half := strconv.Itoa(len(dataset) / 2)
+	if ranges := srv.requestedRanges(); len(ranges) != 3 || ranges[0] != "" ||
+		ranges[1] != "bytes="+half+"-" || ranges[2] != "bytes="+half+"-" {
+		t.Errorf("Range headers = %q, want resume from %s", ranges, half)
+	}
+
+	p, _ := last.Load().(client.Progress)
+	if p.Transferred != size || p.Total != size || p.ETA.Duration != 0 {
+		t.Errorf("last Progress = %+v, want complete", p)
+	}
+}
+
+func TestClient_DownloadFileResume(t *testing.T) {
+	dataset := bytes.Repeat([]byte("Amn "), 5000)
+	srv := &flakyServer{data: dataset, etag: `"v1"`}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	c := newDownloadClient(t, ts.URL+"/dataset.bin")
+	dir := t.TempDir()
+
+	tests := []struct {
+		name      string
+		validator string
+		wantRange string
+	}{
+		{name: "same resource", validator: `"v1"`, wantRange: "bytes=1000-"},
+		{name: "changed resource", validator: `"v0"`, wantRange: "bytes=1000-"},
+		{name: "unknown validator", validator: "", wantRange: ""},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := filepath.Join(dir, strconv.Itoa(i))
+			part := filename + client.DownloadPartSuffix
+
+			// A previous run left a partial file, corrupted when stale
+			partial := bytes.Clone(dataset[:1000])
+			if tt.validator != `"v1"` {
+				partial = bytes.Repeat([]byte("X"), 1000)
+			}
+			_ = os.WriteFile(part, partial, 0o644)
+			if tt.validator != "" {
+				_ = os.WriteFile(part+client.DownloadValidatorSuffix, []byte(tt.validator), 0o644)
+			}
+
+			if _, err := c.DownloadFile(filename, nil); err != nil {
+				t.Fatalf("DownloadFile() error = %v", err)
+			}
+
+			got, _ := os.ReadFile(filename)
+			if !bytes.Equal(got, dataset) {
+				t.Errorf("downloaded file = %d bytes, want %d bytes", len(got), len(dataset))
+			}
+
+			ranges := srv.requestedRanges()
+			if ranges[len(ranges)-1] != tt.wantRange {
+				t.Errorf("Range header = %q, want %q", ranges[len(ranges)-1], tt.wantRange)
+			}
+		})
+	}
+}
+
+func TestClient_DownloadFileNoValidator(t *testing.T) {
+	dataset := bytes.Repeat([]byte("Athkatla "), 5000)
+	srv := &flakyServer{data: dataset, failures: 1}
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	c := newDownloadClient(t, ts.URL+"/dataset.bin")
+	filename := filepath.Join(t.TempDir(), "dataset.bin")
+
+	opt := client.DownloadDefault
+	opt.RetryDelay = time.Millisecond
+
+	if _, err := c.DownloadFile(filename, &opt); err != nil {
+		t.Fatalf("DownloadFile() error = %v", err)
+	}
+
+	got, _ := os.ReadFile(filename)
+	if !bytes.Equal(got, dataset) {
+		t.Errorf("downloaded file = %d bytes, want %d bytes", len(got), len(dataset))
+	}
+
+	// Without a validator, the interrupted transfer restarts from zero
+	if ranges := srv.requestedRanges(); len(ranges) != 2 || ranges[0] != "" || ranges[1] != "" {
+		t.Errorf("Range headers = %q, want no range", ranges)
+	}
+}
+
+func TestClient_DownloadBandwidthIdle(t *testing.T) {
+	dataset := bytes.Repeat([]byte("Imnesvale "), 10)
+	ts := httptest.NewServer(&flakyServer{data: dataset, etag: `"v1"`})
+	defer ts.Close()
+
+	c := newDownloadClient(t, ts.URL+"/dataset.bin")
+	c.Options.Bandwidth, _ = client.NewBandwidth(bytesize.NewInt(1000))
+
+	// Another transfer already reserved the next 200ms of the bandwidth
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_ = c.Options.Bandwidth.WaitN(canceled, 200)
+
+	opt := client.DownloadDefault
+	opt.MaxRetries = 0
+	opt.IdleTimeout = 100 * time.Millisecond
+
+	var buf bytes.Buffer
+	if _, err := c.Download(&buf, &opt); err != nil {
+		t.Fatalf("Download() error = %v, throttling counted as idle", err)
+	}
+	if !bytes.Equal(buf.Bytes(), dataset) {
+		t.Errorf("Download() = %d bytes, want %d bytes", buf.Len(), len(dataset))
+	}
+}
+
+func TestClient_DownloadErrors(t *testing.T) {
+	changing := &flakyServer{data: bytes.Repeat([]byte("Ulgoth's Beard "), 1000), etag: `"v1"`, failures: 1}
+	unvalidated := &flakyServer{data: bytes.Repeat([]byte("Trademeet "), 1000), failures: 1}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/changing":
+			// The resource is updated after the first interruption
+			defer func() {
+				changing.mu.Lock()
+				changing.etag = `"v2"`
+				changing.mu.Unlock()
+			}()
+			changing.ServeHTTP(w, r)
+		case "/unvalidated":
+			unvalidated.ServeHTTP(w, r)
+		case "/truncated":
+			// Every response ends cleanly before the announced size
+			contentRange, size := "bytes 0-99/200", 100
+			w.Header().Set("ETag", `"v1"`)
+			if r.Header.Get("Range") != "" {
+				contentRange, size = "bytes 100-149/200", 50
+			}
+			w.Header().Set("Content-Range", contentRange)
+			w.WriteHeader(http.StatusPartialContent)
+			_, _ = w.Write(make([]byte, size))
+		case "/missing":
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	opt := client.DownloadDefault
+	opt.MaxRetries = 1
+	opt.RetryDelay = time.Millisecond
+
+	tests := []struct {
+		path      string
+		wantErrIs error
+	}{
+		{path: "/changing", wantErrIs: client.ErrDownloadChanged},
+		{path: "/unvalidated", wantErrIs: client.ErrDownloadNoValidator},
+		{path: "/truncated", wantErrIs: client.ErrDownloadIncomplete},
+		{path: "/missing", wantErrIs: client.ErrDownloadStatus},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			c := newDownloadClient(t, ts.URL+tt.path)
+
+			var buf bytes.Buffer
+			if _, err := c.Download(&buf, &opt); !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("Download() error = %v, want %v", err, tt.wantErrIs)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client
+
+// Children returns the number of children registered in the client, to
+// check that closed children are released.
+func Children(c *Client) int {
+	c.Mu.RLock()
+	defer c.Mu.RUnlock()
+
+	return len(c.closer)
+}
+
+// FlightWaiters returns the number of requests waiting for a coalesced
+// request of the client.
+func FlightWaiters(c *Client) int {
+	c.flights.mu.Lock()
+	defer c.flights.mu.Unlock()
+
+	var waiters int
+	for _, f := range c.flights.flights {
+		waiters += f.dups
+	}
+
+	return waiters
+}
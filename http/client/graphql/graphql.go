@@ -0,0 +1,426 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+// Package graphql sends GraphQL queries and mutations through an http
+// client, decoding the data into a typed value and returning the GraphQL
+// errors as Go errors, with support for the automatic persisted queries.
+package graphql
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gitlab.com/iglou.eu/goulc/http/client"
+)
+
+const (
+	// RequestName is the identifier for Request Marshaler
+	RequestName = "graphql.Request"
+	// ResponseName is the identifier for Response Unmarshaler
+	ResponseName = "graphql.Response"
+
+	// ContentType is the content type of the requests
+	ContentType = "application/json"
+	// Accept lists the response content types of the GraphQL over HTTP
+	// specification, the legacy one last
+	Accept = "application/graphql-response+json, application/json"
+
+	// PersistedQueryVersion is the version of the automatic persisted
+	// queries protocol
+	PersistedQueryVersion = 1
+)
+
+// The error codes of a server not knowing the hash of a persisted query, or
+// not supporting them.
+const (
+	persistedQueryNotFound     = "PERSISTED_QUERY_NOT_FOUND"
+	persistedQueryNotSupported = "PERSISTED_QUERY_NOT_SUPPORTED"
+)
+
+var (
+	// ErrEmptyQuery is returned when an operation has no query
+	ErrEmptyQuery = errors.New("empty GraphQL query")
+
+	// ErrUnexpectedStatus is returned when the server answers with an error
+	// status code and without GraphQL errors
+	ErrUnexpectedStatus = errors.New("unexpected status code")
+
+	// ErrEmptyResponse is returned when the response has neither data nor
+	// errors
+	ErrEmptyResponse = errors.New("GraphQL response without data nor errors")
+)
+
+// Verify Request implements client.Marshaler interface
+var _ client.Marshaler = &Request{}
+
+// Verify Response implements client.Unmarshaler interface
+var _ client.Unmarshaler = &Response{}
+
+// Client sends the GraphQL operations to an endpoint, through a child of
+// the http/client Client given to New.
+type Client struct {
+	client    *client.Client
+	persisted bool
+}
+
+// Options holds the configuration of a GraphQL client.
+type Options struct {
+	// PersistedQueries sends the SHA-256 hash of the queries instead of
+	// their text, following the automatic persisted queries protocol: the
+	// query is only sent when the server does not know its hash yet, or
+	// does not support the persisted queries.
+	PersistedQueries bool
+}
+
+// New returns a Client sending the operations to the `path` endpoint of c,
+// such as "/graphql", through a child of c. The options may be nil.
+//
+// Example:
+//
+//	gql, err := graphql.New(&c, "/graphql", nil)
+//	if err != nil {
+//	    return err
+//	}
+//	defer gql.Close()
+//
+//	var data struct {
+//	    Pet struct{ Name string } `json:"pet"`
+//	}
+//	_, err = gql.Query(`query ($id: ID!) { pet(id: $id) { name } }`,
+//	    map[string]any{"id": 1}, &data)
+func New(c *client.Client, path string, opt *Options) (*Client, error) {
+	child := c.NewChild(path)
+	if child == nil {
+		return nil, client.ErrClientClosed
+	}
+
+	gql := &Client{client: child}
+	if opt != nil {
+		gql.persisted = opt.PersistedQueries
+	}
+
+	return gql, nil
+}
+
+// Close closes the child client, c is left open.
+func (gql *Client) Close() error {
+	return gql.client.Close()
+}
+
+// Query sends the `query` operation with its variables, see Do.
+func (gql *Client) Query(
+	query string, variables map[string]any, data any,
+) (*client.Response, error) {
+	return gql.Do(Request{Query: query, Variables: variables}, data)
+}
+
+// Mutate sends the `mutation` operation with its variables, see Do.
+func (gql *Client) Mutate(
+	mutation string, variables map[string]any, data any,
+) (*client.Response, error) {
+	return gql.Do(Request{Query: mutation, Variables: variables}, data)
+}
+
+// Do posts the operation and decodes the data of the response into `data`,
+// a pointer like for json.Unmarshal, or nil to ignore it.
+//
+// The GraphQL errors of the response are returned as Errors, whatever the
+// status code, the partial data being decoded anyway. An error status
+// without GraphQL errors returns ErrUnexpectedStatus. The response is
+// returned with these errors, its BodyUml being the *Response.
+func (gql *Client) Do(req Request, data any) (*client.Response, error) {
+	if strings.TrimSpace(req.Query) == "" {
+		return nil, ErrEmptyQuery
+	}
+
+	if !gql.persisted {
+		return gql.send(req, data)
+	}
+
+	// The hash is sent alone first, then with the query if the server
+	// does not know it yet
+	sum := sha256.Sum256([]byte(req.Query))
+	persisted := req.withPersistedQuery(hex.EncodeToString(sum[:]))
+	query := persisted.Query
+	persisted.Query = ""
+
+	resp, err := gql.send(persisted, data)
+
+	var gqlErrs Errors
+	if !errors.As(err, &gqlErrs) {
+		return resp, err
+	}
+	switch {
+	case gqlErrs.hasCode(persistedQueryNotFound, "PersistedQueryNotFound"):
+		persisted.Query = query
+		return gql.send(persisted, data)
+	case gqlErrs.hasCode(persistedQueryNotSupported, "PersistedQueryNotSupported"):
+		return gql.send(req, data)
+	}
+
+	return resp, err
+}
+
+// send posts the request and decodes the response.
+func (gql *Client) send(req Request, data any) (*client.Response, error) {
+	var body Response
+	resp, err := gql.client.R().
+		Header("Accept", Accept).
+		Marshal(&req).
+		Do(http.MethodPost, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(body.Errors) > 0 {
+		// The partial data is decoded along the errors
+		if err := body.decode(data); err != nil {
+			return resp, errors.Join(body.Errors, err)
+		}
+		return resp, body.Errors
+	}
+
+	if !resp.Success {
+		return resp, errors.Join(ErrUnexpectedStatus,
+			errors.New("got "+resp.Status))
+	}
+
+	if !body.hasData() {
+		return resp, ErrEmptyResponse
+	}
+
+	return resp, body.decode(data)
+}
+
+// Request is a GraphQL operation, sent as the JSON body of a POST request.
+type Request struct {
+	// Query is the GraphQL document, a query, mutation or subscription.
+	Query string `json:"query,omitempty"`
+
+	// OperationName selects the operation to run when the document
+	// contains several.
+	OperationName string `json:"operationName,omitempty"`
+
+	// Variables are the values of the operation variables.
+	Variables map[string]any `json:"variables,omitempty"`
+
+	// Extensions are the protocol extensions, such as the persisted
+	// query hash.
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// Name returns the identifier for this request type.
+func (_ Request) Name() string {
+	return RequestName
+}
+
+// ContentType returns the content type of the request body.
+func (_ Request) ContentType() string {
+	return ContentType
+}
+
+// Marshal serializes the request as JSON.
+func (r *Request) Marshal() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// withPersistedQuery returns a copy of the request with the persisted query
+// extension of `hash`, the other extensions are kept.
+func (r Request) withPersistedQuery(hash string) Request {
+	extensions := make(map[string]any, len(r.Extensions)+1)
+	for key, value := range r.Extensions {
+		extensions[key] = value
+	}
+	extensions["persistedQuery"] = map[string]any{
+		"version":    PersistedQueryVersion,
+		"sha256Hash": hash,
+	}
+	r.Extensions = extensions
+
+	return r
+}
+
+// Response is the body of a GraphQL response.
+type Response struct {
+	// Data is the raw result of the operation, decoded into the data
+	// given to Do.
+	Data json.RawMessage `json:"data,omitempty"`
+
+	// Errors are the errors raised by the operation.
+	Errors Errors `json:"errors,omitempty"`
+
+	// Extensions are the protocol extensions, such as tracing or cost
+	// information.
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// Name returns the identifier for this response type.
+func (_ Response) Name() string {
+	return ResponseName
+}
+
+// Unmarshal parses the JSON-encoded response body. A body that is not JSON
+// is only an error on a successful status code, the error status being
+// reported by Client.Do.
+func (r *Response) Unmarshal(status int, _ http.Header, body []byte) error {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(body, r); err != nil {
+		if status >= http.StatusBadRequest {
+			*r = Response{}
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+// hasData reports whether the response holds a data entry, null included.
+func (r *Response) hasData() bool {
+	return len(r.Data) > 0
+}
+
+// decode decodes the data into `data`, if any.
+func (r *Response) decode(data any) error {
+	if data == nil || !r.hasData() || bytes.Equal(r.Data, []byte("null")) {
+		return nil
+	}
+
+	return json.Unmarshal(r.Data, data)
+}
+
+// Location is the position of an error in the GraphQL document.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Path is the path of the response field an error is raised on, made of
+// the field names and of the list indexes, as string and int.
+type Path []any
+
+// UnmarshalJSON decodes the path, the list indexes as int.
+func (p *Path) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	path := make(Path, 0, len(raw))
+	for _, segment := range raw {
+		var index int
+		if err := json.Unmarshal(segment, &index); err == nil {
+			path = append(path, index)
+			continue
+		}
+
+		var field string
+		if err := json.Unmarshal(segment, &field); err != nil {
+			return err
+		}
+		path = append(path, field)
+	}
+	*p = path
+
+	return nil
+}
+
+// String returns the path segments joined by dots, such as "pets.0.name".
+func (p Path) String() string {
+	segments := make([]string, len(p))
+	for i, segment := range p {
+		switch segment := segment.(type) {
+		case int:
+			segments[i] = strconv.Itoa(segment)
+		case string:
+			segments[i] = segment
+		}
+	}
+
+	return strings.Join(segments, ".")
+}
+
+// Error is a GraphQL error of a response.
+type Error struct {
+	Message    string         `json:"message"`
+	Locations  []Location     `json:"locations,omitempty"`
+	Path       Path           `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// Error returns the message, with the path if any.
+func (e *Error) Error() string {
+	if len(e.Path) == 0 {
+		return e.Message
+	}
+
+	return e.Message + " at " + e.Path.String()
+}
+
+// Code returns the "code" extension of the error, empty if none.
+func (e *Error) Code() string {
+	code, _ := e.Extensions["code"].(string)
+	return code
+}
+
+// Errors are the GraphQL errors of a response, returned by Client.Do.
+// errors.As retrieves either the whole list or its first *Error.
+type Errors []*Error
+
+// Error returns the messages of the errors.
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+
+	return "graphql: " + strings.Join(messages, "; ")
+}
+
+// Unwrap returns the errors.
+func (e Errors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+
+	return errs
+}
+
+// hasCode reports whether one of the errors has the `code` extension or
+// the `message`, as the servers report the persisted query errors either
+// way.
+func (e Errors) hasCode(code, message string) bool {
+	for _, err := range e {
+		if err.Code() == code || err.Message == message {
+			return true
+		}
+	}
+
+	return false
+}
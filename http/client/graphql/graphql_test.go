@@ -0,0 +1,257 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package graphql_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"gitlab.com/iglou.eu/goulc/http/client"
+	"gitlab.com/iglou.eu/goulc/http/client/graphql"
+)
+
+// newClient returns a GraphQL client of the `handler` server.
+func newClient(
+	t *testing.T, handler http.HandlerFunc, opt *graphql.Options,
+) *graphql.Client {
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+
+	c, err := client.New(context.Background(), ts.URL, nil,
+		&client.Options{}, nil)
+	if err != nil {
+		t.Fatalf("client.New() error = %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	gql, err := graphql.New(&c, "/graphql", opt)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { gql.Close() })
+
+	return gql
+}
+
+// decodeRequest decodes the GraphQL request of `r`.
+func decodeRequest(t *testing.T, r *http.Request) graphql.Request {
+	var req graphql.Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		t.Errorf("invalid request body: %v", err)
+	}
+
+	return req
+}
+
+func TestClient_Query(t *testing.T) {
+	gql := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		req := decodeRequest(t, r)
+		if r.Method != http.MethodPost || r.URL.Path != "/graphql" ||
+			r.Header.Get("Content-Type") != graphql.ContentType ||
+			r.Header.Get("Accept") != graphql.Accept ||
+			req.Variables["id"] != "101" || req.OperationName != "" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"data":{"vault":{"number":101,"overseer":"Alphonse"}}}`))
+	}, nil)
+
+	var data struct {
+		Vault struct {
+			Number   int    `json:"number"`
+			Overseer string `json:"overseer"`
+		} `json:"vault"`
+	}
+	resp, err := gql.Query(`query ($id: ID!) { vault(id: $id) { number overseer } }`,
+		map[string]any{"id": "101"}, &data)
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if data.Vault.Number != 101 || data.Vault.Overseer != "Alphonse" {
+		t.Errorf("Query() data = %+v", data)
+	}
+	if _, ok := resp.BodyUml.(*graphql.Response); !ok {
+		t.Errorf("Query() BodyUml = %T, want *graphql.Response", resp.BodyUml)
+	}
+
+	if _, err := gql.Query(" ", nil, &data); !errors.Is(err, graphql.ErrEmptyQuery) {
+		t.Errorf("Query() error = %v, want %v", err, graphql.ErrEmptyQuery)
+	}
+}
+
+func TestClient_Errors(t *testing.T) {
+	const partial = `{
+		"data": {"dwellers": [{"name": "Butch"}, null]},
+		"errors": [{
+			"message": "dweller not found",
+			"locations": [{"line": 1, "column": 14}],
+			"path": ["dwellers", 1, "name"],
+			"extensions": {"code": "NOT_FOUND"}
+		}]
+	}`
+
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		wantErr   error
+		wantError string
+	}{
+		{"partial data with a 200", http.StatusOK, partial, nil, "graphql: dweller not found at dwellers.1.name"},
+		{"errors with a 400", http.StatusBadRequest, `{"errors":[{"message":"syntax error"}]}`, nil, "graphql: syntax error"},
+		{"status without errors", http.StatusBadGateway, `<html>bad gateway</html>`, graphql.ErrUnexpectedStatus, ""},
+		{"neither data nor errors", http.StatusOK, `{}`, graphql.ErrEmptyResponse, ""},
+		{"invalid body", http.StatusOK, `<html>`, client.ErrRequestFailed, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gql := newClient(t, func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}, nil)
+
+			var data struct {
+				Dwellers []*struct {
+					Name string `json:"name"`
+				} `json:"dwellers"`
+			}
+			_, err := gql.Mutate(`mutation { evict }`, nil, &data)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Mutate() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+
+			var gqlErrs graphql.Errors
+			if !errors.As(err, &gqlErrs) || err.Error() != tt.wantError {
+				t.Fatalf("Mutate() error = %v, want %q", err, tt.wantError)
+			}
+			if tt.body != partial {
+				return
+			}
+
+			var gqlErr *graphql.Error
+			if !errors.As(err, &gqlErr) || gqlErr.Code() != "NOT_FOUND" ||
+				len(gqlErr.Locations) != 1 || gqlErr.Locations[0].Column != 14 ||
+				gqlErr.Path[1] != 1 {
+				t.Errorf("Mutate() error = %#v", gqlErr)
+			}
+			if len(data.Dwellers) != 2 || data.Dwellers[0].Name != "Butch" {
+				t.Errorf("Mutate() partial data = %+v", data)
+			}
+		})
+	}
+}
+
+func TestClient_PersistedQueries(t *testing.T) {
+	const (
+		query = `{ vault { number } }`
+		hash  = "1303a0a219fdabaabd3e179af73df97a194684959a67f4e31f0b326b30b56527"
+	)
+
+	var mu sync.Mutex
+	var persisted map[string]string
+	var sent []string
+
+	gql := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		req := decodeRequest(t, r)
+		pq, _ := req.Extensions["persistedQuery"].(map[string]any)
+		sha, _ := pq["sha256Hash"].(string)
+
+		mu.Lock()
+		defer mu.Unlock()
+		sent = append(sent, req.Query)
+
+		if persisted == nil {
+			if pq != nil {
+				w.Write([]byte(`{"errors":[{"message":"PersistedQueryNotSupported"}]}`))
+				return
+			}
+			w.Write([]byte(`{"data":{"vault":{"number":13}}}`))
+			return
+		}
+		if pq["version"] != float64(graphql.PersistedQueryVersion) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if req.Query != "" {
+			persisted[sha] = req.Query
+		}
+		if _, ok := persisted[sha]; !ok {
+			w.Write([]byte(`{"errors":[{"message":"PersistedQueryNotFound",` +
+				`"extensions":{"code":"PERSISTED_QUERY_NOT_FOUND"}}]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"vault":{"number":13}}}`))
+	}, &graphql.Options{PersistedQueries: true})
+
+	run := func(wantSent ...string) {
+		t.Helper()
+
+		mu.Lock()
+		sent = nil
+		mu.Unlock()
+
+		var data struct {
+			Vault struct{ Number int } `json:"vault"`
+		}
+		if _, err := gql.Query(query, nil, &data); err != nil {
+			t.Fatalf("Query() error = %v", err)
+		}
+		if data.Vault.Number != 13 {
+			t.Errorf("Query() data = %+v", data)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if len(sent) != len(wantSent) {
+			t.Fatalf("sent queries = %q, want %q", sent, wantSent)
+		}
+		for i := range sent {
+			if sent[i] != wantSent[i] {
+				t.Errorf("sent queries = %q, want %q", sent, wantSent)
+			}
+		}
+	}
+
+	// Not supported, the query is sent again without the hash
+	run("", query)
+
+	// Unknown hash, then registered
+	mu.Lock()
+	persisted = make(map[string]string)
+	mu.Unlock()
+	run("", query)
+	run("")
+
+	mu.Lock()
+	if len(persisted) != 1 || persisted[hash] != query {
+		t.Errorf("persisted queries = %v, want the %s hash", persisted, hash)
+	}
+	mu.Unlock()
+}
@@ -0,0 +1,244 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+var (
+	// ErrDestinationDenied is returned when the DialGuard refuses to connect
+	// to an address
+	ErrDestinationDenied = errors.New("destination address denied")
+
+	// ErrInvalidCIDR is returned when an allow or deny entry of the
+	// DialGuard is neither a CIDR nor an IP address
+	ErrInvalidCIDR = errors.New("invalid CIDR")
+)
+
+// guardDefaultDeny are the ranges denied by default: the addresses that are
+// not public unicast ones, where the internal services live.
+var guardDefaultDeny = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),          // "this" network
+	netip.MustParsePrefix("10.0.0.0/8"),         // RFC 1918
+	netip.MustParsePrefix("100.64.0.0/10"),      // CGNAT, RFC 6598, Alibaba metadata
+	netip.MustParsePrefix("127.0.0.0/8"),        // loopback
+	netip.MustParsePrefix("169.254.0.0/16"),     // link-local, cloud metadata
+	netip.MustParsePrefix("172.16.0.0/12"),      // RFC 1918
+	netip.MustParsePrefix("192.0.0.0/24"),       // IETF protocol assignments, Oracle metadata
+	netip.MustParsePrefix("192.88.99.0/24"),     // 6to4 relay anycast
+	netip.MustParsePrefix("192.168.0.0/16"),     // RFC 1918
+	netip.MustParsePrefix("198.18.0.0/15"),      // benchmarking, RFC 2544
+	netip.MustParsePrefix("224.0.0.0/4"),        // multicast
+	netip.MustParsePrefix("240.0.0.0/4"),        // reserved
+	netip.MustParsePrefix("255.255.255.255/32"), // broadcast
+	netip.MustParsePrefix("::/128"),             // unspecified
+	netip.MustParsePrefix("::1/128"),            // loopback
+	netip.MustParsePrefix("64:ff9b::/96"),       // NAT64, embeds IPv4 addresses
+	netip.MustParsePrefix("64:ff9b:1::/48"),     // local-use NAT64
+	netip.MustParsePrefix("100::/64"),           // discard-only
+	netip.MustParsePrefix("2001::/32"),          // Teredo, embeds IPv4 addresses
+	netip.MustParsePrefix("2001:db8::/32"),      // documentation
+	netip.MustParsePrefix("2002::/16"),          // 6to4, embeds IPv4 addresses
+	netip.MustParsePrefix("fc00::/7"),           // ULA, AWS metadata fd00:ec2::254
+	netip.MustParsePrefix("fe80::/10"),          // link-local
+	netip.MustParsePrefix("ff00::/8"),           // multicast
+}
+
+// DialGuard protects the clients fetching untrusted URLs against server-side
+// request forgery (SSRF). It resolves the host of every connection,
+// including the redirect hops, and only connects to the checked addresses,
+// so a DNS answer changing between the check and the connection is not
+// followed.
+//
+// By default, it denies the loopback, RFC 1918, link-local, CGNAT, cloud
+// metadata services, IPv6 ULA, multicast and reserved addresses, as well as
+// the IPv6 ranges embedding IPv4 addresses. The allow list overrides the
+// deny list, which overrides the defaults.
+//
+// The environment proxy is not used by a guarded client, as the guard
+// would check the proxy address instead of the destination. The Unix domain
+// socket clients are not guarded.
+type DialGuard struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+
+	// Resolver resolves the host names, net.DefaultResolver when nil.
+	Resolver *net.Resolver
+}
+
+// NewDialGuard creates a DialGuard with the `allow` and `deny` lists of
+// CIDRs or IP addresses, such as "10.1.0.0/16" or "2001:db8::1".
+//
+// Example:
+//
+//	guard, err := client.NewDialGuard([]string{"10.1.2.0/24"}, nil)
+//	opt.DialGuard = guard
+func NewDialGuard(allow, deny []string) (*DialGuard, error) {
+	var err error
+	g := &DialGuard{}
+
+	if g.allow, err = parsePrefixes(allow); err != nil {
+		return nil, err
+	}
+	if g.deny, err = parsePrefixes(deny); err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// parsePrefixes parses the CIDRs or IP addresses of the list.
+func parsePrefixes(list []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(list))
+	for _, entry := range list {
+		entry = strings.TrimSpace(entry)
+
+		if !strings.Contains(entry, "/") {
+			addr, err := netip.ParseAddr(entry)
+			if err != nil {
+				return nil, errors.Join(ErrInvalidCIDR, err)
+			}
+			addr = addr.Unmap()
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, errors.Join(ErrInvalidCIDR, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+
+	return prefixes, nil
+}
+
+// Check returns an error wrapping ErrDestinationDenied if the guard refuses
+// to connect to `addr`. The IPv4-mapped IPv6 addresses are checked as IPv4.
+func (g *DialGuard) Check(addr netip.Addr) error {
+	addr = addr.Unmap().WithZone("")
+	if !addr.IsValid() {
+		return errors.Join(ErrDestinationDenied,
+			errors.New("invalid address"))
+	}
+
+	if containsAddr(g.allow, addr) {
+		return nil
+	}
+
+	if containsAddr(g.deny, addr) || containsAddr(guardDefaultDeny, addr) {
+		return errors.Join(ErrDestinationDenied,
+			errors.New("refused to connect to "+addr.String()))
+	}
+
+	return nil
+}
+
+// containsAddr reports whether one of the prefixes contains `addr`.
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DialContext returns a dial function resolving the host of the address,
+// checking the resolved addresses and connecting to the allowed ones in
+// turn with `dial`. If `dial` is nil, a net.Dialer is used.
+func (g *DialGuard) DialContext(
+	dial func(ctx context.Context, network, addr string) (net.Conn, error),
+) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if dial == nil {
+		var d net.Dialer
+		dial = d.DialContext
+	}
+
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		addrs, err := g.resolve(ctx, network, host)
+		if err != nil {
+			return nil, err
+		}
+
+		var errs []error
+		for _, ip := range addrs {
+			if err := g.Check(ip); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+
+			// The checked address is dialed, not the host name
+			conn, err := dial(ctx, network,
+				net.JoinHostPort(ip.Unmap().String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			errs = append(errs, err)
+		}
+
+		return nil, errors.Join(errs...)
+	}
+}
+
+// resolve returns the addresses of the host, the host itself if it is an
+// IP address.
+func (g *DialGuard) resolve(
+	ctx context.Context, network, host string,
+) ([]netip.Addr, error) {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return []netip.Addr{addr}, nil
+	}
+
+	ipNetwork := "ip"
+	switch {
+	case strings.HasSuffix(network, "4"):
+		ipNetwork = "ip4"
+	case strings.HasSuffix(network, "6"):
+		ipNetwork = "ip6"
+	}
+
+	resolver := g.Resolver
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	addrs, err := resolver.LookupNetIP(ctx, ipNetwork, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, errors.Join(ErrDestinationDenied,
+			errors.New("no address found for "+host))
+	}
+
+	return addrs, nil
+}
@@ -0,0 +1,176 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync"
+	"testing"
+
+	"gitlab.com/iglou.eu/goulc/http/client"
+)
+
+func TestDialGuard_Check(t *testing.T) {
+	defaults, err := client.NewDialGuard(nil, nil)
+	if err != nil {
+		t.Fatalf("NewDialGuard() error = %v", err)
+	}
+	custom, err := client.NewDialGuard(
+		[]string{"127.0.0.0/8", "10.13.0.13"},
+		[]string{"203.0.113.0/24", "10.0.0.0/8", "127.0.0.1"})
+	if err != nil {
+		t.Fatalf("NewDialGuard() error = %v", err)
+	}
+
+	tests := []struct {
+		guard  *client.DialGuard
+		addr   string
+		denied bool
+	}{
+		{defaults, "127.0.0.1", true},
+		{defaults, "10.13.0.1", true},
+		{defaults, "172.20.0.1", true},
+		{defaults, "192.168.1.1", true},
+		{defaults, "169.254.169.254", true},
+		{defaults, "100.100.100.200", true},
+		{defaults, "192.0.0.192", true},
+		{defaults, "0.0.0.0", true},
+		{defaults, "::1", true},
+		{defaults, "fe80::1%eth0", true},
+		{defaults, "fd00:ec2::254", true},
+		{defaults, "::ffff:127.0.0.1", true},
+		{defaults, "64:ff9b::a00:1", true},
+		{defaults, "8.8.8.8", false},
+		{defaults, "2606:4700:4700::1111", false},
+		{defaults, "203.0.113.7", false},
+
+		// The allow list overrides the deny list and the defaults
+		{custom, "127.0.0.1", false},
+		{custom, "10.13.0.13", false},
+		{custom, "10.13.0.14", true},
+		{custom, "203.0.113.7", true},
+		{custom, "::ffff:203.0.113.7", true},
+		{custom, "192.168.1.1", true},
+		{custom, "8.8.8.8", false},
+	}
+
+	for _, tt := range tests {
+		err := tt.guard.Check(netip.MustParseAddr(tt.addr))
+		if errors.Is(err, client.ErrDestinationDenied) != tt.denied {
+			t.Errorf("Check(%s) error = %v, want denied %v", tt.addr, err, tt.denied)
+		}
+	}
+
+	if err := defaults.Check(netip.Addr{}); !errors.Is(err, client.ErrDestinationDenied) {
+		t.Errorf("Check() invalid address error = %v, want %v", err, client.ErrDestinationDenied)
+	}
+}
+
+func TestNewDialGuard_Invalid(t *testing.T) {
+	for _, list := range [][]string{{"10.0.0.0/33"}, {"vault-13"}, {""}} {
+		if _, err := client.NewDialGuard(list, nil); !errors.Is(err, client.ErrInvalidCIDR) {
+			t.Errorf("NewDialGuard(%q) error = %v, want %v", list, err, client.ErrInvalidCIDR)
+		}
+		if _, err := client.NewDialGuard(nil, list); !errors.Is(err, client.ErrInvalidCIDR) {
+			t.Errorf("NewDialGuard(nil, %q) error = %v, want %v", list, err, client.ErrInvalidCIDR)
+		}
+	}
+}
+
+func TestOptions_DialGuard(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			// Another loopback address, out of the allow list
+			_, port, _ := net.SplitHostPort(r.Host)
+			http.Redirect(w, r, "http://127.0.0.2:"+port+"/", http.StatusFound)
+			return
+		}
+		w.Write([]byte("vault-tec"))
+	}))
+	defer ts.Close()
+	_, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+
+	// The loopback server is denied by default
+	guard, err := client.NewDialGuard(nil, nil)
+	if err != nil {
+		t.Fatalf("NewDialGuard() error = %v", err)
+	}
+	c, err := client.New(context.Background(), ts.URL, nil,
+		&client.Options{DialGuard: guard}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := c.Do(http.MethodGet, nil, nil); !errors.Is(err, client.ErrDestinationDenied) {
+		t.Errorf("Do() error = %v, want %v", err, client.ErrDestinationDenied)
+	}
+	c.Close()
+
+	// The host is resolved and the checked address is dialed
+	guard, err = client.NewDialGuard([]string{"127.0.0.1"}, nil)
+	if err != nil {
+		t.Fatalf("NewDialGuard() error = %v", err)
+	}
+
+	var mu sync.Mutex
+	var dialed []string
+	c, err = client.New(context.Background(), "http://localhost:"+port, nil,
+		&client.Options{
+			Follow:      true,
+			MaxRedirect: 5,
+			DialGuard:   guard,
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				mu.Lock()
+				dialed = append(dialed, addr)
+				mu.Unlock()
+
+				var d net.Dialer
+				return d.DialContext(ctx, network, addr)
+			},
+		}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.Do(http.MethodGet, nil, nil)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if string(resp.Body) != "vault-tec" {
+		t.Errorf("Do() body = %q, want %q", resp.Body, "vault-tec")
+	}
+	mu.Lock()
+	if strings.Join(dialed, ",") != "127.0.0.1:"+port {
+		t.Errorf("dialed addresses = %v, want only 127.0.0.1:%s", dialed, port)
+	}
+	mu.Unlock()
+
+	// Every redirect hop is guarded
+	_, err = c.NewChild("/redirect").Do(http.MethodGet, nil, nil)
+	if !errors.Is(err, client.ErrDestinationDenied) {
+		t.Errorf("Do() redirect error = %v, want %v", err, client.ErrDestinationDenied)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// IdempotencyKeyHeader is the header carrying the idempotency key.
+// https://datatracker.ietf.org/doc/draft-ietf-httpapi-idempotency-key-header/
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// needsIdempotencyKey reports if the method is not idempotent, so a key
+// must be sent to retry it safely.
+func needsIdempotencyKey(method string) bool {
+	return method == http.MethodPost || method == http.MethodPatch
+}
+
+// idempotencyKey returns the idempotency key of the request, generated
+// when the Idempotency option is enabled and the method needs one. A key
+// set by the caller in the client headers is kept as is.
+//
+// The key is set in the headers of the request clone, so it is the same
+// for every redirect and failover of the request.
+func (c *Client) idempotencyKey(method string) (string, error) {
+	if key := c.Header.Get(IdempotencyKeyHeader); key != "" {
+		return key, nil
+	}
+
+	if !c.Options.Idempotency || !needsIdempotencyKey(method) {
+		return "", nil
+	}
+
+	key, err := newIdempotencyKey()
+	if err != nil {
+		return "", err
+	}
+
+	if c.Header == nil {
+		c.Header = make(http.Header)
+	}
+	c.Header.Set(IdempotencyKeyHeader, key)
+
+	c.logger.Debug("idempotency key generated",
+		"method", method,
+		"idempotency_key", key)
+	return key, nil
+}
+
+// newIdempotencyKey generates a random UUID version 4.
+// RFC 9562 §5.4: https://www.rfc-editor.org/rfc/rfc9562#section-5.4
+func newIdempotencyKey() (string, error) {
+	var uuid [16]byte
+	if _, err := rand.Read(uuid[:]); err != nil {
+		return "", err
+	}
+	uuid[6] = uuid[6]&0x0F | 0x40
+	uuid[8] = uuid[8]&0x3F | 0x80
+
+	var buf [36]byte
+	hex.Encode(buf[0:8], uuid[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], uuid[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], uuid[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], uuid[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], uuid[10:])
+
+	return string(buf[:]), nil
+}
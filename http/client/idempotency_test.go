@@ -0,0 +1,160 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/iglou.eu/goulc/http/client"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+// keyRecorder records the idempotency keys received
+type keyRecorder struct {
+	mu   sync.Mutex
+	keys []string
+}
+
+func (k *keyRecorder) record(r *http.Request) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.keys = append(k.keys, r.Header.Get(client.IdempotencyKeyHeader))
+}
+
+func (k *keyRecorder) received() []string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	keys := k.keys
+	k.keys = nil
+	return keys
+}
+
+func TestClient_Idempotency(t *testing.T) {
+	var rec keyRecorder
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec.record(r)
+		if r.URL.Path == "/payments" {
+			http.Redirect(w, r, "/v2/payments", http.StatusTemporaryRedirect)
+		}
+	}))
+	defer ts.Close()
+
+	c, err := client.New(context.Background(), ts.URL, nil, &client.Options{
+		Timeout:     time.Second,
+		Follow:      true,
+		MaxRedirect: 2,
+		Idempotency: true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	// The key is kept across the redirect
+	payments := c.NewChild("/payments")
+	resp, err := payments.Do(http.MethodPost, []byte(`{"gold":100}`), nil)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if !uuidPattern.MatchString(resp.IdempotencyKey) {
+		t.Fatalf("IdempotencyKey = %q, want a UUID", resp.IdempotencyKey)
+	}
+	if keys := rec.received(); len(keys) != 2 || keys[0] != resp.IdempotencyKey || keys[1] != resp.IdempotencyKey {
+		t.Errorf("received keys = %q, want %q twice", keys, resp.IdempotencyKey)
+	}
+
+	// Each logical request has its own key
+	again, err := payments.Do(http.MethodPost, []byte(`{"gold":100}`), nil)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if again.IdempotencyKey == resp.IdempotencyKey {
+		t.Errorf("IdempotencyKey = %q reused across requests", again.IdempotencyKey)
+	}
+	rec.received()
+
+	// Safe methods are sent without key
+	resp, err = c.Do(http.MethodGet, nil, nil)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if keys := rec.received(); resp.IdempotencyKey != "" || len(keys) != 1 || keys[0] != "" {
+		t.Errorf("GET keys = %q and %q, want none", resp.IdempotencyKey, keys)
+	}
+
+	// A key supplied by the caller takes precedence
+	resp, err = c.R().Header(client.IdempotencyKeyHeader, "order-42").Do(http.MethodPatch, nil)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if keys := rec.received(); resp.IdempotencyKey != "order-42" || len(keys) != 1 || keys[0] != "order-42" {
+		t.Errorf("PATCH keys = %q and %q, want %q", resp.IdempotencyKey, keys, "order-42")
+	}
+}
+
+func TestClient_IdempotencyFailover(t *testing.T) {
+	var rec keyRecorder
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec.record(r)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec.record(r)
+	}))
+	defer healthy.Close()
+
+	b, err := client.NewBalancer(context.Background(), []client.Endpoint{
+		{URL: failing.URL}, {URL: healthy.URL},
+	}, nil, nil)
+	if err != nil {
+		t.Fatalf("NewBalancer() error = %v", err)
+	}
+
+	c, err := client.NewBalanced(context.Background(), b, nil, &client.Options{
+		Timeout:     time.Second,
+		Idempotency: true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("NewBalanced() error = %v", err)
+	}
+	defer c.Close()
+
+	// A keyed request is safely replayed on the next endpoint
+	resp, err := c.Do(http.MethodPost, []byte(`{"gold":100}`), nil)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Do() status = %v, want %v", resp.StatusCode, http.StatusOK)
+	}
+	if keys := rec.received(); len(keys) != 2 || keys[0] == "" || keys[0] != keys[1] {
+		t.Errorf("received keys = %q, want the same key twice", keys)
+	}
+}
@@ -0,0 +1,244 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"gitlab.com/iglou.eu/goulc/http/client"
+)
+
+// Verify the batch types implement the client.Marshaler and
+// client.Unmarshaler interfaces
+var (
+	_ client.Marshaler   = batchRequest{}
+	_ client.Unmarshaler = &batchResponse{}
+)
+
+// Batch holds the calls and notifications sent together in one request.
+// The responses are matched to the calls by id, whatever their order. A
+// Batch is not safe for concurrent use.
+//
+// Example:
+//
+//	var block, balance string
+//	batch := rpc.NewBatch()
+//	number := batch.Call("eth_blockNumber", nil, &block)
+//	batch.Call("eth_getBalance", []any{address, "latest"}, &balance)
+//	batch.Notify("log", []any{"balance checked"})
+//	if _, err := batch.Do(); err != nil {
+//	    // the errors of the calls, also set on each Call
+//	}
+//	if number.Err == nil { ... }
+type Batch struct {
+	rpc      *Client
+	requests []Request
+	calls    []*Call
+}
+
+// Call is a call of a Batch, its result is decoded and its Err set when the
+// batch is done.
+type Call struct {
+	// ID is the id of the call, numbered by the Client.
+	ID uint64
+
+	// Method is the called method.
+	Method string
+
+	// Result is the pointer the result is decoded into, nil to ignore it.
+	Result any
+
+	// Err is the error of the call, an *Error when answered by the server.
+	Err error
+}
+
+// NewBatch returns an empty Batch sent through the client.
+func (rpc *Client) NewBatch() *Batch {
+	return &Batch{rpc: rpc}
+}
+
+// Call adds a call of `method` to the batch, decoding its result into
+// `result`, see Client.Call.
+func (b *Batch) Call(method string, params, result any) *Call {
+	call := &Call{ID: b.rpc.nextID(), Method: method, Result: result}
+	b.requests = append(b.requests,
+		Request{Method: method, Params: params, ID: &call.ID})
+	b.calls = append(b.calls, call)
+
+	return call
+}
+
+// Notify adds a notification of `method` to the batch.
+func (b *Batch) Notify(method string, params any) {
+	b.requests = append(b.requests, Request{Method: method, Params: params})
+}
+
+// Len returns the number of calls and notifications of the batch.
+func (b *Batch) Len() int {
+	return len(b.requests)
+}
+
+// Do sends the batch and sets the result and error of each call. It returns
+// the request error, set on every call, or the errors of the calls joined.
+// A batch of notifications only expects no response.
+func (b *Batch) Do() (*client.Response, error) {
+	if len(b.requests) == 0 {
+		return nil, ErrEmptyBatch
+	}
+	for _, req := range b.requests {
+		if req.Method == "" {
+			return nil, ErrEmptyMethod
+		}
+	}
+
+	var body batchResponse
+	resp, err := b.rpc.send(batchRequest(b.requests), &body)
+	if err != nil {
+		b.fail(err)
+		return nil, err
+	}
+
+	if len(b.calls) == 0 {
+		if !resp.Success {
+			return resp, invalidResponse(resp)
+		}
+		return resp, nil
+	}
+
+	switch {
+	case body.single != nil && body.single.Error != nil:
+		// The whole batch is rejected, such as an invalid JSON
+		b.fail(body.single.Error)
+		return resp, body.single.Error
+	case body.single != nil || len(body.responses) == 0:
+		err := invalidResponse(resp)
+		b.fail(err)
+		return resp, err
+	}
+
+	return resp, b.match(body.responses)
+}
+
+// match sets the results and errors of the calls from the responses.
+func (b *Batch) match(responses []Response) error {
+	pending := make(map[uint64]*Call, len(b.calls))
+	for _, call := range b.calls {
+		pending[call.ID] = call
+	}
+
+	// The errors without id are the calls the server could not identify
+	var unmatched []error
+	for _, res := range responses {
+		id, ok := res.id()
+		call := pending[id]
+		if !ok || call == nil {
+			if res.Error != nil {
+				unmatched = append(unmatched, res.Error)
+			} else {
+				unmatched = append(unmatched, errors.Join(ErrInvalidResponse,
+					errors.New("unexpected id "+string(res.ID))))
+			}
+			continue
+		}
+		delete(pending, id)
+
+		if res.Error != nil {
+			call.Err = res.Error
+		} else {
+			call.Err = res.decode(call.Result)
+		}
+	}
+
+	errs := make([]error, 0, len(b.calls))
+	for _, call := range b.calls {
+		if _, missing := pending[call.ID]; missing {
+			call.Err = errors.Join(append([]error{ErrMissingResponse}, unmatched...)...)
+		}
+		if call.Err != nil {
+			errs = append(errs, call.Err)
+		}
+	}
+	if len(errs) == 0 {
+		return errors.Join(unmatched...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// fail sets `err` on every call of the batch.
+func (b *Batch) fail(err error) {
+	for _, call := range b.calls {
+		call.Err = err
+	}
+}
+
+// batchRequest is the body of a batch, an array of requests.
+type batchRequest []Request
+
+// Name returns the identifier for this request type.
+func (_ batchRequest) Name() string {
+	return BatchName
+}
+
+// ContentType returns the content type of the request body.
+func (_ batchRequest) ContentType() string {
+	return ContentType
+}
+
+// Marshal serializes the requests as a JSON array.
+func (b batchRequest) Marshal() ([]byte, error) {
+	messages := make([]any, len(b))
+	for i := range b {
+		messages[i] = b[i].message()
+	}
+
+	return json.Marshal(messages)
+}
+
+// batchResponse is the body answering a batch: an array of responses, or a
+// single error response when the batch itself is rejected.
+type batchResponse struct {
+	responses []Response
+	single    *Response
+}
+
+// Name returns the identifier for this response type.
+func (_ batchResponse) Name() string {
+	return BatchName
+}
+
+// Unmarshal parses the JSON-encoded array or single response.
+func (b *batchResponse) Unmarshal(status int, _ http.Header, body []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(body), []byte("{")) {
+		b.single = &Response{}
+		if err := unmarshal(status, body, b.single); err != nil {
+			return err
+		}
+		if b.single.Version == "" {
+			b.single = nil
+		}
+		return nil
+	}
+
+	return unmarshal(status, body, &b.responses)
+}
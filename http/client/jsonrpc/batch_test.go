@@ -0,0 +1,143 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package jsonrpc_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"gitlab.com/iglou.eu/goulc/http/client/jsonrpc"
+)
+
+func TestBatch_Do(t *testing.T) {
+	rpc := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var msgs []message
+		if err := json.NewDecoder(r.Body).Decode(&msgs); err != nil {
+			w.Write([]byte(`{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`))
+			return
+		}
+
+		var responses []string
+		for _, msg := range msgs {
+			switch {
+			case msg.ID == nil:
+				// Notifications are not answered
+			case msg.Method == "echo":
+				responses = append(responses, `{"jsonrpc":"2.0","result":`+
+					string(msg.Params)+`,"id":`+string(msg.ID)+`}`)
+			case msg.Method == "fail":
+				responses = append(responses, `{"jsonrpc":"2.0","error":`+
+					`{"code":-32602,"message":"Invalid params"},"id":`+string(msg.ID)+`}`)
+			case msg.Method == "unknown":
+				responses = append(responses, `{"jsonrpc":"2.0","error":`+
+					`{"code":-32600,"message":"Invalid Request"},"id":null}`)
+			}
+		}
+		if len(responses) == 0 {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		// The responses are answered out of order
+		slices.Reverse(responses)
+		w.Write([]byte("[" + strings.Join(responses, ",") + "]"))
+	})
+
+	var first, second []string
+	batch := rpc.NewBatch()
+	one := batch.Call("echo", []string{"vault", "13"}, &first)
+	batch.Notify("log", []string{"water chip"})
+	two := batch.Call("echo", []string{"vault", "101"}, &second)
+	failed := batch.Call("fail", []int{1}, nil)
+	unknown := batch.Call("unknown", nil, nil)
+	if batch.Len() != 5 || one.ID == two.ID {
+		t.Fatalf("batch of %d calls with ids %d and %d", batch.Len(), one.ID, two.ID)
+	}
+
+	_, err := batch.Do()
+	if err == nil {
+		t.Fatal("Do() expected the errors of the calls")
+	}
+	if one.Err != nil || two.Err != nil ||
+		!slices.Equal(first, []string{"vault", "13"}) ||
+		!slices.Equal(second, []string{"vault", "101"}) {
+		t.Errorf("Do() results = %q (%v), %q (%v)", first, one.Err, second, two.Err)
+	}
+
+	var rpcErr *jsonrpc.Error
+	if !errors.As(failed.Err, &rpcErr) || rpcErr.Code != jsonrpc.CodeInvalidParams {
+		t.Errorf("failed call error = %v, want invalid params", failed.Err)
+	}
+	if !errors.Is(unknown.Err, jsonrpc.ErrMissingResponse) ||
+		!errors.As(unknown.Err, &rpcErr) || rpcErr.Code != jsonrpc.CodeInvalidRequest {
+		t.Errorf("unknown call error = %v, want a missing response with the invalid request", unknown.Err)
+	}
+	if !errors.Is(err, jsonrpc.ErrMissingResponse) {
+		t.Errorf("Do() error = %v, want the call errors", err)
+	}
+
+	// A batch of notifications expects no response
+	batch = rpc.NewBatch()
+	batch.Notify("log", nil)
+	batch.Notify("log", nil)
+	if _, err := batch.Do(); err != nil {
+		t.Errorf("Do() notifications error = %v", err)
+	}
+
+	// The request error is set on every call
+	batch = rpc.NewBatch()
+	call := batch.Call("echo", make(chan int), nil)
+	if _, err := batch.Do(); err == nil || call.Err != err {
+		t.Errorf("Do() unmarshalable params error = %v, call error %v", err, call.Err)
+	}
+
+	if _, err := rpc.NewBatch().Do(); !errors.Is(err, jsonrpc.ErrEmptyBatch) {
+		t.Errorf("Do() empty error = %v, want %v", err, jsonrpc.ErrEmptyBatch)
+	}
+	batch = rpc.NewBatch()
+	batch.Notify("", nil)
+	if _, err := batch.Do(); !errors.Is(err, jsonrpc.ErrEmptyMethod) {
+		t.Errorf("Do() error = %v, want %v", err, jsonrpc.ErrEmptyMethod)
+	}
+}
+
+func TestBatch_Rejected(t *testing.T) {
+	rpc := newClient(t, func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`))
+	})
+
+	batch := rpc.NewBatch()
+	calls := []*jsonrpc.Call{batch.Call("echo", nil, nil), batch.Call("echo", nil, nil)}
+	_, err := batch.Do()
+
+	var rpcErr *jsonrpc.Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code != jsonrpc.CodeParseError {
+		t.Fatalf("Do() error = %v, want a parse error", err)
+	}
+	for i, call := range calls {
+		if call.Err != err {
+			t.Errorf("call %d error = %v, want %v", i, call.Err, err)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+// Package jsonrpc implements a JSON-RPC 2.0 client over an http client,
+// sending single calls, notifications and batches.
+package jsonrpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"gitlab.com/iglou.eu/goulc/http/client"
+)
+
+const (
+	// RequestName is the identifier for Request Marshaler
+	RequestName = "jsonrpc.Request"
+	// ResponseName is the identifier for Response Unmarshaler
+	ResponseName = "jsonrpc.Response"
+	// BatchName is the identifier for the batch Marshaler and Unmarshaler
+	BatchName = "jsonrpc.Batch"
+
+	// Version is the JSON-RPC protocol version
+	Version = "2.0"
+	// ContentType is the content type of the requests and responses
+	ContentType = "application/json"
+)
+
+// The error codes defined by the specification, the -32000 to -32099 range
+// being reserved for the server errors.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+var (
+	// ErrEmptyMethod is returned when a call has no method name
+	ErrEmptyMethod = errors.New("empty JSON-RPC method")
+
+	// ErrEmptyBatch is returned when a batch without call is sent
+	ErrEmptyBatch = errors.New("empty JSON-RPC batch")
+
+	// ErrUnexpectedStatus is returned when the server answers with an error
+	// status code and without JSON-RPC response
+	ErrUnexpectedStatus = errors.New("unexpected status code")
+
+	// ErrInvalidResponse is returned when the response is not the JSON-RPC
+	// response of the call
+	ErrInvalidResponse = errors.New("invalid JSON-RPC response")
+
+	// ErrMissingResponse is set on the calls of a batch the server did not
+	// answer
+	ErrMissingResponse = errors.New("missing JSON-RPC response")
+)
+
+// Verify Request implements client.Marshaler interface
+var _ client.Marshaler = &Request{}
+
+// Verify Response implements client.Unmarshaler interface
+var _ client.Unmarshaler = &Response{}
+
+// Client sends the JSON-RPC calls to an endpoint, through a child of the
+// http/client Client given to New. It numbers the calls itself, it is safe
+// for concurrent use.
+type Client struct {
+	client *client.Client
+	lastID atomic.Uint64
+}
+
+// New returns a Client sending the calls to the `path` endpoint of c,
+// through a child of c.
+//
+// Example:
+//
+//	rpc, err := jsonrpc.New(&c, "/rpc")
+//	if err != nil {
+//	    return err
+//	}
+//	defer rpc.Close()
+//
+//	var balance string
+//	_, err = rpc.Call("eth_getBalance", []any{address, "latest"}, &balance)
+func New(c *client.Client, path string) (*Client, error) {
+	child := c.NewChild(path)
+	if child == nil {
+		return nil, client.ErrClientClosed
+	}
+
+	return &Client{client: child}, nil
+}
+
+// Close closes the child client, c is left open.
+func (rpc *Client) Close() error {
+	return rpc.client.Close()
+}
+
+// nextID returns the id of a new call.
+func (rpc *Client) nextID() uint64 {
+	return rpc.lastID.Add(1)
+}
+
+// Call calls `method` with its params, an array or object value or nil,
+// and decodes the result into `result`, a pointer like for json.Unmarshal,
+// or nil to ignore it. An error answered by the server is returned as an
+// *Error, along with the response.
+func (rpc *Client) Call(method string, params, result any) (*client.Response, error) {
+	if method == "" {
+		return nil, ErrEmptyMethod
+	}
+
+	id := rpc.nextID()
+	var body Response
+	resp, err := rpc.send(&Request{Method: method, Params: params, ID: &id}, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	if body.Version == "" {
+		return resp, invalidResponse(resp)
+	}
+	if body.Error != nil {
+		return resp, body.Error
+	}
+	if responseID, ok := body.id(); !ok || responseID != id {
+		return resp, errors.Join(ErrInvalidResponse,
+			errors.New("unexpected id "+string(body.ID)))
+	}
+
+	return resp, body.decode(result)
+}
+
+// Notify sends a notification of `method` with its params, a call the
+// server does not answer.
+func (rpc *Client) Notify(method string, params any) (*client.Response, error) {
+	if method == "" {
+		return nil, ErrEmptyMethod
+	}
+
+	var body Response
+	resp, err := rpc.send(&Request{Method: method, Params: params}, &body)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return resp, invalidResponse(resp)
+	}
+
+	return resp, nil
+}
+
+// send posts the request body.
+func (rpc *Client) send(
+	req client.Marshaler, body client.Unmarshaler,
+) (*client.Response, error) {
+	return rpc.client.R().
+		Header("Accept", ContentType).
+		Marshal(req).
+		Do(http.MethodPost, body)
+}
+
+// invalidResponse returns the error of a response without a JSON-RPC body.
+func invalidResponse(resp *client.Response) error {
+	if !resp.Success {
+		return errors.Join(ErrUnexpectedStatus,
+			errors.New("got "+resp.Status))
+	}
+
+	return errors.Join(ErrInvalidResponse,
+		errors.New("no JSON-RPC response in the body"))
+}
+
+// Request is a JSON-RPC request, a notification when it has no id.
+type Request struct {
+	Method string
+	Params any
+	ID     *uint64
+}
+
+// Name returns the identifier for this request type.
+func (_ Request) Name() string {
+	return RequestName
+}
+
+// ContentType returns the content type of the request body.
+func (_ Request) ContentType() string {
+	return ContentType
+}
+
+// Marshal serializes the request as JSON, with the protocol version.
+func (r *Request) Marshal() ([]byte, error) {
+	return json.Marshal(r.message())
+}
+
+// message returns the JSON message of the request.
+func (r *Request) message() any {
+	return struct {
+		Version string  `json:"jsonrpc"`
+		Method  string  `json:"method"`
+		Params  any     `json:"params,omitempty"`
+		ID      *uint64 `json:"id,omitempty"`
+	}{Version, r.Method, r.Params, r.ID}
+}
+
+// Response is a JSON-RPC response.
+type Response struct {
+	Version string          `json:"jsonrpc"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+// Name returns the identifier for this response type.
+func (_ Response) Name() string {
+	return ResponseName
+}
+
+// Unmarshal parses the JSON-encoded response body. A body that is not JSON
+// is only an error on a successful status code, the error status being
+// reported by Client.Call.
+func (r *Response) Unmarshal(status int, _ http.Header, body []byte) error {
+	return unmarshal(status, body, r)
+}
+
+// id returns the id of the response, false if it is not one of the numbers
+// sent by the client, such as null.
+func (r *Response) id() (uint64, bool) {
+	id, err := strconv.ParseUint(string(r.ID), 10, 64)
+	return id, err == nil
+}
+
+// decode decodes the result into `result`, if any.
+func (r *Response) decode(result any) error {
+	if result == nil || len(r.Result) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(r.Result, result)
+}
+
+// unmarshal decodes the JSON `body` into `v`, ignoring the empty bodies and
+// the bodies of the error status codes that are not JSON.
+func unmarshal(status int, body []byte, v any) error {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		if status >= http.StatusBadRequest {
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Error is a JSON-RPC error answered by the server.
+type Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// Error returns the code and the message.
+func (e *Error) Error() string {
+	return "jsonrpc: " + strconv.Itoa(e.Code) + " " + e.Message
+}
+
+// DecodeData decodes the data of the error into `v`, a pointer like for
+// json.Unmarshal. It does nothing when the error has no data.
+func (e *Error) DecodeData(v any) error {
+	if len(e.Data) == 0 {
+		return nil
+	}
+
+	return json.Unmarshal(e.Data, v)
+}
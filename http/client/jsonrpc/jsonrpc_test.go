@@ -0,0 +1,133 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package jsonrpc_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gitlab.com/iglou.eu/goulc/http/client"
+	"gitlab.com/iglou.eu/goulc/http/client/jsonrpc"
+)
+
+// message is a JSON-RPC request received by the test servers.
+type message struct {
+	Version string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id"`
+}
+
+// newClient returns a JSON-RPC client of the `handler` server.
+func newClient(t *testing.T, handler http.HandlerFunc) *jsonrpc.Client {
+	ts := httptest.NewServer(handler)
+	t.Cleanup(ts.Close)
+
+	c, err := client.New(context.Background(), ts.URL, nil,
+		&client.Options{}, nil)
+	if err != nil {
+		t.Fatalf("client.New() error = %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	rpc, err := jsonrpc.New(&c, "/rpc")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { rpc.Close() })
+
+	return rpc
+}
+
+func TestClient_Call(t *testing.T) {
+	var notified []message
+	rpc := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+		var msg message
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil ||
+			r.URL.Path != "/rpc" || msg.Version != jsonrpc.Version ||
+			r.Header.Get("Content-Type") != jsonrpc.ContentType {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch msg.Method {
+		case "add":
+			var params []int
+			json.Unmarshal(msg.Params, &params)
+			w.Write([]byte(`{"jsonrpc":"2.0","result":` +
+				string(mustMarshal(params[0]+params[1])) + `,"id":` + string(msg.ID) + `}`))
+		case "log":
+			notified = append(notified, msg)
+			w.WriteHeader(http.StatusNoContent)
+		case "wrong_id":
+			w.Write([]byte(`{"jsonrpc":"2.0","result":1,"id":999}`))
+		case "gateway":
+			w.WriteHeader(http.StatusBadGateway)
+			w.Write([]byte(`<html>bad gateway</html>`))
+		default:
+			w.Write([]byte(`{"jsonrpc":"2.0","error":{"code":-32601,` +
+				`"message":"Method not found","data":{"method":"` + msg.Method + `"}},"id":` +
+				string(msg.ID) + `}`))
+		}
+	})
+
+	var sum int
+	if _, err := rpc.Call("add", []int{40, 2}, &sum); err != nil || sum != 42 {
+		t.Errorf("Call() = %d, %v, want 42", sum, err)
+	}
+
+	_, err := rpc.Call("divide", map[string]int{"a": 1}, nil)
+	var rpcErr *jsonrpc.Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code != jsonrpc.CodeMethodNotFound ||
+		err.Error() != "jsonrpc: -32601 Method not found" {
+		t.Fatalf("Call() error = %v, want a method not found error", err)
+	}
+	var data struct{ Method string }
+	if err := rpcErr.DecodeData(&data); err != nil || data.Method != "divide" {
+		t.Errorf("DecodeData() = %+v, %v", data, err)
+	}
+
+	for method, wantErr := range map[string]error{
+		"wrong_id": jsonrpc.ErrInvalidResponse,
+		"gateway":  jsonrpc.ErrUnexpectedStatus,
+		"":         jsonrpc.ErrEmptyMethod,
+	} {
+		if _, err := rpc.Call(method, nil, nil); !errors.Is(err, wantErr) {
+			t.Errorf("Call(%q) error = %v, want %v", method, err, wantErr)
+		}
+	}
+
+	if _, err := rpc.Notify("log", []string{"vault door opened"}); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if len(notified) != 1 || notified[0].ID != nil ||
+		string(notified[0].Params) != `["vault door opened"]` {
+		t.Errorf("Notify() sent %+v, want a notification without id", notified)
+	}
+}
+
+func mustMarshal(v any) []byte {
+	data, _ := json.Marshal(v)
+	return data
+}
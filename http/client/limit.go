@@ -0,0 +1,178 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"gitlab.com/iglou.eu/goulc/bytesize"
+)
+
+var (
+	// ErrResponseTooLarge is matched by ResponseTooLargeError
+	ErrResponseTooLarge = errors.New("response body too large")
+
+	// ErrInvalidResponseLimit is returned when a response size limit
+	// is negative
+	ErrInvalidResponseLimit = errors.New("invalid response size limit")
+)
+
+// ResponseTooLargeError is returned when a response body exceeds the
+// MaxResponseSize or the MaxDecompressedSize option.
+// It matches ErrResponseTooLarge with errors.Is.
+type ResponseTooLargeError struct {
+	// Limit is the exceeded limit
+	Limit bytesize.Size
+
+	// ContentLength is the length declared by the server, -1 if unknown
+	ContentLength int64
+
+	// Decompressed reports if the limit applied to the decompressed body
+	Decompressed bool
+}
+
+// Error implements the error interface.
+func (e *ResponseTooLargeError) Error() string {
+	msg := ErrResponseTooLarge.Error() + ": limit " + e.Limit.String()
+	if e.Decompressed {
+		msg += " decompressed"
+	}
+
+	if e.ContentLength >= 0 {
+		msg += ", declared content length " +
+			strconv.FormatInt(e.ContentLength, 10) + " bytes"
+	}
+
+	return msg
+}
+
+// Is reports whether the target is ErrResponseTooLarge.
+func (e *ResponseTooLargeError) Is(target error) bool {
+	return target == ErrResponseTooLarge
+}
+
+// limitedReader reads at most n bytes and fails with err beyond them,
+// unlike io.LimitReader that silently truncates.
+type limitedReader struct {
+	r   io.Reader
+	n   int64
+	err error
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, l.err
+	}
+
+	// Read one extra byte to detect the overflow
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, l.err
+	}
+
+	return n, err
+}
+
+// validateLimits checks the response size limits of the options.
+func validateLimits(opt *Options) error {
+	if opt.MaxResponseSize.Bytes() < 0 {
+		return errors.Join(ErrInvalidResponseLimit,
+			errors.New("MaxResponseSize cannot be negative"))
+	}
+
+	if opt.MaxDecompressedSize.Bytes() < 0 {
+		return errors.Join(ErrInvalidResponseLimit,
+			errors.New("MaxDecompressedSize cannot be negative"))
+	}
+
+	return nil
+}
+
+// decompressing reports if the client handles the gzip decompression itself,
+// to bound the decompressed size. This is only the case when the user did not
+// set its own Accept-Encoding header.
+func (c *Client) decompressing() bool {
+	return c.Options.MaxDecompressedSize.Bytes() > 0 &&
+		c.Header.Get("Accept-Encoding") == ""
+}
+
+// readBody reads the response body within the configured size limits,
+// throttled to the bandwidth and reporting its progress.
+// The response headers are updated when the body is decompressed.
+func (c *Client) readBody(res *http.Response, header http.Header) ([]byte, error) {
+	meter := newProgressMeter(c.Options.DownloadProgress,
+		ProgressDefaultInterval, 0)
+	body := newMeteredReader(c.context, res.Body, res.ContentLength,
+		c.Options.Bandwidth, meter)
+
+	// When the transport decompressed the body itself, the wire size cannot
+	// be observed and the limit applies to the decompressed bytes
+	if limit := c.Options.MaxResponseSize; limit.Bytes() > 0 {
+		tooLarge := &ResponseTooLargeError{
+			Limit:         limit,
+			ContentLength: res.ContentLength,
+			Decompressed:  res.Uncompressed,
+		}
+
+		if res.ContentLength > limit.Bytes() {
+			return nil, tooLarge
+		}
+
+		body = &limitedReader{r: body, n: limit.Bytes(), err: tooLarge}
+	}
+
+	if c.decompressing() &&
+		strings.EqualFold(res.Header.Get("Content-Encoding"), "gzip") {
+		c.logger.Debug("decompressing response body",
+			"max_decompressed_size", c.Options.MaxDecompressedSize.String())
+
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+
+		body = &limitedReader{
+			r: zr,
+			n: c.Options.MaxDecompressedSize.Bytes(),
+			err: &ResponseTooLargeError{
+				Limit:         c.Options.MaxDecompressedSize,
+				ContentLength: res.ContentLength,
+				Decompressed:  true,
+			},
+		}
+
+		// Same as the transport transparent decompression
+		header.Del("Content-Encoding")
+		header.Del("Content-Length")
+	}
+
+	return io.ReadAll(body)
+}
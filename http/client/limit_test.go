@@ -0,0 +1,151 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.com/iglou.eu/goulc/bytesize"
+	"gitlab.com/iglou.eu/goulc/http/client"
+)
+
+func gzipped(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestClient_ResponseSizeLimits(t *testing.T) {
+	bomb := gzipped(t, bytes.Repeat([]byte("A"), 1<<20))
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/declared":
+			_, _ = w.Write(bytes.Repeat([]byte("B"), 2048))
+		case "/chunked":
+			for range 4 {
+				_, _ = w.Write(bytes.Repeat([]byte("C"), 512))
+				w.(http.Flusher).Flush()
+			}
+		case "/small":
+			_, _ = w.Write([]byte("Boo"))
+		case "/bomb":
+			if r.Header.Get("Accept-Encoding") != "gzip" {
+				t.Errorf("Accept-Encoding = %q, want gzip", r.Header.Get("Accept-Encoding"))
+			}
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Header().Set("Content-Length", strconv.Itoa(len(bomb)))
+			_, _ = w.Write(bomb)
+		}
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name              string
+		path              string
+		maxResponse       string
+		maxDecompressed   string
+		wantBody          string
+		wantContentLength int64
+		wantDecompressed  bool
+	}{
+		{name: "declared content length", path: "/declared", maxResponse: "1KiB", wantContentLength: 2048},
+		{name: "unknown content length", path: "/chunked", maxResponse: "1KiB", wantContentLength: -1},
+		{name: "within limit", path: "/small", maxResponse: "1KiB", wantBody: "Boo"},
+		{name: "exact limit", path: "/small", maxResponse: "3B", wantBody: "Boo"},
+		{
+			name: "decompression bomb", path: "/bomb", maxResponse: "1MiB", maxDecompressed: "64KiB",
+			wantContentLength: int64(len(bomb)), wantDecompressed: true,
+		},
+		{
+			name: "decompressed within limit", path: "/bomb", maxDecompressed: "2MiB",
+			wantBody: strings.Repeat("A", 1<<20),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opt := &client.Options{Timeout: time.Second}
+			opt.MaxResponseSize, _ = bytesize.New(tt.maxResponse)
+			opt.MaxDecompressedSize, _ = bytesize.New(tt.maxDecompressed)
+
+			c, err := client.New(context.Background(), ts.URL+tt.path, nil, opt, nil)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer c.Close()
+
+			resp, err := c.Do(http.MethodGet, nil, nil)
+			if tt.wantBody != "" {
+				if err != nil {
+					t.Fatalf("Do() error = %v", err)
+				}
+				if string(resp.Body) != tt.wantBody {
+					t.Errorf("Do() body = %d bytes, want %d", len(resp.Body), len(tt.wantBody))
+				}
+				if resp.Header.Get("Content-Encoding") != "" {
+					t.Errorf("Content-Encoding = %q, want none", resp.Header.Get("Content-Encoding"))
+				}
+				return
+			}
+
+			var tooLarge *client.ResponseTooLargeError
+			if !errors.Is(err, client.ErrResponseTooLarge) || !errors.As(err, &tooLarge) {
+				t.Fatalf("Do() error = %v, want %v", err, client.ErrResponseTooLarge)
+			}
+
+			wantLimit := opt.MaxResponseSize
+			if tt.wantDecompressed {
+				wantLimit = opt.MaxDecompressedSize
+			}
+			if tooLarge.Limit != wantLimit ||
+				tooLarge.ContentLength != tt.wantContentLength ||
+				tooLarge.Decompressed != tt.wantDecompressed {
+				t.Errorf("Do() error = %+v, want limit %v, content length %d, decompressed %v",
+					tooLarge, wantLimit, tt.wantContentLength, tt.wantDecompressed)
+			}
+		})
+	}
+
+	// Negative limits are rejected
+	if _, err := client.New(context.Background(), ts.URL, nil, &client.Options{
+		MaxResponseSize: bytesize.NewInt(-1),
+	}, nil); !errors.Is(err, client.ErrInvalidResponseLimit) {
+		t.Errorf("New() error = %v, want %v", err, client.ErrInvalidResponseLimit)
+	}
+}
@@ -22,22 +22,16 @@ package client
 import (
 	"context"
 	"log/slog"
+	"net"
 	"net/http"
 	"net/url"
 	"sync"
 	"time"
 
+	"gitlab.com/iglou.eu/goulc/bytesize"
 	"gitlab.com/iglou.eu/goulc/http/client/auth"
 )
 
-// ErrorHistory stores information about a failed request.
-type ErrorHistory struct {
-	URL        string
-	StatusCode int
-	Timestamp  time.Time
-	IsError    bool
-}
-
 // Redirects stores information about a HTTP redirection.
 type Redirects struct {
 	URL        string
@@ -71,6 +65,13 @@ type Options struct {
 	// Default: 2
 	MaxRedirect int
 
+	// Redirect refines the redirects followed: allowed hosts, https to http
+	// downgrades, method and body handling, auth forwarding per host and
+	// a per-hop callback. When set, it decides the auth forwarding in place
+	// of the exact host match. It is shared with the children.
+	// Default: nil
+	Redirect *RedirectPolicy
+
 	// Timeout sets the maximum duration for the entire request.
 	// Default: 35s
 	Timeout time.Duration
@@ -82,6 +83,65 @@ type Options struct {
 	// RateLimiter allows for rate limiting by implementing the Wait method.
 	// Default: nil
 	RateLimiter Ratelimiter
+
+	// DialContext specifies the dial function used to open the connections,
+	// it allows to reach services over custom transports. It is set
+	// automatically for Unix domain socket URLs.
+	// Default: nil
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	// DialGuard refuses the connections to the private and internal
+	// addresses, every redirect hop included, for the clients fetching
+	// untrusted URLs. It wraps DialContext, if any.
+	// Default: nil
+	DialGuard *DialGuard
+
+	// Protocol restricts the HTTP protocol versions used by the client.
+	// Default: ProtoAuto
+	Protocol Protocol
+
+	// Jar stores the cookies received and sends them back, including across
+	// the redirects of a single request. It is shared with the children.
+	// Default: nil
+	Jar http.CookieJar
+
+	// MaxResponseSize limits the size of the response body read by Do,
+	// zero means no limit. When the transport decompresses the body
+	// transparently, the limit applies to the decompressed bytes.
+	// Default: 0
+	MaxResponseSize bytesize.Size
+
+	// MaxDecompressedSize limits the size a gzip response body may grow to
+	// once decompressed, zero means no limit. When set, the client requests
+	// and decompresses gzip itself unless an Accept-Encoding header is set.
+	// Default: 0
+	MaxDecompressedSize bytesize.Size
+
+	// Bandwidth caps the bytes per second of the request and response
+	// bodies. It is shared with the children.
+	// Default: nil
+	Bandwidth *Bandwidth
+
+	// UploadProgress is called while the request body is sent.
+	// Default: nil
+	UploadProgress func(Progress)
+
+	// DownloadProgress is called while the response body is read.
+	// Default: nil
+	DownloadProgress func(Progress)
+
+	// Idempotency generates an Idempotency-Key header for the POST and PATCH
+	// requests, the same key being sent on every redirect and failover of a
+	// request. A key set by the caller in the headers takes precedence.
+	// Default: false
+	Idempotency bool
+
+	// Coalesce shares a single request between the concurrent GET and HEAD
+	// requests of the client with the same URL, headers and authenticator,
+	// each caller getting a copy of the response flagged as Shared. The
+	// children have their own in-flight requests.
+	// Default: false
+	Coalesce bool
 }
 
 // Client manages its own configuration. The configuration can be safely
@@ -92,10 +152,42 @@ type Client struct {
 	activeRequests int32
 	logger         *slog.Logger
 
-	closer  []func() error
+	// closer holds the Close functions of the children, clones and
+	// WebSockets, by registration id. A child removes itself once closed
+	// with release, so long-lived clients do not accumulate them.
+	closer    map[uint64]func() error
+	closerSeq uint64
+	release   func()
+
+	// authMu serializes the use of Auth, shared by the request snapshots
+	authMu *sync.Mutex
+
+	// stats records the requests of the client tree, statsPath is the
+	// client path the requests of a snapshot are recorded under
+	stats     *statsEngine
+	statsPath string
+
+	// flights holds the in-flight requests coalesced by the Coalesce
+	// option, shared by the request snapshots
+	flights *flightGroup
+
+	// transports holds the transports built for the options of the client
+	// tree, shared by the snapshots and the children
+	transports *transportCache
+
 	context context.Context
 	cancel  context.CancelFunc
 
+	// balancer dispatches requests across several base URLs, if any.
+	// basePath is the escaped path of the base URL the client was created from,
+	// used to rebase the client path on the selected endpoint.
+	balancer *Balancer
+	basePath string
+
+	// socketPath is the Unix domain socket path when the client
+	// was created from a `unix://` URL
+	socketPath string
+
 	// Mu is the mutex to lock when accessing or modifying the client
 	// It's used to ensure thread-safety
 	Mu *sync.RWMutex
@@ -114,10 +206,6 @@ type Client struct {
 
 	// Query stores URL query parameters
 	Query url.Values
-
-	// ErrorHistory tracks request errors for the last minute
-	// Used to calculate error rate metrics
-	ErrorHistory []ErrorHistory
 }
 
 // Response encapsulates the HTTP response details and provides access to
@@ -163,7 +251,14 @@ type Response struct {
 	// that occurred during the request
 	Trace []Redirects
 
-	// ErrorRate is the percentage of failed requests in
-	// the last minute (shared across client)
+	// ErrorRate is the percentage of failed requests over the StatsWindow,
+	// shared by the client and all its children, see Client.Stats
 	ErrorRate float64
+
+	// IdempotencyKey is the Idempotency-Key sent with the request, if any
+	IdempotencyKey string
+
+	// Shared reports that the response was shared with concurrent identical
+	// requests, see Options.Coalesce
+	Shared bool
 }
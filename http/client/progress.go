@@ -0,0 +1,198 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"gitlab.com/iglou.eu/goulc/bytesize"
+	"gitlab.com/iglou.eu/goulc/duration"
+)
+
+const (
+	// ProgressDefaultInterval is the minimum delay between two progress
+	// calls of the UploadProgress and DownloadProgress options
+	ProgressDefaultInterval = 500 * time.Millisecond
+)
+
+// Progress reports the state of a transfer.
+type Progress struct {
+	// Transferred is the number of bytes transferred, resumed bytes included
+	Transferred bytesize.Size
+
+	// Total is the size of the transfer, zero when unknown
+	Total bytesize.Size
+
+	// Rate is the transfer rate per second of the current call
+	Rate bytesize.Size
+
+	// ETA is the estimated remaining time, zero when unknown
+	ETA duration.Duration
+}
+
+// progressMeter computes the progress of a transfer and reports it to
+// a callback, at most once per interval.
+type progressMeter struct {
+	callback func(Progress)
+	interval time.Duration
+
+	start       time.Time
+	startOffset int64
+	last        time.Time
+}
+
+// newProgressMeter returns a meter starting at `offset`, nil when there is
+// no callback.
+func newProgressMeter(
+	callback func(Progress), interval time.Duration, offset int64,
+) *progressMeter {
+	if callback == nil {
+		return nil
+	}
+
+	return &progressMeter{
+		callback:    callback,
+		interval:    interval,
+		start:       time.Now(),
+		startOffset: offset,
+	}
+}
+
+// reset restarts the rate computation from `offset`.
+func (m *progressMeter) reset(offset int64) {
+	if m == nil {
+		return
+	}
+
+	m.start = time.Now()
+	m.startOffset = offset
+}
+
+// report calls the callback, unless it was called less than an interval
+// ago and `force` is not set. A negative `total` means unknown.
+func (m *progressMeter) report(transferred, total int64, force bool) {
+	if m == nil {
+		return
+	}
+
+	now := time.Now()
+	if !force && now.Sub(m.last) < m.interval {
+		return
+	}
+	m.last = now
+
+	p := Progress{Transferred: bytesize.NewInt(transferred)}
+	if total >= 0 {
+		p.Total = bytesize.NewInt(total)
+	}
+
+	elapsed := now.Sub(m.start).Seconds()
+	if elapsed <= 0 {
+		m.callback(p)
+		return
+	}
+
+	rate := float64(transferred-m.startOffset) / elapsed
+	p.Rate = bytesize.NewInt(int64(rate))
+	if total >= 0 && rate > 0 {
+		remaining := float64(total-transferred) / rate
+		p.ETA = duration.Duration{
+			Duration: time.Duration(remaining * float64(time.Second)),
+		}
+	}
+
+	m.callback(p)
+}
+
+// meteredReader throttles a body to the bandwidth and reports its progress.
+type meteredReader struct {
+	ctx       context.Context
+	r         io.Reader
+	bandwidth *Bandwidth
+	meter     *progressMeter
+
+	n     int64
+	total int64
+}
+
+// newMeteredReader wraps `r`, it returns `r` when there is nothing to meter.
+func newMeteredReader(
+	ctx context.Context, r io.Reader, total int64,
+	bandwidth *Bandwidth, meter *progressMeter,
+) io.Reader {
+	if bandwidth == nil && meter == nil {
+		return r
+	}
+
+	return &meteredReader{
+		ctx:       ctx,
+		r:         r,
+		bandwidth: bandwidth,
+		meter:     meter,
+		total:     total,
+	}
+}
+
+func (m *meteredReader) Read(p []byte) (int, error) {
+	if m.bandwidth != nil {
+		p = p[:min(len(p), m.bandwidth.chunk())]
+	}
+
+	n, err := m.r.Read(p)
+	if n > 0 {
+		m.n += int64(n)
+
+		if m.bandwidth != nil {
+			if werr := m.bandwidth.WaitN(m.ctx, n); werr != nil {
+				return n, werr
+			}
+		}
+
+		m.meter.report(m.n, m.total, false)
+	}
+
+	if errors.Is(err, io.EOF) {
+		m.meter.report(m.n, m.total, true)
+	}
+
+	return n, err
+}
+
+// meterUpload throttles and reports the progress of the request body.
+func (c *Client) meterUpload(req *http.Request, body []byte) {
+	if body == nil ||
+		(c.Options.Bandwidth == nil && c.Options.UploadProgress == nil) {
+		return
+	}
+
+	// GetBody is called again by the redirects replaying the body
+	req.GetBody = func() (io.ReadCloser, error) {
+		meter := newProgressMeter(c.Options.UploadProgress,
+			ProgressDefaultInterval, 0)
+		return io.NopCloser(newMeteredReader(c.context, bytes.NewReader(body),
+			int64(len(body)), c.Options.Bandwidth, meter)), nil
+	}
+	req.Body, _ = req.GetBody()
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Protocol defines the HTTP protocol versions the client is allowed to use.
+// The negotiated protocol is reported by Response.Proto.
+type Protocol uint8
+
+const (
+	// ProtoAuto lets the transport negotiate the protocol, HTTP/2 being
+	// used over TLS when the server supports it. HTTP/1.1 is forced when
+	// the TLS verification is disabled.
+	ProtoAuto Protocol = iota
+	// ProtoHTTP1 restricts the client to HTTP/1.1.
+	ProtoHTTP1
+	// ProtoPreferHTTP2 negotiates HTTP/2 over TLS and falls back to
+	// HTTP/1.1, even when the TLS verification is disabled.
+	ProtoPreferHTTP2
+	// ProtoHTTP2Only restricts the client to HTTP/2 over TLS.
+	ProtoHTTP2Only
+	// ProtoH2C uses cleartext HTTP/2 with prior knowledge, the server must
+	// accept HTTP/2 without upgrade. It requires OnlyHTTPS to be disabled.
+	ProtoH2C
+)
+
+var (
+	// ErrInvalidProtocol is returned when the protocol value is invalid
+	ErrInvalidProtocol = errors.New("invalid HTTP protocol")
+
+	// ErrH2COverHTTPS is returned when h2c is used with OnlyHTTPS
+	ErrH2COverHTTPS = errors.New("h2c is cleartext and cannot be used " +
+		"with the OnlyHTTPS option")
+)
+
+// String returns the name of the protocol.
+func (p Protocol) String() string {
+	switch p {
+	case ProtoAuto:
+		return "auto"
+	case ProtoHTTP1:
+		return "http/1.1"
+	case ProtoPreferHTTP2:
+		return "prefer-h2"
+	case ProtoHTTP2Only:
+		return "h2"
+	case ProtoH2C:
+		return "h2c"
+	}
+
+	return "unknown"
+}
+
+// validate checks that the protocol is known and compatible with
+// the other options.
+func (p Protocol) validate(onlyHTTPS bool) error {
+	if p > ProtoH2C {
+		return ErrInvalidProtocol
+	}
+
+	if p == ProtoH2C && onlyHTTPS {
+		return errors.Join(ErrInvalidProtocol, ErrH2COverHTTPS)
+	}
+
+	return nil
+}
+
+// protocols returns the transport protocols matching the Protocol.
+// It returns nil for ProtoAuto to keep the transport defaults.
+func (p Protocol) protocols() *http.Protocols {
+	protocols := new(http.Protocols)
+
+	switch p {
+	case ProtoHTTP1:
+		protocols.SetHTTP1(true)
+	case ProtoPreferHTTP2:
+		protocols.SetHTTP1(true)
+		protocols.SetHTTP2(true)
+	case ProtoHTTP2Only:
+		protocols.SetHTTP2(true)
+	case ProtoH2C:
+		protocols.SetUnencryptedHTTP2(true)
+	default:
+		return nil
+	}
+
+	return protocols
+}
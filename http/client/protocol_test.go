@@ -0,0 +1,130 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gitlab.com/iglou.eu/goulc/http/client"
+)
+
+func TestOptions_Protocol(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	})
+
+	// TLS server negotiating HTTP/2 with ALPN
+	tlsServer := httptest.NewUnstartedServer(handler)
+	tlsServer.EnableHTTP2 = true
+	tlsServer.StartTLS()
+	defer tlsServer.Close()
+
+	// Cleartext server accepting HTTP/2 with prior knowledge
+	h2cServer := httptest.NewUnstartedServer(handler)
+	h2cServer.Config.Protocols = new(http.Protocols)
+	h2cServer.Config.Protocols.SetHTTP1(true)
+	h2cServer.Config.Protocols.SetUnencryptedHTTP2(true)
+	h2cServer.Start()
+	defer h2cServer.Close()
+
+	tests := []struct {
+		name      string
+		serverURL string
+		protocol  client.Protocol
+		onlyHTTPS bool
+		wantProto string
+		wantErrIs error
+	}{
+		{
+			name:      "auto without TLS verification",
+			serverURL: tlsServer.URL,
+			protocol:  client.ProtoAuto,
+			wantProto: "HTTP/1.1",
+		},
+		{
+			name:      "HTTP/1.1 only",
+			serverURL: tlsServer.URL,
+			protocol:  client.ProtoHTTP1,
+			wantProto: "HTTP/1.1",
+		},
+		{
+			name:      "prefer HTTP/2",
+			serverURL: tlsServer.URL,
+			protocol:  client.ProtoPreferHTTP2,
+			wantProto: "HTTP/2.0",
+		},
+		{
+			name:      "HTTP/2 only",
+			serverURL: tlsServer.URL,
+			protocol:  client.ProtoHTTP2Only,
+			wantProto: "HTTP/2.0",
+		},
+		{
+			name:      "h2c prior knowledge",
+			serverURL: h2cServer.URL,
+			protocol:  client.ProtoH2C,
+			wantProto: "HTTP/2.0",
+		},
+		{
+			name:      "h2c with OnlyHTTPS",
+			serverURL: h2cServer.URL,
+			protocol:  client.ProtoH2C,
+			onlyHTTPS: true,
+			wantErrIs: client.ErrH2COverHTTPS,
+		},
+		{
+			name:      "unknown protocol",
+			serverURL: h2cServer.URL,
+			protocol:  client.Protocol(42),
+			wantErrIs: client.ErrInvalidProtocol,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := client.New(context.Background(), tt.serverURL, nil, &client.Options{
+				OnlyHTTPS:        tt.onlyHTTPS,
+				Timeout:          time.Second,
+				DisableTLSVerify: true,
+				Protocol:         tt.protocol,
+			}, nil)
+			if !errors.Is(err, tt.wantErrIs) {
+				t.Fatalf("New() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if err != nil {
+				return
+			}
+			defer c.Close()
+
+			resp, err := c.Do(http.MethodGet, nil, nil)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			if resp.Proto != tt.wantProto || string(resp.Body) != tt.wantProto {
+				t.Errorf("Do() proto = %v (server %s), want %v", resp.Proto, resp.Body, tt.wantProto)
+			}
+		})
+	}
+}
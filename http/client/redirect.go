@@ -0,0 +1,245 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// RedirectMethod defines how the method and the body of a request are
+// handled on a 301, 302 or 303 redirect. The 307 and 308 redirects always
+// keep them, the body being replayed from the original bytes.
+type RedirectMethod uint8
+
+const (
+	// RedirectSwitchToGet follows the Go client: the 301, 302 and 303
+	// redirects switch the methods other than GET and HEAD to GET, without
+	// the body.
+	RedirectSwitchToGet RedirectMethod = iota
+	// RedirectSwitchOn303 only switches to GET on 303 See Other, the 301
+	// and 302 redirects keep the method and replay the body.
+	RedirectSwitchOn303
+	// RedirectKeepMethod keeps the method and replays the body on every
+	// redirect.
+	RedirectKeepMethod
+)
+
+var (
+	// ErrRedirectNotAllowed is returned when a redirect targets a host
+	// out of RedirectPolicy.AllowedHosts
+	ErrRedirectNotAllowed = errors.New("redirect to a host not allowed")
+
+	// ErrRedirectDowngrade is returned when a redirect goes from https to
+	// http and RedirectPolicy.ForbidDowngrade is set
+	ErrRedirectDowngrade = errors.New("redirect from https to http")
+)
+
+// bodyHeaders are the headers describing the body, dropped by the Go client
+// when a redirect switches to GET.
+var bodyHeaders = []string{
+	"Content-Encoding", "Content-Language", "Content-Location", "Content-Type",
+}
+
+// RedirectPolicy refines how the redirects are followed, on top of the
+// Follow, FollowReferer and MaxRedirect options. The host patterns are
+// either a host name, matched without the port, or a wildcard such as
+// "*.example.com" matching the subdomains but not the domain itself.
+//
+// Example:
+//
+//	opt.Redirect = &client.RedirectPolicy{
+//	    AllowedHosts:    []string{"example.com", "*.example.com"},
+//	    ForbidDowngrade: true,
+//	    Method:          client.RedirectSwitchOn303,
+//	    AuthHosts:       []string{"*.example.com"},
+//	}
+type RedirectPolicy struct {
+	// AllowedHosts restricts the redirects to these hosts, any host is
+	// allowed when empty.
+	AllowedHosts []string
+
+	// ForbidDowngrade refuses the redirects from https to http. The
+	// redirects upgraded by OnlyHTTPS are not downgrades.
+	ForbidDowngrade bool
+
+	// Method defines the method and body handling of the 301, 302 and 303
+	// redirects.
+	Method RedirectMethod
+
+	// AuthHosts are the hosts the authentication is forwarded to, on top of
+	// the host of the original request. When empty, FollowAuth forwards it
+	// to any host. The authenticator of the client computes a new header for
+	// each hop, a static Authorization header is copied. On the other hosts,
+	// the Authorization header and the headers of the authenticator, such
+	// as an API key header, are removed.
+	AuthHosts []string
+
+	// OnRedirect is called for each hop once the method and body are set,
+	// before the host checks. It can rewrite the request, such as its URL or
+	// headers, or veto the hop by returning an error;
+	// http.ErrUseLastResponse stops on the redirect response without error.
+	OnRedirect func(req *http.Request, via []*http.Request) error
+}
+
+// apply applies the policy to the next hop `req`, `body` being the body
+// of the original request.
+func (p *RedirectPolicy) apply(
+	c *Client, req *http.Request, via []*http.Request, body []byte,
+) error {
+	first, last := via[0], via[len(via)-1]
+
+	replay, err := p.rewriteMethod(req, first, last)
+	if err != nil {
+		return err
+	}
+
+	if p.OnRedirect != nil {
+		if err := p.OnRedirect(req, via); err != nil {
+			return err
+		}
+	}
+
+	if len(p.AllowedHosts) > 0 && !matchHost(p.AllowedHosts, req.URL.Hostname()) {
+		return errors.Join(ErrRedirectNotAllowed,
+			errors.New("got "+req.URL.Host))
+	}
+
+	if p.ForbidDowngrade && last.URL.Scheme == "https" && req.URL.Scheme == "http" {
+		return errors.Join(ErrRedirectDowngrade,
+			errors.New("to "+req.URL.String()))
+	}
+
+	// The authentication is removed first, the Go client keeps it on the
+	// same domain and its subdomains
+	stripAuth(req, first)
+	if !p.forwardAuth(c, first.URL, req.URL) {
+		return nil
+	}
+
+	if c.Auth == nil {
+		if value := first.Header.Get("Authorization"); value != "" {
+			req.Header.Set("Authorization", value)
+		}
+		return nil
+	}
+
+	if !replay {
+		body = nil
+	}
+	_, err = c.authenticate(req, body)
+	return err
+}
+
+// rewriteMethod restores the method and the body the Go client dropped on
+// a 301, 302 or 303 redirect, according to the policy. It reports whether
+// the body is sent with the next hop.
+func (p *RedirectPolicy) rewriteMethod(
+	req, first, last *http.Request,
+) (bool, error) {
+	hasBody := last.ContentLength != 0 && first.GetBody != nil
+
+	if req.Response == nil {
+		return false, nil
+	}
+	switch req.Response.StatusCode {
+	case http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return hasBody, nil
+	case http.StatusSeeOther:
+		if p.Method != RedirectKeepMethod {
+			return false, nil
+		}
+	case http.StatusMovedPermanently, http.StatusFound:
+		if p.Method == RedirectSwitchToGet {
+			return false, nil
+		}
+	default:
+		return false, nil
+	}
+
+	req.Method = last.Method
+	if !hasBody {
+		return false, nil
+	}
+
+	var err error
+	if req.Body, err = first.GetBody(); err != nil {
+		return false, err
+	}
+	req.GetBody = first.GetBody
+	req.ContentLength = first.ContentLength
+
+	for _, name := range bodyHeaders {
+		if values, ok := first.Header[name]; ok {
+			req.Header[name] = values
+		}
+	}
+
+	return true, nil
+}
+
+// forwardAuth reports whether the authentication of a request to `origin`
+// is forwarded to `target`.
+func (p *RedirectPolicy) forwardAuth(c *Client, origin, target *url.URL) bool {
+	if strings.EqualFold(origin.Host, target.Host) {
+		return true
+	}
+	if len(p.AuthHosts) == 0 {
+		return c.Options.FollowAuth
+	}
+
+	return matchHost(p.AuthHosts, target.Hostname())
+}
+
+// matchHost reports whether `host` matches one of the host patterns.
+func matchHost(patterns []string, host string) bool {
+	for _, pattern := range patterns {
+		if domain, ok := strings.CutPrefix(pattern, "*."); ok {
+			if len(host) > len(domain)+1 &&
+				strings.EqualFold(host[len(host)-len(domain)-1:], "."+domain) {
+				return true
+			}
+			continue
+		}
+
+		if strings.EqualFold(pattern, host) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// authHeadersKey is the context key of the names of the headers set by the
+// authenticator on the original request.
+type authHeadersKey struct{}
+
+// stripAuth removes the Authorization header and the headers set by the
+// authenticator on the original request `first`, such as an API key header.
+func stripAuth(req, first *http.Request) {
+	req.Header.Del("Authorization")
+
+	names, _ := first.Context().Value(authHeadersKey{}).([]string)
+	for _, name := range names {
+		req.Header.Del(name)
+	}
+}
@@ -0,0 +1,344 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"gitlab.com/iglou.eu/goulc/hided"
+	"gitlab.com/iglou.eu/goulc/http/client"
+	"gitlab.com/iglou.eu/goulc/http/client/auth"
+)
+
+// newRedirectServer returns a server redirecting /redirect/<code> to
+// the `location` query parameter, and answering the other paths with the
+// received method, body, content type and authorization. Every host is
+// dialed to the server.
+func newRedirectServer(t *testing.T) *client.Options {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if code, ok := strings.CutPrefix(r.URL.Path, "/redirect/"); ok {
+			status, _ := strconv.Atoi(code)
+			http.Redirect(w, r, r.URL.Query().Get("location"), status)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		w.Write([]byte(r.Host + " " + r.Method + " " + string(body) + " " +
+			r.Header.Get("Content-Type") + " " + r.Header.Get("Authorization")))
+	}))
+	t.Cleanup(ts.Close)
+
+	return &client.Options{
+		Follow:      true,
+		MaxRedirect: 5,
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, ts.Listener.Addr().String())
+		},
+	}
+}
+
+func TestRedirectPolicy_Method(t *testing.T) {
+	opt := newRedirectServer(t)
+
+	const (
+		body       = `{"faction":"brotherhood"}`
+		keepMethod = "api.citadel.wasteland POST " + body + " application/json "
+		switchGet  = "api.citadel.wasteland GET   "
+	)
+
+	tests := []struct {
+		name   string
+		method client.RedirectMethod
+		code   int
+		want   string
+	}{
+		{"302 switch to GET", client.RedirectSwitchToGet, http.StatusFound, switchGet},
+		{"307 switch to GET", client.RedirectSwitchToGet, http.StatusTemporaryRedirect, keepMethod},
+		{"301 switch on 303", client.RedirectSwitchOn303, http.StatusMovedPermanently, keepMethod},
+		{"302 switch on 303", client.RedirectSwitchOn303, http.StatusFound, keepMethod},
+		{"303 switch on 303", client.RedirectSwitchOn303, http.StatusSeeOther, switchGet},
+		{"303 keep method", client.RedirectKeepMethod, http.StatusSeeOther, keepMethod},
+		{"308 keep method", client.RedirectKeepMethod, http.StatusPermanentRedirect, keepMethod},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opt := *opt
+			opt.Redirect = &client.RedirectPolicy{Method: tt.method}
+
+			c, err := client.New(context.Background(),
+				"http://api.citadel.wasteland", nil, &opt, nil)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer c.Close()
+
+			resp, err := c.R().
+				Path("/redirect/"+strconv.Itoa(tt.code)).
+				Query("location", "/scribes").
+				Body([]byte(body)).
+				Do(http.MethodPost, nil)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			if string(resp.Body) != tt.want {
+				t.Errorf("Do() body = %q, want %q", resp.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedirectPolicy_Hosts(t *testing.T) {
+	opt := newRedirectServer(t)
+
+	basic, err := auth.NewBasic("elder", hided.String("lyons"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const authorization = "Basic ZWxkZXI6bHlvbnM="
+
+	tests := []struct {
+		name     string
+		policy   client.RedirectPolicy
+		location string
+		want     string
+		wantErr  error
+	}{
+		{
+			name:     "allowed subdomain without auth",
+			policy:   client.RedirectPolicy{AllowedHosts: []string{"*.citadel.wasteland"}},
+			location: "http://cdn.citadel.wasteland/holotape",
+			want:     "cdn.citadel.wasteland GET   ",
+		},
+		{
+			name:     "domain out of the wildcard",
+			policy:   client.RedirectPolicy{AllowedHosts: []string{"*.citadel.wasteland"}},
+			location: "http://citadel.wasteland/holotape",
+			wantErr:  client.ErrRedirectNotAllowed,
+		},
+		{
+			name:     "host not allowed",
+			policy:   client.RedirectPolicy{AllowedHosts: []string{"api.citadel.wasteland"}},
+			location: "http://enclave.wasteland/holotape",
+			wantErr:  client.ErrRedirectNotAllowed,
+		},
+		{
+			name:     "auth forwarded to the same host",
+			policy:   client.RedirectPolicy{AuthHosts: []string{"cdn.citadel.wasteland"}},
+			location: "/holotape",
+			want:     "api.citadel.wasteland GET   " + authorization,
+		},
+		{
+			name:     "auth forwarded to an auth host",
+			policy:   client.RedirectPolicy{AuthHosts: []string{"*.CITADEL.wasteland"}},
+			location: "http://cdn.citadel.wasteland/holotape",
+			want:     "cdn.citadel.wasteland GET   " + authorization,
+		},
+		{
+			name:     "auth removed on a subdomain out of the auth hosts",
+			policy:   client.RedirectPolicy{AuthHosts: []string{"cdn.citadel.wasteland"}},
+			location: "http://vault.api.citadel.wasteland/holotape",
+			want:     "vault.api.citadel.wasteland GET   ",
+		},
+		{
+			name: "vetoed hop",
+			policy: client.RedirectPolicy{
+				OnRedirect: func(*http.Request, []*http.Request) error {
+					return client.ErrRedirectDowngrade
+				},
+			},
+			location: "/holotape",
+			wantErr:  client.ErrRedirectDowngrade,
+		},
+		{
+			name: "stopped on the redirect response",
+			policy: client.RedirectPolicy{
+				OnRedirect: func(*http.Request, []*http.Request) error {
+					return http.ErrUseLastResponse
+				},
+			},
+			location: "/holotape",
+			want:     "",
+		},
+		{
+			name: "rewritten hop checked against the allowed hosts",
+			policy: client.RedirectPolicy{
+				AllowedHosts: []string{"api.citadel.wasteland"},
+				OnRedirect: func(req *http.Request, _ []*http.Request) error {
+					req.URL.Host = "enclave.wasteland"
+					return nil
+				},
+			},
+			location: "/holotape",
+			wantErr:  client.ErrRedirectNotAllowed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opt := *opt
+			opt.Redirect = &tt.policy
+
+			c, err := client.New(context.Background(),
+				"http://api.citadel.wasteland", &basic, &opt, nil)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer c.Close()
+
+			resp, err := c.R().
+				Path("/redirect/302").
+				Query("location", tt.location).
+				Do(http.MethodGet, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Do() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if string(resp.Body) != tt.want && tt.want != "" {
+				t.Errorf("Do() body = %q, want %q", resp.Body, tt.want)
+			}
+			if tt.want == "" && resp.StatusCode != http.StatusFound {
+				t.Errorf("Do() status = %d, want %d", resp.StatusCode, http.StatusFound)
+			}
+		})
+	}
+}
+
+func TestRedirectPolicy_ForbidDowngrade(t *testing.T) {
+	plain := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("plain"))
+	}))
+	defer plain.Close()
+
+	secure := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, plain.URL, http.StatusFound)
+	}))
+	defer secure.Close()
+
+	for _, forbid := range []bool{false, true} {
+		c, err := client.New(context.Background(), secure.URL, nil, &client.Options{
+			Follow:           true,
+			MaxRedirect:      5,
+			DisableTLSVerify: true,
+			Redirect:         &client.RedirectPolicy{ForbidDowngrade: forbid},
+		}, nil)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		resp, err := c.Do(http.MethodGet, nil, nil)
+		switch {
+		case forbid && !errors.Is(err, client.ErrRedirectDowngrade):
+			t.Errorf("Do() error = %v, wantErr %v", err, client.ErrRedirectDowngrade)
+		case !forbid && (err != nil || string(resp.Body) != "plain"):
+			t.Errorf("Do() error = %v, want the plain server response", err)
+		}
+		c.Close()
+	}
+}
+
+func TestRedirect_AuthenticatorHeader(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, r.URL.Query().Get("location"), http.StatusFound)
+			return
+		}
+		w.Write([]byte(r.Host + " " + r.Header.Get("X-Api-Key")))
+	}))
+	defer ts.Close()
+
+	apiKey, err := auth.NewAPIKey("X-Api-Key", hided.String("lyons"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		policy   *client.RedirectPolicy
+		location string
+		want     string
+	}{
+		{
+			name:     "default removes the key on another host",
+			location: "http://cdn.citadel.wasteland/holotape",
+			want:     "cdn.citadel.wasteland ",
+		},
+		{
+			name:     "default keeps the key on the same host",
+			location: "/holotape",
+			want:     "api.citadel.wasteland lyons",
+		},
+		{
+			name:     "policy removes the key on another host",
+			policy:   &client.RedirectPolicy{},
+			location: "http://cdn.citadel.wasteland/holotape",
+			want:     "cdn.citadel.wasteland ",
+		},
+		{
+			name:     "policy forwards the key to an auth host",
+			policy:   &client.RedirectPolicy{AuthHosts: []string{"cdn.citadel.wasteland"}},
+			location: "http://cdn.citadel.wasteland/holotape",
+			want:     "cdn.citadel.wasteland lyons",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opt := &client.Options{
+				Follow:      true,
+				MaxRedirect: 5,
+				Redirect:    tt.policy,
+				DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, network, ts.Listener.Addr().String())
+				},
+			}
+
+			c, err := client.New(context.Background(),
+				"http://api.citadel.wasteland", &apiKey, opt, nil)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer c.Close()
+
+			resp, err := c.R().
+				Path("/redirect").
+				Query("location", tt.location).
+				Do(http.MethodGet, nil)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			if string(resp.Body) != tt.want {
+				t.Errorf("Do() body = %q, want %q", resp.Body, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client
+
+import (
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"gitlab.com/iglou.eu/goulc/http/client/auth"
+)
+
+// Request is an immutable builder of a single request, created by Client.R.
+// Each method returns a new Request and leaves the receiver unchanged, so a
+// Request can be shared and derived concurrently. The values are merged with
+// the client defaults for the call only, the client is never modified.
+//
+// Example:
+//
+//	resp, err := c.R().
+//	    Path("/users/42").
+//	    Query("fields", "name").
+//	    Header("X-Request-ID", "minsc").
+//	    Do(http.MethodGet, &user)
+type Request struct {
+	client *Client
+	err    error
+
+	// path is escaped, to keep the encoded "/" of the template variables
+	path    string
+	query   url.Values
+	header  http.Header
+	body    []byte
+	marshal Marshaler
+	auth    auth.Authenticator
+}
+
+// R returns a new Request builder sending through the client.
+func (main *Client) R() Request {
+	return Request{client: main}
+}
+
+// Path returns a copy of the Request with `path` appended to the client
+// path, following the same rules as NewChild.
+func (r Request) Path(path string) Request {
+	r.path += escapePath(path)
+
+	return r
+}
+
+// Template returns a copy of the Request with the path and query expanded
+// from an RFC 6570 URI template, see Client.NewChildTemplate. An invalid
+// template is reported by Do.
+func (r Request) Template(template string, vars map[string]any) Request {
+	path, query, err := expandTemplate(template, vars)
+	if err != nil {
+		r.err = err
+		return r
+	}
+	r.path += path
+
+	if len(query) > 0 {
+		merged := maps.Clone(r.query)
+		if merged == nil {
+			merged = make(url.Values)
+		}
+		for key, values := range query {
+			merged[key] = append(slices.Clone(merged[key]), values...)
+		}
+		r.query = merged
+	}
+
+	return r
+}
+
+// Query returns a copy of the Request with the query parameter added to the
+// client ones.
+func (r Request) Query(key, value string) Request {
+	query := maps.Clone(r.query)
+	if query == nil {
+		query = make(url.Values)
+	}
+	query[key] = append(slices.Clone(query[key]), value)
+	r.query = query
+
+	return r
+}
+
+// Header returns a copy of the Request with the header set, replacing the
+// client one of the same name.
+func (r Request) Header(key, value string) Request {
+	header := r.header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Set(key, value)
+	r.header = header
+
+	return r
+}
+
+// Body returns a copy of the Request sending `body`. The slice must not be
+// modified until the requests using it are done.
+func (r Request) Body(body []byte) Request {
+	r.body = body
+	r.marshal = nil
+
+	return r
+}
+
+// Marshal returns a copy of the Request sending `body`, marshaled when the
+// request is done, with its content type.
+func (r Request) Marshal(body Marshaler) Request {
+	r.body = nil
+	r.marshal = body
+
+	return r
+}
+
+// Auth returns a copy of the Request authenticated with `authenticator`
+// instead of the client one. The authenticator is shared by the requests
+// using it, its Update and Header calls are serialized like the client one.
+func (r Request) Auth(authenticator auth.Authenticator) Request {
+	r.auth = authenticator
+
+	return r
+}
+
+// Do sends the request with the given method, see Client.Do.
+func (r Request) Do(method string, respUml Unmarshaler) (*Response, error) {
+	if r.client == nil {
+		return nil, ErrClientClosed
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	return r.client.do(method, r.body, respUml, &r)
+}
@@ -0,0 +1,166 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/iglou.eu/goulc/http/client"
+	"gitlab.com/iglou.eu/goulc/http/client/auth"
+)
+
+// echoed is the request seen by the echo server
+type echoed struct {
+	Path        string              `json:"path"`
+	Query       map[string][]string `json:"query"`
+	Header      map[string]string   `json:"header"`
+	Body        string              `json:"body"`
+	ContentType string              `json:"content_type"`
+}
+
+func (_ *echoed) Name() string { return "echoed" }
+func (e *echoed) Unmarshal(_ int, _ http.Header, body []byte) error {
+	return json.Unmarshal(body, e)
+}
+
+func TestClient_R(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_ = json.NewEncoder(w).Encode(echoed{
+			Path:  r.URL.Path,
+			Query: r.URL.Query(),
+			Header: map[string]string{
+				"X-Party": r.Header.Get("X-Party"),
+				"X-Quest": r.Header.Get("X-Quest"),
+			},
+			Body:        string(body),
+			ContentType: r.Header.Get("Content-Type"),
+		})
+	}))
+	defer ts.Close()
+
+	c, err := client.New(context.Background(), ts.URL+"/api?lang=en", nil, &client.Options{Timeout: time.Second}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+	c.Header.Set("X-Party", "minsc")
+
+	base := c.R().Path("/users").Query("fields", "name")
+	derived := base.Path("/42").Query("fields", "class").Header("X-Party", "jaheira").Header("X-Quest", "rescue")
+
+	var got echoed
+	if _, err := derived.Body([]byte(`{"hp":42}`)).Do(http.MethodPost, &got); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if got.Path != "/api/users/42" || got.Header["X-Party"] != "jaheira" || got.Header["X-Quest"] != "rescue" ||
+		got.Body != `{"hp":42}` || len(got.Query["fields"]) != 2 || got.Query["lang"][0] != "en" {
+		t.Errorf("Do() sent %+v", got)
+	}
+
+	// The base builder is not modified by the derived one
+	got = echoed{}
+	if _, err := base.Do(http.MethodGet, &got); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if got.Path != "/api/users" || got.Header["X-Party"] != "minsc" || got.Header["X-Quest"] != "" ||
+		len(got.Query["fields"]) != 1 || got.Body != "" {
+		t.Errorf("Do() sent %+v", got)
+	}
+
+	// Marshaled bodies set their content type
+	got = echoed{}
+	if _, err := c.R().Marshal(&testMarshaler{Message: "Go for the eyes"}).Do(http.MethodPost, &got); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if got.ContentType != "application/json" || got.Body != `{"message":"Go for the eyes"}` {
+		t.Errorf("Do() sent %+v", got)
+	}
+
+	// Concurrent builders never leak into each other nor into the client
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			id := strconv.Itoa(i)
+
+			var got echoed
+			if _, err := base.Query("id", id).Header("X-Quest", id).Do(http.MethodGet, &got); err != nil {
+				t.Errorf("Do() error = %v", err)
+				return
+			}
+			if got.Header["X-Quest"] != id || len(got.Query["id"]) != 1 || got.Query["id"][0] != id {
+				t.Errorf("Do() sent %+v, want id %s", got, id)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if c.URL.Path != "/api" || len(c.Query) != 1 || c.Header.Get("X-Quest") != "" {
+		t.Errorf("client modified: path %s, query %v, header %v", c.URL.Path, c.Query, c.Header)
+	}
+
+	// A closed client cannot send requests
+	c.Close()
+	if _, err := base.Do(http.MethodGet, nil); !errors.Is(err, client.ErrClientClosed) {
+		t.Errorf("Do() error = %v, want %v", err, client.ErrClientClosed)
+	}
+}
+
+func TestRequest_Auth(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer ts.Close()
+
+	basic, _ := auth.NewBasic("minsc", "boo")
+	c, err := client.New(context.Background(), ts.URL, &basic, &client.Options{Timeout: time.Second}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	bearer, _ := auth.NewBearer("hamster")
+	resp, err := c.R().Auth(&bearer).Do(http.MethodGet, nil)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if string(resp.Body) != "Bearer hamster" {
+		t.Errorf("Authorization = %s, want the request authenticator", resp.Body)
+	}
+
+	// The client authenticator is kept for the other requests
+	if resp, err = c.R().Do(http.MethodGet, nil); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if string(resp.Body) != auth.BasicValuePrefix+auth.BasicUserPass("minsc", "boo") {
+		t.Errorf("Authorization = %s, want the client authenticator", resp.Body)
+	}
+}
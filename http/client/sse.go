@@ -0,0 +1,321 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"iter"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// EventStreamContentType is the media type of Server-Sent Events
+	EventStreamContentType = "text/event-stream"
+
+	// EventDefaultType is the type of the events without an event field
+	EventDefaultType = "message"
+
+	// EventDefaultRetry is the reconnection delay used until the server
+	// provides a retry hint
+	EventDefaultRetry = 3 * time.Second
+
+	// EventMaxRetry is the longest reconnection delay accepted from the
+	// server, the longer retry hints are clamped to it
+	EventMaxRetry = time.Hour
+
+	// EventMaxLineSize is the maximum size of a single event stream line
+	EventMaxLineSize = 1 << 20
+)
+
+var (
+	// ErrEventStreamStatus is returned when the server answers the event
+	// stream request with a non-200 status
+	ErrEventStreamStatus = errors.New("unexpected event stream status")
+
+	// ErrEventStreamContentType is returned when the server answers the
+	// event stream request with another content type
+	ErrEventStreamContentType = errors.New(
+		"unexpected event stream content type")
+
+	// ErrEventLineTooLong is returned when a line of the event stream is
+	// longer than EventMaxLineSize
+	ErrEventLineTooLong = errors.New("event stream line too long")
+)
+
+// Event is a single Server-Sent Event.
+// HTML Living Standard §9.2: https://html.spec.whatwg.org/#server-sent-events
+type Event struct {
+	// ID is the last event ID of the stream when the event was dispatched
+	ID string
+
+	// Event is the event type, "message" when not provided by the server
+	Event string
+
+	// Data is the event payload, multiple data lines are joined with "\n"
+	Data string
+
+	// Retry is the reconnection delay in effect when the event was
+	// dispatched
+	Retry time.Duration
+}
+
+// eventStream holds the state kept across the reconnections.
+type eventStream struct {
+	lastID string
+	retry  time.Duration
+}
+
+// Events consumes the client URL as a Server-Sent Events stream and returns
+// an iterator over the received events. The stream is requested with
+// a GET through the client, so headers, authentication, rate limiting and
+// balancing apply to each connection.
+//
+// When the connection is lost, the client reconnects after the retry delay
+// provided by the server, sending the `Last-Event-ID` header. Connection
+// failures and read errors are yielded as errors and followed by
+// a reconnection, unless the loop is stopped. A non-200 status, a content
+// type other than `text/event-stream` or a line longer than
+// EventMaxLineSize is yielded as a final error. The iteration ends when
+// the server answers with 204 No Content, when the client context is
+// canceled or when the loop is stopped.
+//
+// The client Timeout does not apply to the event stream.
+//
+// Example:
+//
+//	for event, err := range client.Events("") {
+//	    if err != nil {
+//	        log.Println(err)
+//	        continue
+//	    }
+//	    fmt.Println(event.Event, event.Data)
+//	}
+func (main *Client) Events(lastEventID string) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		if main.IsClosed() {
+			yield(Event{}, ErrClientClosed)
+			return
+		}
+
+		c := main.Clone()
+		if c == nil {
+			yield(Event{}, ErrClientClosed)
+			return
+		}
+		defer c.Close()
+
+		// The stream is long-lived, it ends with the client context
+		c.Options.Timeout = 0
+		c.Header.Set("Accept", EventStreamContentType)
+		c.Header.Set("Cache-Control", "no-cache")
+
+		if len(c.Query) > 0 {
+			c.URL.RawQuery = c.Query.Encode()
+		}
+
+		stream := eventStream{
+			lastID: lastEventID,
+			retry:  EventDefaultRetry,
+		}
+
+		for {
+			if stream.lastID != "" {
+				c.Header.Set("Last-Event-ID", stream.lastID)
+			} else {
+				c.Header.Del("Last-Event-ID")
+			}
+
+			next := stream.connect(c, yield)
+			if !next || c.context.Err() != nil {
+				return
+			}
+
+			c.logger.Debug("event stream reconnection",
+				"url", c.URL.String(),
+				"last_event_id", stream.lastID,
+				"retry", stream.retry)
+
+			select {
+			case <-c.context.Done():
+				return
+			case <-time.After(stream.retry):
+			}
+		}
+	}
+}
+
+// connect opens the event stream and reads it until the connection ends.
+// It returns false when the stream must not be reconnected.
+func (s *eventStream) connect(
+	c *Client, yield func(Event, error) bool,
+) bool {
+	res, _, err := c.dispatch(http.MethodGet, nil)
+	if err != nil {
+		return c.context.Err() == nil && yield(Event{}, err)
+	}
+	defer res.Body.Close()
+
+	// HTTP 204 No Content asks the client to stop reconnecting
+	if res.StatusCode == http.StatusNoContent {
+		c.logger.Debug("event stream closed by the server")
+		return false
+	}
+
+	if res.StatusCode != http.StatusOK {
+		yield(Event{}, errors.Join(ErrEventStreamStatus,
+			errors.New("got "+res.Status)))
+		return false
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if mediaType != EventStreamContentType {
+		yield(Event{}, errors.Join(ErrEventStreamContentType,
+			errors.New("got "+res.Header.Get("Content-Type"))))
+		return false
+	}
+
+	// The read errors caused by the end of the client are not reported
+	return s.read(res.Body, func(event Event, err error) bool {
+		if err != nil && c.context.Err() != nil {
+			return false
+		}
+		return yield(event, err)
+	})
+}
+
+// read parses the event stream and yields the dispatched events, then the
+// read error if any. It returns false when the consumer stopped the
+// iteration or when the stream must not be reconnected.
+// HTML Living Standard §9.2.6: https://html.spec.whatwg.org/#event-stream-interpretation
+func (s *eventStream) read(body io.Reader, yield func(Event, error) bool) bool {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), EventMaxLineSize)
+	scanner.Split(scanEventLines)
+
+	var eventType string
+	var data strings.Builder
+
+	first := true
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		// A leading byte order mark must be ignored
+		if first {
+			line = strings.TrimPrefix(line, "\uFEFF")
+			first = false
+		}
+
+		// An empty line dispatches the event
+		if line == "" {
+			if data.Len() == 0 {
+				eventType = ""
+				continue
+			}
+
+			event := Event{
+				ID:    s.lastID,
+				Event: eventType,
+				Data:  strings.TrimSuffix(data.String(), "\n"),
+				Retry: s.retry,
+			}
+			if event.Event == "" {
+				event.Event = EventDefaultType
+			}
+
+			eventType = ""
+			data.Reset()
+
+			if !yield(event, nil) {
+				return false
+			}
+			continue
+		}
+
+		// Lines starting with a colon are comments
+		if line[0] == ':' {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			eventType = value
+		case "data":
+			data.WriteString(value)
+			data.WriteByte('\n')
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				s.lastID = value
+			}
+		case "retry":
+			if ms, err := strconv.ParseUint(value, 10, 63); err == nil {
+				s.retry = min(time.Duration(ms), EventMaxRetry/time.Millisecond) *
+					time.Millisecond
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		// The same line would be sent again after a reconnection
+		if errors.Is(err, bufio.ErrTooLong) {
+			yield(Event{}, errors.Join(ErrEventLineTooLong, err))
+			return false
+		}
+
+		return yield(Event{}, err)
+	}
+
+	// Incomplete events are discarded, the connection is simply reopened
+	return true
+}
+
+// scanEventLines is a bufio.SplitFunc splitting lines ended by
+// CRLF, LF or CR.
+func scanEventLines(data []byte, atEOF bool) (int, []byte, error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+
+	i := bytes.IndexAny(data, "\r\n")
+	switch {
+	case i < 0 && atEOF:
+		return len(data), data, nil
+	case i < 0:
+		return 0, nil, nil
+	case data[i] == '\n':
+		return i + 1, data[:i], nil
+	case i+1 < len(data) && data[i+1] == '\n':
+		return i + 2, data[:i], nil
+	case i+1 < len(data) || atEOF:
+		return i + 1, data[:i], nil
+	}
+
+	// A CR ends the buffer, wait to know if a LF follows
+	return 0, nil, nil
+}
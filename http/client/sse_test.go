@@ -0,0 +1,203 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client_test
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitlab.com/iglou.eu/goulc/http/client"
+	"gitlab.com/iglou.eu/goulc/http/client/auth"
+)
+
+func TestClient_Events(t *testing.T) {
+	var connections atomic.Int32
+	lastIDs := make(chan string, 3)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, ok := r.BasicAuth(); !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		lastIDs <- r.Header.Get("Last-Event-ID")
+
+		switch connections.Add(1) {
+		case 1:
+			w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+			_, _ = w.Write([]byte("\uFEFF: welcome to the Elfsong Tavern\n" +
+				"retry: 10\n" +
+				"data: first\n\n" +
+				"id: 1\r\n" +
+				"event: quest\r\n" +
+				"data: rescue\r\n" +
+				"data: Imoen\r\n\r\n" +
+				"id: 2\r" +
+				"data:no space\r\r" +
+				"data: incomplete"))
+		case 2:
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("id: 3\ndata: resumed\n\n"))
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer ts.Close()
+
+	c, err := client.New(context.Background(), ts.URL, &auth.Basic{UserID: "jaheira", Password: "harper"},
+		&client.Options{Timeout: time.Second}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	var got []client.Event
+	for event, err := range c.Events("") {
+		if err != nil {
+			t.Fatalf("Events() error = %v", err)
+		}
+		got = append(got, event)
+	}
+
+	retry := 10 * time.Millisecond
+	want := []client.Event{
+		{ID: "", Event: "message", Data: "first", Retry: retry},
+		{ID: "1", Event: "quest", Data: "rescue\nImoen", Retry: retry},
+		{ID: "2", Event: "message", Data: "no space", Retry: retry},
+		{ID: "3", Event: "message", Data: "resumed", Retry: retry},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Events() = %+v, want %+v", got, want)
+	}
+
+	close(lastIDs)
+	var ids []string
+	for id := range lastIDs {
+		ids = append(ids, id)
+	}
+	if wantIDs := []string{"", "2", "3"}; !reflect.DeepEqual(ids, wantIDs) {
+		t.Errorf("Last-Event-ID headers = %q, want %q", ids, wantIDs)
+	}
+}
+
+func TestClient_EventsRetryLimit(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("retry: 9223372036854775807\ndata: patience\n\n"))
+	}))
+	defer ts.Close()
+
+	c, err := client.New(context.Background(), ts.URL, nil, &client.Options{Timeout: time.Second}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	// The hint would overflow the duration, it is clamped
+	for event, err := range c.Events("") {
+		if err != nil {
+			t.Fatalf("Events() error = %v", err)
+		}
+		if event.Retry != client.EventMaxRetry {
+			t.Errorf("Events() retry = %v, want %v", event.Retry, client.EventMaxRetry)
+		}
+		break
+	}
+}
+
+func TestClient_EventsErrors(t *testing.T) {
+	var longHits atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{}`))
+		case "/long":
+			longHits.Add(1)
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = w.Write([]byte("data: " + strings.Repeat("x", client.EventMaxLineSize) + "\n\n"))
+		default:
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c, err := client.New(ctx, ts.URL, nil, &client.Options{Timeout: time.Second}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	tests := []struct {
+		path      string
+		wantErrIs error
+	}{
+		{path: "/forbidden", wantErrIs: client.ErrEventStreamStatus},
+		{path: "/json", wantErrIs: client.ErrEventStreamContentType},
+		{path: "/long", wantErrIs: bufio.ErrTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			var gotErr error
+			for _, err := range c.NewChild(tt.path).Events("") {
+				gotErr = err
+			}
+			if !errors.Is(gotErr, tt.wantErrIs) {
+				t.Errorf("Events() error = %v, want %v", gotErr, tt.wantErrIs)
+			}
+		})
+	}
+
+	// A too long line is final, the stream is not reopened
+	if got := longHits.Load(); got != 1 {
+		t.Errorf("Events() long line connections = %d, want 1", got)
+	}
+
+	// Canceling the client context ends the iteration
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range c.NewChild("/idle").Events("") {
+			t.Error("Events() unexpected event")
+		}
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Events() did not stop with the client context")
+	}
+}
@@ -0,0 +1,300 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client
+
+import (
+	"math/bits"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// StatsWindow is the duration covered by the request statistics.
+	StatsWindow = time.Minute
+
+	// StatsMaxPaths limits the number of paths tracked separately, the
+	// requests of the other paths are only counted in the totals.
+	StatsMaxPaths = 1024
+
+	// statsSlots is the number of slots the window is divided in, the
+	// oldest slot is dropped as the window slides.
+	statsSlots = 12
+	statsSlot  = StatsWindow / statsSlots
+
+	// latencyBuckets is the number of buckets of the latency histogram.
+	// They follow a log-linear scale of the microseconds, with 4 buckets
+	// per power of two, so the percentiles are within 12.5% of the exact
+	// value up to about an hour.
+	latencyBuckets = 128
+	latencySubBits = 2
+)
+
+// RequestStats are the statistics of the requests over the window.
+type RequestStats struct {
+	// Requests is the number of requests
+	Requests int64
+
+	// Errors is the number of requests failed with a transport error
+	// or an error status (>= 400)
+	Errors int64
+
+	// ErrorRate is the percentage of failed requests
+	ErrorRate float64
+
+	// RequestRate is the number of requests per second
+	RequestRate float64
+
+	// P50, P90 and P99 are the latency percentiles, from the request
+	// start to the response headers
+	P50, P90, P99 time.Duration
+}
+
+// Stats are the request statistics shared by a client and all its children,
+// over a sliding window of StatsWindow.
+type Stats struct {
+	RequestStats
+
+	// Window is the duration covered, shorter than StatsWindow for
+	// a recent client
+	Window time.Duration
+
+	// Paths are the statistics per client path, as set with NewChild
+	Paths map[string]RequestStats
+}
+
+// Stats returns the request statistics of the client tree: the root client
+// and all its children share the same statistics.
+func (c *Client) Stats() Stats {
+	c.Mu.RLock()
+	engine := c.stats
+	c.Mu.RUnlock()
+
+	if engine == nil {
+		return Stats{}
+	}
+
+	return engine.snapshot(time.Now())
+}
+
+// recordStats counts the request in the statistics of the client tree, it
+// returns the error rate of the tree.
+func (c *Client) recordStats(
+	statusCode int, err error, latency time.Duration,
+) float64 {
+	if c.stats == nil {
+		return 0
+	}
+
+	failed := err != nil || statusCode >= http.StatusBadRequest
+	return c.stats.record(c.statsPath, failed, latency, time.Now())
+}
+
+// statsSlotData holds the counters of a slot of the window.
+type statsSlotData struct {
+	start    int64 // slot number since the Unix epoch
+	requests int64
+	errors   int64
+	latency  [latencyBuckets]uint32
+}
+
+// pathStats is a ring of slots covering the window.
+type pathStats struct {
+	slots [statsSlots]statsSlotData
+}
+
+// slot returns the slot for the slot number, reset if it was used by
+// a previous window.
+func (p *pathStats) slot(n int64) *statsSlotData {
+	s := &p.slots[n%statsSlots]
+	if s.start != n {
+		*s = statsSlotData{start: n}
+	}
+
+	return s
+}
+
+// record counts a request in the slot number `n`.
+func (p *pathStats) record(n int64, failed bool, bucket int) {
+	s := p.slot(n)
+	s.requests++
+	if failed {
+		s.errors++
+	}
+	s.latency[bucket]++
+}
+
+// counts returns the requests and errors of the window ending in the
+// slot number `n`.
+func (p *pathStats) counts(n int64) (int64, int64) {
+	var requests, errs int64
+	for i := range p.slots {
+		if s := &p.slots[i]; s.start > n-statsSlots {
+			requests += s.requests
+			errs += s.errors
+		}
+	}
+
+	return requests, errs
+}
+
+// stats returns the statistics of the window ending in the slot number `n`.
+func (p *pathStats) stats(n int64, window time.Duration) RequestStats {
+	var rs RequestStats
+	var latency [latencyBuckets]int64
+
+	for i := range p.slots {
+		s := &p.slots[i]
+		if s.start <= n-statsSlots {
+			continue
+		}
+
+		rs.Requests += s.requests
+		rs.Errors += s.errors
+		for b, count := range s.latency {
+			latency[b] += int64(count)
+		}
+	}
+
+	if rs.Requests == 0 {
+		return rs
+	}
+
+	rs.ErrorRate = float64(rs.Errors) / float64(rs.Requests) * percent
+	rs.RequestRate = float64(rs.Requests) / window.Seconds()
+	rs.P50 = latencyPercentile(&latency, rs.Requests, 50)
+	rs.P90 = latencyPercentile(&latency, rs.Requests, 90)
+	rs.P99 = latencyPercentile(&latency, rs.Requests, 99)
+
+	return rs
+}
+
+// statsEngine records the requests of a client tree.
+type statsEngine struct {
+	mu      sync.Mutex
+	created time.Time
+	total   pathStats
+	paths   map[string]*pathStats
+}
+
+func newStatsEngine() *statsEngine {
+	return &statsEngine{
+		created: time.Now(),
+		paths:   make(map[string]*pathStats),
+	}
+}
+
+// record counts a request on the path and returns the error rate of the
+// client tree over the window.
+func (e *statsEngine) record(
+	path string, failed bool, latency time.Duration, now time.Time,
+) float64 {
+	n := now.UnixNano() / int64(statsSlot)
+	bucket := latencyBucket(latency)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.total.record(n, failed, bucket)
+
+	p, ok := e.paths[path]
+	if !ok && len(e.paths) < StatsMaxPaths {
+		p = &pathStats{}
+		e.paths[path] = p
+	}
+	if p != nil {
+		p.record(n, failed, bucket)
+	}
+
+	requests, errs := e.total.counts(n)
+	return float64(errs) / float64(requests) * percent
+}
+
+// snapshot returns the statistics of the window ending now.
+func (e *statsEngine) snapshot(now time.Time) Stats {
+	n := now.UnixNano() / int64(statsSlot)
+	window := min(now.Sub(e.created), StatsWindow)
+	window = max(window, time.Second)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	stats := Stats{
+		RequestStats: e.total.stats(n, window),
+		Window:       window,
+		Paths:        make(map[string]RequestStats, len(e.paths)),
+	}
+
+	for path, p := range e.paths {
+		rs := p.stats(n, window)
+		if rs.Requests == 0 {
+			// Forget the paths without request in the window
+			delete(e.paths, path)
+			continue
+		}
+		stats.Paths[path] = rs
+	}
+
+	return stats
+}
+
+// latencyBucket returns the histogram bucket of the latency.
+func latencyBucket(latency time.Duration) int {
+	us := max(latency.Microseconds(), 0)
+	if us < 1<<latencySubBits {
+		return int(us)
+	}
+
+	exp := bits.Len64(uint64(us)) - 1
+	sub := int(us>>(exp-latencySubBits)) & (1<<latencySubBits - 1)
+
+	return min((exp-latencySubBits+1)<<latencySubBits+sub, latencyBuckets-1)
+}
+
+// latencyBucketValue returns the middle of the bucket range.
+func latencyBucketValue(bucket int) time.Duration {
+	if bucket < 1<<latencySubBits {
+		return time.Duration(bucket) * time.Microsecond
+	}
+
+	exp := bucket>>latencySubBits + latencySubBits - 1
+	sub := bucket & (1<<latencySubBits - 1)
+	width := int64(1) << (exp - latencySubBits)
+	lower := int64(1<<latencySubBits+sub) * width
+
+	return time.Duration(lower+width/2) * time.Microsecond
+}
+
+// latencyPercentile returns the latency below which `p` percent of the
+// requests are.
+func latencyPercentile(
+	histogram *[latencyBuckets]int64, requests int64, p int64,
+) time.Duration {
+	rank := (requests*p + percent - 1) / percent
+	var seen int64
+	for bucket, count := range histogram {
+		seen += count
+		if seen >= rank {
+			return latencyBucketValue(bucket)
+		}
+	}
+
+	return latencyBucketValue(latencyBuckets - 1)
+}
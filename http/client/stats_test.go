@@ -0,0 +1,107 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client_test
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gitlab.com/iglou.eu/goulc/http/client"
+)
+
+func TestClient_Stats(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/slow":
+			time.Sleep(20 * time.Millisecond)
+		case "/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer ts.Close()
+
+	main, err := client.New(context.Background(), ts.URL, nil, &client.Options{Timeout: time.Second}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer main.Close()
+
+	slow, broken := main.NewChild("/slow"), main.NewChild("/broken")
+
+	for range 10 {
+		if _, err := slow.Do(http.MethodGet, nil, nil); err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+	}
+
+	var resp *client.Response
+	for range 10 {
+		if resp, err = broken.Do(http.MethodGet, nil, nil); err != nil {
+			t.Fatalf("Do() error = %v", err)
+		}
+	}
+
+	// The error rate is the one of the whole tree
+	if resp.ErrorRate != 50 {
+		t.Errorf("Response.ErrorRate = %v, want 50", resp.ErrorRate)
+	}
+
+	// Every client of the tree shares the statistics
+	stats := broken.Stats()
+	if root := main.Stats(); root.Requests != stats.Requests {
+		t.Errorf("root Stats().Requests = %d, child %d", root.Requests, stats.Requests)
+	}
+
+	if stats.Requests != 20 || stats.Errors != 10 || stats.ErrorRate != 50 {
+		t.Errorf("Stats() = %d requests, %d errors, %v%%, want 20, 10, 50%%",
+			stats.Requests, stats.Errors, stats.ErrorRate)
+	}
+	if want := 20 / stats.Window.Seconds(); math.Abs(stats.RequestRate-want) > 1e-9 {
+		t.Errorf("Stats().RequestRate = %v, want %v", stats.RequestRate, want)
+	}
+
+	slowStats, ok := stats.Paths["/slow"]
+	if !ok || slowStats.Requests != 10 || slowStats.Errors != 0 {
+		t.Fatalf("Stats().Paths[/slow] = %+v, want 10 requests", slowStats)
+	}
+	if slowStats.P50 < 17*time.Millisecond || slowStats.P50 > slowStats.P90 ||
+		slowStats.P90 > slowStats.P99 || slowStats.P99 > time.Second {
+		t.Errorf("Stats().Paths[/slow] percentiles = %v, %v, %v, want about 20ms",
+			slowStats.P50, slowStats.P90, slowStats.P99)
+	}
+
+	if brokenStats := stats.Paths["/broken"]; brokenStats.ErrorRate != 100 ||
+		brokenStats.P99 >= slowStats.P50 {
+		t.Errorf("Stats().Paths[/broken] = %+v, want fast failures", brokenStats)
+	}
+
+	// Transport errors are counted as failures
+	ts.Close()
+	if _, err := slow.Do(http.MethodGet, nil, nil); err == nil {
+		t.Fatal("Do() on a closed server succeeded")
+	}
+	if got := main.Stats().Paths["/slow"].Errors; got != 1 {
+		t.Errorf("Stats().Paths[/slow].Errors = %d, want 1", got)
+	}
+}
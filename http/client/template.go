@@ -0,0 +1,149 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+
+	"gitlab.com/iglou.eu/goulc/http/utils"
+)
+
+// NewChildTemplate creates a new child Client, like NewChild, with the path
+// and query expanded from an RFC 6570 URI template. The variables are
+// percent-encoded, so an identifier containing "/" or "?" stays in its path
+// segment. The expanded query parameters are added to the child ones.
+//
+// Example:
+//
+//	issues, err := c.NewChildTemplate("/repos/{owner}/{repo}/issues{?state,labels}",
+//	    map[string]any{"owner": "iglou", "repo": "goulc",
+//	        "state": "open", "labels": []string{"bug", "ui"}})
+//	// issues URL will be https://api.example.com/repos/iglou/goulc/issues
+//	// issues Query will be {"labels": ["bug,ui"], "state": ["open"]}
+func (c *Client) NewChildTemplate(
+	template string, vars map[string]any,
+) (*Client, error) {
+	path, query, err := expandTemplate(template, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	child := c.Clone()
+	if child == nil {
+		return nil, ErrClientClosed
+	}
+
+	if path != "" {
+		appendEscapedPath(&child.URL, path)
+	}
+
+	if child.Query == nil {
+		child.Query = make(url.Values)
+	}
+	for key, values := range query {
+		child.Query[key] = append(child.Query[key], values...)
+	}
+
+	c.logger.Debug("new child client created",
+		"parent_url", c.URL.String(),
+		"child_url", child.URL.String())
+	return child, nil
+}
+
+// DoTemplate performs a request, like Do, on the path and query expanded
+// from an RFC 6570 URI template, see NewChildTemplate.
+//
+// Example:
+//
+//	resp, err := c.DoTemplate(http.MethodGet, "/users/{id}{?fields*}",
+//	    map[string]any{"id": id, "fields": []string{"name", "email"}},
+//	    nil, &user)
+func (main *Client) DoTemplate(
+	method, template string, vars map[string]any,
+	body []byte, respUml Unmarshaler,
+) (*Response, error) {
+	return main.R().Template(template, vars).Body(body).Do(method, respUml)
+}
+
+// expandTemplate expands the template and splits the result in an escaped
+// path and its query parameters.
+func expandTemplate(
+	template string, vars map[string]any,
+) (string, url.Values, error) {
+	expanded, err := utils.ExpandTemplate(template, vars)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if strings.IndexByte(expanded, '#') >= 0 {
+		return "", nil, errors.Join(utils.ErrInvalidTemplate,
+			errors.New("fragment is not allowed in "+template))
+	}
+
+	path, rawQuery, _ := strings.Cut(expanded, "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return "", nil, errors.Join(ErrInvalidQuery,
+			errors.New("failed to parse query "+rawQuery), err)
+	}
+
+	if path != "" {
+		path = utils.PathFormatting(path)
+	}
+
+	return path, query, nil
+}
+
+// escapePath formats and escapes a plain path to be appended to the client
+// URL. An empty path stays empty.
+func escapePath(path string) string {
+	if path == "" {
+		return ""
+	}
+
+	return (&url.URL{Path: utils.PathFormatting(path)}).EscapedPath()
+}
+
+// appendEscapedPath appends an escaped path to the URL path. The escaped
+// form is kept as the raw path, so an encoded "/" is not mistaken for a
+// segment separator.
+func appendEscapedPath(u *url.URL, escaped string) {
+	base := u.EscapedPath()
+	if base == "/" || base == "" {
+		setEscapedPath(u, escaped)
+		return
+	}
+
+	setEscapedPath(u, base+escaped)
+}
+
+// setEscapedPath sets both the decoded and the raw path of the URL.
+func setEscapedPath(u *url.URL, escaped string) {
+	path, err := url.PathUnescape(escaped)
+	if err != nil {
+		// Not a valid escaping, the path is taken literally
+		u.Path, u.RawPath = escaped, ""
+		return
+	}
+
+	u.Path, u.RawPath = path, escaped
+}
@@ -0,0 +1,114 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/iglou.eu/goulc/http/client"
+	"gitlab.com/iglou.eu/goulc/http/utils"
+)
+
+func TestClient_Template(t *testing.T) {
+	var mu sync.Mutex
+	var uris []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		uris = append(uris, r.RequestURI)
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	c, err := client.New(context.Background(), ts.URL+"/api?lang=en", nil, &client.Options{Timeout: time.Second}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	vars := map[string]any{
+		"owner":  "iglou",
+		"repo":   "a/b?c",
+		"state":  "open",
+		"labels": []string{"bug", "ui"},
+	}
+
+	child, err := c.NewChildTemplate("/repos/{owner}/{repo}", vars)
+	if err != nil {
+		t.Fatalf("NewChildTemplate() error = %v", err)
+	}
+	defer child.Close()
+
+	if _, err := child.DoTemplate(http.MethodGet, "/issues{?state,labels}", vars, nil, nil); err != nil {
+		t.Fatalf("DoTemplate() error = %v", err)
+	}
+	if _, err := child.NewChild("/pulls").Do(http.MethodGet, nil, nil); err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if _, err := c.R().Template("/users/{id}", map[string]any{"id": "42#1"}).
+		Query("fields", "name").Do(http.MethodGet, nil); err != nil {
+		t.Fatalf("Request.Do() error = %v", err)
+	}
+
+	want := []string{
+		"/api/repos/iglou/a%2Fb%3Fc/issues?labels=bug%2Cui&lang=en&state=open",
+		"/api/repos/iglou/a%2Fb%3Fc/pulls?lang=en",
+		"/api/users/42%231?fields=name&lang=en",
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(uris) != len(want) {
+		t.Fatalf("request URIs = %q, want %q", uris, want)
+	}
+	for i := range want {
+		if uris[i] != want[i] {
+			t.Errorf("request URI = %q, want %q", uris[i], want[i])
+		}
+	}
+
+	// The parent client is left untouched
+	if got := c.URL.EscapedPath(); got != "/api" {
+		t.Errorf("parent path = %q, want %q", got, "/api")
+	}
+}
+
+func TestClient_TemplateErrors(t *testing.T) {
+	c, err := client.New(context.Background(), "https://api.example.com", nil, nil, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	for _, template := range []string{"/users/{id", "/users/{id}{#section}"} {
+		vars := map[string]any{"id": 42, "section": "bio"}
+
+		if _, err := c.NewChildTemplate(template, vars); !errors.Is(err, utils.ErrInvalidTemplate) {
+			t.Errorf("NewChildTemplate(%q) error = %v, want %v", template, err, utils.ErrInvalidTemplate)
+		}
+		if _, err := c.DoTemplate(http.MethodGet, template, vars, nil, nil); !errors.Is(err, utils.ErrInvalidTemplate) {
+			t.Errorf("DoTemplate(%q) error = %v, want %v", template, err, utils.ErrInvalidTemplate)
+		}
+	}
+}
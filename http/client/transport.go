@@ -0,0 +1,80 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client
+
+import (
+	"net/http"
+	"reflect"
+	"sync"
+)
+
+// transportKey identifies the options a transport is built from. The
+// dialer is identified by its code pointer: the closures of a function
+// literal, such as the Unix socket dialers, are told apart by the other
+// fields only.
+type transportKey struct {
+	disableTLSVerify bool
+	protocol         Protocol
+	guard            *DialGuard
+	socketPath       string
+	dial             uintptr
+}
+
+// transportCache holds the transports of a client tree by options, so the
+// requests reuse their connections.
+type transportCache struct {
+	mu         sync.Mutex
+	transports map[transportKey]*http.Transport
+}
+
+func newTransportCache() *transportCache {
+	return &transportCache{transports: make(map[transportKey]*http.Transport)}
+}
+
+// transportKey returns the key of the client options.
+func (c *Client) transportKey() transportKey {
+	key := transportKey{
+		disableTLSVerify: c.Options.DisableTLSVerify,
+		protocol:         c.Options.Protocol,
+		guard:            c.Options.DialGuard,
+		socketPath:       c.socketPath,
+	}
+	if c.Options.DialContext != nil {
+		key.dial = reflect.ValueOf(c.Options.DialContext).Pointer()
+	}
+
+	return key
+}
+
+// get returns the transport of `key`, built with `build` the first time.
+func (tc *transportCache) get(
+	key transportKey, build func() *http.Transport,
+) *http.Transport {
+	tc.mu.Lock()
+	defer tc.mu.Unlock()
+
+	transport, ok := tc.transports[key]
+	if !ok {
+		transport = build()
+		tc.transports[key] = transport
+	}
+
+	return transport
+}
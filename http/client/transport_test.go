@@ -0,0 +1,75 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"gitlab.com/iglou.eu/goulc/http/client"
+)
+
+func TestClient_TransportReuse(t *testing.T) {
+	var conns atomic.Int32
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("vault-tec"))
+	}))
+	ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	ts.Start()
+	defer ts.Close()
+
+	for name, opt := range map[string]client.Options{
+		"protocol": {Protocol: client.ProtoHTTP1},
+		"dialer": {DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, addr)
+		}},
+	} {
+		conns.Store(0)
+		c, err := client.New(context.Background(), ts.URL, nil, &opt, nil)
+		if err != nil {
+			t.Fatalf("New() error = %v", err)
+		}
+
+		// The snapshots and the children share the connections
+		for i := range 10 {
+			target := &c
+			if i%2 == 1 {
+				target = c.NewChild("/pip-boy")
+			}
+			if _, err := target.Do(http.MethodGet, nil, nil); err != nil {
+				t.Fatalf("%s: Do() error = %v", name, err)
+			}
+		}
+		c.Close()
+
+		if n := conns.Load(); n != 1 {
+			t.Errorf("%s: %d connections opened, want 1", name, n)
+		}
+	}
+}
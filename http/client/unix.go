@@ -0,0 +1,75 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+	"strings"
+)
+
+const (
+	// UnixScheme is the URL scheme used to target a Unix domain socket
+	UnixScheme = "unix"
+
+	// UnixHost is the host used in requests sent over a Unix domain socket
+	UnixHost = "localhost"
+
+	// unixPathSeparator separates the socket path from the URL path
+	unixPathSeparator = ":"
+)
+
+// ErrInvalidSocket is returned when the Unix domain socket URL is invalid
+var ErrInvalidSocket = errors.New("invalid unix domain socket URL")
+
+// parseUnixURL rewrites a `unix://<socket>:<path>` URL into a plain HTTP
+// URL and returns the socket path. The URL path is optional.
+//
+// Example:
+//
+// unix:///var/run/docker.sock:/v1.43 => http://localhost/v1.43
+func parseUnixURL(u *url.URL) (string, error) {
+	// The socket path is into the host part for unix://host/path.sock
+	// forms, join it back to the path
+	socket, path, _ := strings.Cut(u.Host+u.Path, unixPathSeparator)
+	if socket == "" {
+		return "", errors.Join(ErrInvalidSocket,
+			errors.New("no socket path found in "+u.String()))
+	}
+
+	u.Scheme = "http"
+	u.Host = UnixHost
+	u.Path = path
+	u.RawPath = ""
+
+	return socket, nil
+}
+
+// unixDialer returns a dial function that always connects to the Unix
+// domain socket, regardless of the requested address.
+func unixDialer(socketPath string) func(
+	ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, _, _ string) (net.Conn, error) {
+		var d net.Dialer
+		return d.DialContext(ctx, UnixScheme, socketPath)
+	}
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/iglou.eu/goulc/http/client"
+)
+
+func newUnixServer(t *testing.T, handler http.Handler) string {
+	t.Helper()
+
+	// Keep the socket path short, the limit is around 100 characters
+	dir, err := os.MkdirTemp("", "goulc")
+	if err != nil {
+		t.Fatalf("MkdirTemp() error = %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	socket := filepath.Join(dir, "dock.sock")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+
+	ts := httptest.NewUnstartedServer(handler)
+	ts.Listener = l
+	ts.Start()
+	t.Cleanup(ts.Close)
+
+	return socket
+}
+
+func TestNew_UnixSocket(t *testing.T) {
+	socket := newUnixServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1.43/containers/json" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"Id":"tavern"}]`))
+	}))
+
+	tests := []struct {
+		name      string
+		serverURL string
+		wantURL   string
+		wantErrIs error
+	}{
+		{
+			name:      "socket with path",
+			serverURL: "unix://" + socket + ":/v1.43",
+			wantURL:   "http://localhost/v1.43",
+		},
+		{
+			name:      "socket without path",
+			serverURL: "unix://" + socket,
+			wantURL:   "http://localhost/",
+		},
+		{
+			name:      "no socket",
+			serverURL: "unix://:/v1.43",
+			wantErrIs: client.ErrInvalidSocket,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := client.New(context.Background(), tt.serverURL, nil, nil, nil)
+			if !errors.Is(err, tt.wantErrIs) {
+				t.Fatalf("New() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if err != nil {
+				return
+			}
+			defer c.Close()
+
+			if got := c.URL.String(); got != tt.wantURL {
+				t.Errorf("New() URL = %v, want %v", got, tt.wantURL)
+			}
+		})
+	}
+
+	// OnlyHTTPS must not upgrade the local socket
+	c, err := client.New(context.Background(), "unix://"+socket+":/v1.43", nil, &client.OptDefault, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.NewChild("/containers/json").Do(http.MethodGet, nil, nil)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || string(resp.Body) != `[{"Id":"tavern"}]` {
+		t.Errorf("Do() = %v %s, want 200 with containers", resp.StatusCode, resp.Body)
+	}
+}
+
+func TestOptions_DialContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Host))
+	}))
+	defer ts.Close()
+
+	// All connections are routed to the test server whatever the host
+	dialed := false
+	opt := client.Options{
+		Timeout: time.Second,
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			dialed = true
+			var d net.Dialer
+			return d.DialContext(ctx, network, ts.Listener.Addr().String())
+		},
+	}
+
+	c, err := client.New(context.Background(), "http://systemd.internal", nil, &opt, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	resp, err := c.Do(http.MethodGet, nil, nil)
+	if err != nil {
+		t.Fatalf("Do() error = %v", err)
+	}
+	if !dialed {
+		t.Error("Do() did not use the custom dialer")
+	}
+	if string(resp.Body) != "systemd.internal" {
+		t.Errorf("Do() host = %s, want systemd.internal", resp.Body)
+	}
+}
@@ -0,0 +1,714 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// MessageType is the type of a WebSocket message.
+// RFC 6455 §5.2: https://www.rfc-editor.org/rfc/rfc6455#section-5.2
+type MessageType uint8
+
+const (
+	// TextMessage is an UTF-8 encoded text message
+	TextMessage MessageType = 0x1
+	// BinaryMessage is a binary message
+	BinaryMessage MessageType = 0x2
+
+	// Continuation and control frames opcodes
+	opContinuation = 0x0
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+)
+
+// WebSocket close codes.
+// RFC 6455 §7.4.1: https://www.rfc-editor.org/rfc/rfc6455#section-7.4.1
+const (
+	CloseNormal             = 1000
+	CloseGoingAway          = 1001
+	CloseProtocolError      = 1002
+	CloseUnsupportedData    = 1003
+	CloseNoStatus           = 1005
+	CloseAbnormal           = 1006
+	CloseInvalidPayload     = 1007
+	ClosePolicyViolation    = 1008
+	CloseMessageTooBig      = 1009
+	CloseMandatoryExtension = 1010
+	CloseInternalError      = 1011
+)
+
+const (
+	// WebSocketGUID is concatenated to the key to compute the accept value
+	// RFC 6455 §1.3: https://www.rfc-editor.org/rfc/rfc6455#section-1.3
+	WebSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	// WebSocketVersion is the only protocol version supported
+	WebSocketVersion = "13"
+
+	// maxControlPayload is the maximum payload size of a control frame
+	maxControlPayload = 125
+
+	// WebSocketMaxReadLimit is the read limit applied when ReadLimit is zero,
+	// a frame length is never trusted without bound
+	WebSocketMaxReadLimit = 1 << 30
+
+	// frame header flags and masks
+	finBit      = 0x80
+	rsvBits     = 0x70
+	opcodeMask  = 0x0F
+	maskBit     = 0x80
+	lengthMask  = 0x7F
+	length16    = 126
+	length64    = 127
+	maskKeySize = 4
+	maxHeader   = 14
+)
+
+var (
+	// ErrWebSocketHandshake is returned when the upgrade handshake fails
+	ErrWebSocketHandshake = errors.New("websocket handshake failed")
+
+	// ErrWebSocketProtocol is returned when the server violates the protocol
+	ErrWebSocketProtocol = errors.New("websocket protocol error")
+
+	// ErrWebSocketTooBig is returned when a message exceeds the read limit
+	ErrWebSocketTooBig = errors.New("websocket message too big")
+
+	// ErrWebSocketClosed is returned when using a closed WebSocket
+	ErrWebSocketClosed = errors.New("websocket is closed")
+
+	// ErrWebSocketPongTimeout is returned when the server stops answering
+	// the keepalive pings
+	ErrWebSocketPongTimeout = errors.New("websocket pong timeout")
+)
+
+// WebSocketDefault defines the default WebSocket options
+// - 30s between keepalive pings
+// - 10s to receive a frame after a ping
+// - 32MiB maximum message size
+var WebSocketDefault = WebSocketOptions{
+	PingInterval: 30 * time.Second,
+	PongTimeout:  10 * time.Second,
+	ReadLimit:    32 << 20,
+}
+
+// WebSocketOptions configures a WebSocket connection.
+type WebSocketOptions struct {
+	// Subprotocols are the application protocols offered to the server.
+	// Default: nil
+	Subprotocols []string
+
+	// PingInterval is the delay between two keepalive pings, zero disables
+	// the keepalive.
+	// Default: 30s
+	PingInterval time.Duration
+
+	// PongTimeout is the time allowed to receive a frame after a ping
+	// before the connection is considered as lost.
+	// Default: 10s
+	PongTimeout time.Duration
+
+	// ReadLimit is the maximum size of a received message in bytes,
+	// zero means WebSocketMaxReadLimit.
+	// Default: 32MiB
+	ReadLimit int64
+}
+
+// CloseError is returned when the WebSocket is closed by the server.
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+// Error implements the error interface.
+func (e *CloseError) Error() string {
+	msg := "websocket closed with code " + strconv.Itoa(e.Code)
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+
+	return msg
+}
+
+// WebSocket is a client WebSocket connection opened by Client.WebSocket.
+// A single goroutine may read at a time, writes are safe for concurrent use.
+// The keepalive relies on an active reader to receive the pongs.
+type WebSocket struct {
+	conn io.ReadWriteCloser
+	br   *bufio.Reader
+	opt  WebSocketOptions
+
+	subprotocol string
+
+	wmu      sync.Mutex
+	closed   atomic.Bool
+	lastRead atomic.Int64
+	done     chan struct{}
+	once     sync.Once
+	err      atomic.Value
+
+	// release deregisters the socket from the client that opened it
+	release atomic.Value
+}
+
+// WebSocket opens a WebSocket connection to the client URL. The handshake
+// is sent through the client so the URL, query, headers, authentication,
+// dialer and TLS options apply. The `ws` and `wss` schemes are accepted as
+// aliases of `http` and `https`, `ws` is upgraded to `wss` with the
+// OnlyHTTPS option. If `opt` is nil, `WebSocketDefault` is used.
+//
+// The connection is closed when the client, or one of its parents,
+// is closed.
+//
+// RFC 6455 §4.1: https://www.rfc-editor.org/rfc/rfc6455#section-4.1
+func (main *Client) WebSocket(opt *WebSocketOptions) (*WebSocket, error) {
+	if main.IsClosed() {
+		return nil, ErrClientClosed
+	}
+
+	if opt == nil {
+		opt = &WebSocketDefault
+	}
+
+	c := main.Clone()
+	if c == nil {
+		return nil, ErrClientClosed
+	}
+	defer c.Close()
+
+	target := c.URL
+	switch target.Scheme {
+	case "ws":
+		target.Scheme = "http"
+	case "wss":
+		target.Scheme = "https"
+	}
+
+	// Unix domain sockets are local, there is no need to upgrade them
+	if c.Options.OnlyHTTPS && target.Scheme == "http" && c.socketPath == "" {
+		target.Scheme = "https"
+	}
+
+	if len(c.Query) > 0 {
+		target.RawQuery = c.Query.Encode()
+	}
+
+	req, err := c.newRequest(http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// The connection must outlive the handshake, it is bound to the
+	// main client context instead of the clone one
+	req = req.WithContext(main.context)
+
+	key, err := webSocketKey()
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", WebSocketVersion)
+	if len(opt.Subprotocols) > 0 {
+		req.Header.Set("Sec-WebSocket-Protocol",
+			strings.Join(opt.Subprotocols, ", "))
+	}
+
+	// The upgrade is only defined for HTTP/1.1 and redirects are not
+	// followed. The Timeout only applies to the handshake. The connection
+	// is taken over, so the transport is not shared with the requests.
+	transport := c.newTransport()
+	transport.Protocols = ProtoHTTP1.protocols()
+	transport.ResponseHeaderTimeout = c.Options.Timeout
+
+	client := &http.Client{
+		Transport: transport,
+		CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	c.logger.Debug("opening websocket",
+		"url", target.String(),
+		"subprotocols", opt.Subprotocols)
+
+	if c.Options.RateLimiter != nil {
+		if err := c.Options.RateLimiter.Wait(c.context); err != nil {
+			return nil, err
+		}
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Join(ErrWebSocketHandshake, err)
+	}
+
+	if err := checkHandshake(res, key, opt.Subprotocols); err != nil {
+		res.Body.Close()
+		return nil, err
+	}
+
+	conn, ok := res.Body.(io.ReadWriteCloser)
+	if !ok {
+		res.Body.Close()
+		return nil, errors.Join(ErrWebSocketHandshake,
+			errors.New("upgraded connection is not writable"))
+	}
+
+	ws := &WebSocket{
+		conn:        conn,
+		br:          bufio.NewReader(conn),
+		opt:         *opt,
+		subprotocol: res.Header.Get("Sec-WebSocket-Protocol"),
+		done:        make(chan struct{}),
+	}
+	ws.lastRead.Store(time.Now().UnixNano())
+
+	// Close the socket with the client, the socket deregisters once closed
+	release, ok := main.register(func() error {
+		return ws.CloseWithCode(CloseGoingAway, "client closed")
+	})
+	if !ok {
+		_ = ws.CloseWithCode(CloseGoingAway, "client closed")
+		return nil, ErrClientClosed
+	}
+	ws.release.Store(release)
+	if ws.closed.Load() {
+		release()
+	}
+
+	if ws.opt.PingInterval > 0 {
+		go ws.keepalive()
+	}
+
+	c.logger.Debug("websocket opened",
+		"url", target.String(),
+		"subprotocol", ws.subprotocol)
+	return ws, nil
+}
+
+// Subprotocol returns the application protocol selected by the server.
+func (ws *WebSocket) Subprotocol() string {
+	return ws.subprotocol
+}
+
+// ReadMessage reads the next data message. Control frames are handled
+// transparently: pings are answered and a close frame is acknowledged and
+// returned as a *CloseError.
+func (ws *WebSocket) ReadMessage() (MessageType, []byte, error) {
+	var msgType MessageType
+	var msg []byte
+
+	for {
+		if err := ws.failure(); err != nil {
+			return 0, nil, err
+		}
+
+		fin, opcode, payload, err := ws.readFrame()
+		switch {
+		case errors.Is(err, ErrWebSocketTooBig):
+			_ = ws.CloseWithCode(CloseMessageTooBig, "")
+			return 0, nil, err
+		case errors.Is(err, ErrWebSocketProtocol):
+			_ = ws.CloseWithCode(CloseProtocolError, "")
+			return 0, nil, err
+		case err != nil:
+			return 0, nil, ws.fail(CloseAbnormal, err)
+		}
+
+		switch opcode {
+		case opPing:
+			if err := ws.writeFrame(opPong, payload); err != nil {
+				return 0, nil, ws.fail(CloseAbnormal, err)
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			return 0, nil, ws.closeReceived(payload)
+		case opContinuation:
+			if msgType == 0 {
+				return 0, nil, ws.protocolError("unexpected continuation frame")
+			}
+		case byte(TextMessage), byte(BinaryMessage):
+			if msgType != 0 {
+				return 0, nil, ws.protocolError("expected continuation frame")
+			}
+			msgType = MessageType(opcode)
+		default:
+			return 0, nil, ws.protocolError(
+				"unknown opcode " + strconv.Itoa(int(opcode)))
+		}
+
+		if int64(len(msg)+len(payload)) > ws.readLimit() {
+			_ = ws.CloseWithCode(CloseMessageTooBig, "")
+			return 0, nil, ErrWebSocketTooBig
+		}
+		msg = append(msg, payload...)
+
+		if !fin {
+			continue
+		}
+
+		if msgType == TextMessage && !utf8.Valid(msg) {
+			_ = ws.CloseWithCode(CloseInvalidPayload, "")
+			return 0, nil, errors.Join(ErrWebSocketProtocol,
+				errors.New("invalid UTF-8 text message"))
+		}
+
+		return msgType, msg, nil
+	}
+}
+
+// WriteMessage sends a data message in a single frame.
+func (ws *WebSocket) WriteMessage(msgType MessageType, data []byte) error {
+	if msgType != TextMessage && msgType != BinaryMessage {
+		return errors.Join(ErrWebSocketProtocol,
+			errors.New("invalid message type "+strconv.Itoa(int(msgType))))
+	}
+
+	if err := ws.failure(); err != nil {
+		return err
+	}
+
+	return ws.writeFrame(byte(msgType), data)
+}
+
+// Ping sends a ping frame with the given payload, at most 125 bytes.
+func (ws *WebSocket) Ping(payload []byte) error {
+	if len(payload) > maxControlPayload {
+		return errors.Join(ErrWebSocketProtocol,
+			errors.New("control frame payload too large"))
+	}
+
+	return ws.writeFrame(opPing, payload)
+}
+
+// Close closes the connection with the normal closure code.
+func (ws *WebSocket) Close() error {
+	return ws.CloseWithCode(CloseNormal, "")
+}
+
+// CloseWithCode sends a close frame with the code and reason, then closes
+// the connection. Closing an already closed WebSocket is a no-op.
+// RFC 6455 §5.5.1: https://www.rfc-editor.org/rfc/rfc6455#section-5.5.1
+func (ws *WebSocket) CloseWithCode(code int, reason string) error {
+	if ws.closed.Load() {
+		return nil
+	}
+
+	var payload []byte
+	if code != CloseNoStatus && code != CloseAbnormal {
+		payload = binary.BigEndian.AppendUint16(nil, uint16(code))
+		payload = append(payload, reason...)
+		if len(payload) > maxControlPayload {
+			payload = payload[:maxControlPayload]
+		}
+	}
+
+	err := ws.writeFrame(opClose, payload)
+	ws.shutdown(&CloseError{Code: code, Reason: reason})
+
+	return err
+}
+
+// closeReceived acknowledges a close frame sent by the server.
+func (ws *WebSocket) closeReceived(payload []byte) error {
+	closeErr := &CloseError{Code: CloseNoStatus}
+
+	switch {
+	case len(payload) == 1:
+		return ws.protocolError("invalid close frame")
+	case len(payload) >= 2:
+		closeErr.Code = int(binary.BigEndian.Uint16(payload))
+		closeErr.Reason = string(payload[2:])
+	}
+
+	// Echo the close code before closing the connection
+	// RFC 6455 §5.5.1: https://www.rfc-editor.org/rfc/rfc6455#section-5.5.1
+	_ = ws.writeFrame(opClose, payload[:min(len(payload), 2)])
+	ws.shutdown(closeErr)
+
+	return closeErr
+}
+
+// protocolError closes the connection after a protocol violation.
+func (ws *WebSocket) protocolError(msg string) error {
+	err := errors.Join(ErrWebSocketProtocol, errors.New(msg))
+	_ = ws.CloseWithCode(CloseProtocolError, "")
+
+	return err
+}
+
+// fail closes the connection without handshake after an I/O error.
+func (ws *WebSocket) fail(code int, err error) error {
+	if failure := ws.failure(); failure != nil {
+		return failure
+	}
+
+	ws.shutdown(&CloseError{Code: code, Reason: err.Error()})
+	return errors.Join(&CloseError{Code: code}, err)
+}
+
+// failure returns the error that closed the connection, if any.
+func (ws *WebSocket) failure() error {
+	if !ws.closed.Load() {
+		return nil
+	}
+
+	if err, ok := ws.err.Load().(error); ok {
+		return errors.Join(ErrWebSocketClosed, err)
+	}
+
+	return ErrWebSocketClosed
+}
+
+// shutdown closes the underlying connection and stops the keepalive.
+func (ws *WebSocket) shutdown(err error) {
+	ws.once.Do(func() {
+		ws.err.Store(err)
+		ws.closed.Store(true)
+		close(ws.done)
+		ws.conn.Close()
+
+		if release, ok := ws.release.Load().(func()); ok {
+			release()
+		}
+	})
+}
+
+// keepalive sends pings and closes the connection if nothing is received
+// within the pong timeout.
+func (ws *WebSocket) keepalive() {
+	ticker := time.NewTicker(ws.opt.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ws.done:
+			return
+		case <-ticker.C:
+		}
+
+		last := time.Unix(0, ws.lastRead.Load())
+		if ws.opt.PongTimeout > 0 &&
+			time.Since(last) > ws.opt.PingInterval+ws.opt.PongTimeout {
+			ws.shutdown(ErrWebSocketPongTimeout)
+			return
+		}
+
+		if err := ws.writeFrame(opPing, nil); err != nil {
+			ws.shutdown(err)
+			return
+		}
+	}
+}
+
+// readFrame reads a single frame from the server.
+// RFC 6455 §5.2: https://www.rfc-editor.org/rfc/rfc6455#section-5.2
+func (ws *WebSocket) readFrame() (bool, byte, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(ws.br, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	ws.lastRead.Store(time.Now().UnixNano())
+
+	fin := header[0]&finBit != 0
+	opcode := header[0] & opcodeMask
+
+	// No extension is negotiated, reserved bits must be zero
+	if header[0]&rsvBits != 0 {
+		return false, 0, nil, errors.Join(ErrWebSocketProtocol,
+			errors.New("reserved bits set"))
+	}
+
+	// Frames sent by the server must not be masked
+	if header[1]&maskBit != 0 {
+		return false, 0, nil, errors.Join(ErrWebSocketProtocol,
+			errors.New("masked server frame"))
+	}
+
+	length := uint64(header[1] & lengthMask)
+	switch length {
+	case length16:
+		var ext [2]byte
+		if _, err := io.ReadFull(ws.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case length64:
+		var ext [8]byte
+		if _, err := io.ReadFull(ws.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+
+		// The most significant bit must be zero
+		if length>>63 != 0 {
+			return false, 0, nil, errors.Join(ErrWebSocketProtocol,
+				errors.New("invalid frame length"))
+		}
+	}
+
+	isControl := opcode&0x8 != 0
+	if isControl && (!fin || length > maxControlPayload) {
+		return false, 0, nil, errors.Join(ErrWebSocketProtocol,
+			errors.New("invalid control frame"))
+	}
+
+	if length > uint64(ws.readLimit()) {
+		return false, 0, nil, ErrWebSocketTooBig
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(ws.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+
+	return fin, opcode, payload, nil
+}
+
+// readLimit returns the maximum size of a received message.
+func (ws *WebSocket) readLimit() int64 {
+	if ws.opt.ReadLimit > 0 {
+		return ws.opt.ReadLimit
+	}
+
+	return WebSocketMaxReadLimit
+}
+
+// writeFrame sends a single masked frame to the server.
+// RFC 6455 §5.3: https://www.rfc-editor.org/rfc/rfc6455#section-5.3
+func (ws *WebSocket) writeFrame(opcode byte, payload []byte) error {
+	ws.wmu.Lock()
+	defer ws.wmu.Unlock()
+
+	if ws.closed.Load() {
+		return ErrWebSocketClosed
+	}
+
+	frame := make([]byte, 0, maxHeader+len(payload))
+	frame = append(frame, finBit|opcode)
+
+	length := len(payload)
+	switch {
+	case length <= maxControlPayload:
+		frame = append(frame, maskBit|byte(length))
+	case length <= 0xFFFF:
+		frame = append(frame, maskBit|length16)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(length))
+	default:
+		frame = append(frame, maskBit|length64)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(length))
+	}
+
+	var mask [maskKeySize]byte
+	if _, err := rand.Read(mask[:]); err != nil {
+		return err
+	}
+	frame = append(frame, mask[:]...)
+
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%maskKeySize])
+	}
+
+	_, err := ws.conn.Write(frame)
+	return err
+}
+
+// webSocketKey generates a random handshake key.
+func webSocketKey() (string, error) {
+	var key [16]byte
+	if _, err := rand.Read(key[:]); err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(key[:]), nil
+}
+
+// webSocketAccept computes the expected Sec-WebSocket-Accept value.
+func webSocketAccept(key string) string {
+	hash := sha1.Sum([]byte(key + WebSocketGUID))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// checkHandshake validates the server handshake response.
+// RFC 6455 §4.2.2: https://www.rfc-editor.org/rfc/rfc6455#section-4.2.2
+func checkHandshake(res *http.Response, key string, subprotocols []string) error {
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		return errors.Join(ErrWebSocketHandshake,
+			errors.New("unexpected status "+res.Status))
+	}
+
+	if !strings.EqualFold(res.Header.Get("Upgrade"), "websocket") ||
+		!headerContainsToken(res.Header, "Connection", "upgrade") {
+		return errors.Join(ErrWebSocketHandshake,
+			errors.New("missing upgrade headers"))
+	}
+
+	if res.Header.Get("Sec-WebSocket-Accept") != webSocketAccept(key) {
+		return errors.Join(ErrWebSocketHandshake,
+			errors.New("invalid Sec-WebSocket-Accept"))
+	}
+
+	if protocol := res.Header.Get("Sec-WebSocket-Protocol"); protocol != "" {
+		for _, p := range subprotocols {
+			if p == protocol {
+				return nil
+			}
+		}
+
+		return errors.Join(ErrWebSocketHandshake,
+			errors.New("unexpected subprotocol "+protocol))
+	}
+
+	return nil
+}
+
+// headerContainsToken reports if the comma separated header contains the
+// token, case-insensitively.
+func headerContainsToken(h http.Header, name, token string) bool {
+	for _, value := range h.Values(name) {
+		for _, v := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(v), token) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,409 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package client_test
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gitlab.com/iglou.eu/goulc/http/client"
+)
+
+// wsServerConn is a minimal server side WebSocket used for testing
+type wsServerConn struct {
+	net.Conn
+	br *bufio.Reader
+}
+
+func (s *wsServerConn) readFrame() (byte, []byte, error) {
+	var h [2]byte
+	if _, err := io.ReadFull(s.br, h[:]); err != nil {
+		return 0, nil, err
+	}
+	if h[1]&0x80 == 0 {
+		return 0, nil, errors.New("client frame not masked")
+	}
+
+	length := uint64(h[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		_, _ = io.ReadFull(s.br, ext[:])
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		_, _ = io.ReadFull(s.br, ext[:])
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	var mask [4]byte
+	_, _ = io.ReadFull(s.br, mask[:])
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(s.br, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return h[0] & 0x0F, payload, nil
+}
+
+func (s *wsServerConn) writeFrame(fin bool, opcode byte, payload []byte) {
+	b0 := opcode
+	if fin {
+		b0 |= 0x80
+	}
+	frame := []byte{b0}
+	switch {
+	case len(payload) < 126:
+		frame = append(frame, byte(len(payload)))
+	case len(payload) <= 0xFFFF:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(len(payload)))
+	}
+	_, _ = s.Write(append(frame, payload...))
+}
+
+func newWebSocketServer(t *testing.T, handler func(r *http.Request, s *wsServerConn)) *httptest.Server {
+	t.Helper()
+
+	ts := httptest.NewServer(webSocketHandler(t, handler))
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func webSocketHandler(t *testing.T, handler func(r *http.Request, s *wsServerConn)) http.Handler {
+	t.Helper()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" || r.Header.Get("Authorization") == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		hash := sha1.Sum([]byte(r.Header.Get("Sec-WebSocket-Key") + client.WebSocketGUID))
+
+		conn, brw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("Hijack() error = %v", err)
+			return
+		}
+		defer conn.Close()
+
+		protocol := ""
+		if r.Header.Get("Sec-WebSocket-Protocol") != "" {
+			protocol = "Sec-WebSocket-Protocol: chat\r\n"
+		}
+
+		_, _ = brw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+			"Upgrade: websocket\r\nConnection: Upgrade\r\n" + protocol +
+			"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(hash[:]) + "\r\n\r\n")
+		_ = brw.Flush()
+
+		handler(r, &wsServerConn{Conn: conn, br: brw.Reader})
+	})
+}
+
+func TestClient_WebSocket(t *testing.T) {
+	ts := newWebSocketServer(t, func(r *http.Request, s *wsServerConn) {
+		// Echo the query parameter then a fragmented message with a ping
+		s.writeFrame(true, 0x1, []byte(r.URL.Query().Get("party")))
+		s.writeFrame(false, 0x2, []byte("sword "))
+		s.writeFrame(true, 0x9, []byte("ping?"))
+		s.writeFrame(true, 0x0, []byte("of chaos"))
+
+		// Expect the pong then an echo request
+		if op, payload, err := s.readFrame(); err != nil || op != 0xA || string(payload) != "ping?" {
+			t.Errorf("server expected pong, got %x %q %v", op, payload, err)
+		}
+
+		op, payload, err := s.readFrame()
+		if err != nil {
+			return
+		}
+		s.writeFrame(true, op, payload)
+
+		// Close with a reason and wait for the acknowledgment
+		s.writeFrame(true, 0x8, append([]byte{0x03, 0xE9}, "going to Candlekeep"...))
+		if op, _, _ := s.readFrame(); op != 0x8 {
+			t.Errorf("server expected close acknowledgment, got %x", op)
+		}
+	})
+
+	opt := client.OptDefault
+	opt.OnlyHTTPS = false
+	c, err := client.New(context.Background(), "ws"+ts.URL[len("http"):], &mockAuthenticator{
+		name: "mock", header: "Authorization", value: "Bearer harper",
+	}, &opt, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+	c.Query.Set("party", "minsc")
+
+	ws, err := c.WebSocket(&client.WebSocketOptions{Subprotocols: []string{"chat"}})
+	if err != nil {
+		t.Fatalf("WebSocket() error = %v", err)
+	}
+	defer ws.Close()
+
+	if ws.Subprotocol() != "chat" {
+		t.Errorf("Subprotocol() = %q, want chat", ws.Subprotocol())
+	}
+
+	msgType, msg, err := ws.ReadMessage()
+	if err != nil || msgType != client.TextMessage || string(msg) != "minsc" {
+		t.Fatalf("ReadMessage() = %v %q %v, want text minsc", msgType, msg, err)
+	}
+
+	msgType, msg, err = ws.ReadMessage()
+	if err != nil || msgType != client.BinaryMessage || string(msg) != "sword of chaos" {
+		t.Fatalf("ReadMessage() = %v %q %v, want binary fragmented message", msgType, msg, err)
+	}
+
+	long := make([]byte, 70000)
+	if err := ws.WriteMessage(client.BinaryMessage, long); err != nil {
+		t.Fatalf("WriteMessage() error = %v", err)
+	}
+	if _, msg, err = ws.ReadMessage(); err != nil || len(msg) != len(long) {
+		t.Fatalf("ReadMessage() = %d bytes %v, want echo of %d bytes", len(msg), err, len(long))
+	}
+
+	_, _, err = ws.ReadMessage()
+	var closeErr *client.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != client.CloseGoingAway || closeErr.Reason != "going to Candlekeep" {
+		t.Errorf("ReadMessage() error = %v, want close 1001", err)
+	}
+
+	if err := ws.WriteMessage(client.TextMessage, []byte("hello?")); !errors.Is(err, client.ErrWebSocketClosed) {
+		t.Errorf("WriteMessage() error = %v, want %v", err, client.ErrWebSocketClosed)
+	}
+}
+
+func TestClient_WebSocketLifecycle(t *testing.T) {
+	received := make(chan []byte, 1)
+	ts := newWebSocketServer(t, func(_ *http.Request, s *wsServerConn) {
+		for {
+			op, payload, err := s.readFrame()
+			if err != nil {
+				return
+			}
+			if op == 0x8 {
+				received <- payload
+				return
+			}
+		}
+	})
+
+	c, err := client.New(context.Background(), ts.URL, &mockAuthenticator{
+		name: "mock", header: "Authorization", value: "Bearer harper",
+	}, &client.Options{Timeout: time.Second}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// Normal closure
+	ws, err := c.WebSocket(nil)
+	if err != nil {
+		t.Fatalf("WebSocket() error = %v", err)
+	}
+	if err := ws.Close(); err != nil {
+		t.Errorf("Close() error = %v", err)
+	}
+	if code := binary.BigEndian.Uint16(<-received); code != client.CloseNormal {
+		t.Errorf("close code = %d, want %d", code, client.CloseNormal)
+	}
+	if n := client.Children(&c); n != 0 {
+		t.Errorf("registered children = %d after Close(), want 0", n)
+	}
+
+	// Handshake failure
+	noAuth, _ := client.New(context.Background(), ts.URL, nil, &client.Options{Timeout: time.Second}, nil)
+	defer noAuth.Close()
+	if _, err := noAuth.WebSocket(nil); !errors.Is(err, client.ErrWebSocketHandshake) {
+		t.Errorf("WebSocket() error = %v, want %v", err, client.ErrWebSocketHandshake)
+	}
+
+	// Closing the client closes its sockets with a going away code
+	ws, err = c.NewChild("/tavern").WebSocket(nil)
+	if err != nil {
+		t.Fatalf("WebSocket() error = %v", err)
+	}
+	c.Close()
+
+	select {
+	case payload := <-received:
+		if code := binary.BigEndian.Uint16(payload); code != client.CloseGoingAway {
+			t.Errorf("close code = %d, want %d", code, client.CloseGoingAway)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close() did not close the websocket")
+	}
+
+	if _, _, err := ws.ReadMessage(); !errors.Is(err, client.ErrWebSocketClosed) {
+		t.Errorf("ReadMessage() error = %v, want %v", err, client.ErrWebSocketClosed)
+	}
+}
+
+func TestClient_WebSocketKeepalive(t *testing.T) {
+	pings := make(chan struct{}, 8)
+	ts := newWebSocketServer(t, func(_ *http.Request, s *wsServerConn) {
+		// Never answer the pings
+		for {
+			op, _, err := s.readFrame()
+			if err != nil {
+				return
+			}
+			if op == 0x9 {
+				pings <- struct{}{}
+			}
+		}
+	})
+
+	c, err := client.New(context.Background(), ts.URL, &mockAuthenticator{
+		name: "mock", header: "Authorization", value: "Bearer harper",
+	}, &client.Options{Timeout: time.Second}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	ws, err := c.WebSocket(&client.WebSocketOptions{
+		PingInterval: 10 * time.Millisecond,
+		PongTimeout:  10 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("WebSocket() error = %v", err)
+	}
+
+	if _, _, err := ws.ReadMessage(); !errors.Is(err, client.ErrWebSocketPongTimeout) {
+		t.Errorf("ReadMessage() error = %v, want %v", err, client.ErrWebSocketPongTimeout)
+	}
+	if len(pings) == 0 {
+		t.Error("keepalive did not send any ping")
+	}
+}
+
+func TestClient_WebSocketOnlyHTTPS(t *testing.T) {
+	ts := httptest.NewTLSServer(webSocketHandler(t, func(_ *http.Request, s *wsServerConn) {
+		s.writeFrame(true, 0x1, []byte("secure"))
+	}))
+	defer ts.Close()
+
+	// The ws scheme is upgraded to wss, the plain target would fail
+	opt := client.OptDefault
+	opt.DisableTLSVerify = true
+	c, err := client.New(context.Background(), "ws"+ts.URL[len("https"):], &mockAuthenticator{
+		name: "mock", header: "Authorization", value: "Bearer harper",
+	}, &opt, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer c.Close()
+
+	ws, err := c.WebSocket(nil)
+	if err != nil {
+		t.Fatalf("WebSocket() error = %v", err)
+	}
+	defer ws.Close()
+
+	if _, msg, err := ws.ReadMessage(); err != nil || string(msg) != "secure" {
+		t.Errorf("ReadMessage() = %q %v, want secure", msg, err)
+	}
+}
+
+func TestClient_WebSocketFrameLength(t *testing.T) {
+	tests := []struct {
+		name      string
+		length    uint64
+		wantErrIs error
+		wantCode  uint16
+	}{
+		{
+			name:      "most significant bit set",
+			length:    1 << 63,
+			wantErrIs: client.ErrWebSocketProtocol,
+			wantCode:  client.CloseProtocolError,
+		},
+		{
+			name:      "over the maximum read limit",
+			length:    client.WebSocketMaxReadLimit + 1,
+			wantErrIs: client.ErrWebSocketTooBig,
+			wantCode:  client.CloseMessageTooBig,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			received := make(chan []byte, 1)
+			ts := newWebSocketServer(t, func(_ *http.Request, s *wsServerConn) {
+				// Announce the length without sending the payload
+				frame := binary.BigEndian.AppendUint64([]byte{0x82, 127}, tt.length)
+				_, _ = s.Write(frame)
+
+				if op, payload, err := s.readFrame(); err == nil && op == 0x8 {
+					received <- payload
+				}
+			})
+
+			c, err := client.New(context.Background(), ts.URL, &mockAuthenticator{
+				name: "mock", header: "Authorization", value: "Bearer harper",
+			}, &client.Options{Timeout: time.Second}, nil)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			defer c.Close()
+
+			// Zero still bounds the frame length
+			ws, err := c.WebSocket(&client.WebSocketOptions{ReadLimit: 0})
+			if err != nil {
+				t.Fatalf("WebSocket() error = %v", err)
+			}
+
+			if _, _, err := ws.ReadMessage(); !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("ReadMessage() error = %v, want %v", err, tt.wantErrIs)
+			}
+
+			select {
+			case payload := <-received:
+				if code := binary.BigEndian.Uint16(payload); code != tt.wantCode {
+					t.Errorf("close code = %d, want %d", code, tt.wantCode)
+				}
+			case <-time.After(2 * time.Second):
+				t.Fatal("ReadMessage() did not close the websocket")
+			}
+		})
+	}
+}
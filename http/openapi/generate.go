@@ -0,0 +1,410 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"go/format"
+	"go/token"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultTypeName is the name of the generated client type
+	DefaultTypeName = "Client"
+
+	modulePath = "gitlab.com/iglou.eu/goulc"
+)
+
+var (
+	// ErrInvalidConfig is returned when the generator configuration is invalid
+	ErrInvalidConfig = errors.New("invalid generator configuration")
+	// ErrUnsupportedSchema is returned when a schema has no Go equivalent
+	ErrUnsupportedSchema = errors.New("unsupported OpenAPI schema")
+)
+
+// Config holds the options of the generated code.
+type Config struct {
+	// Package is the name of the generated package
+	Package string
+
+	// TypeName is the name of the generated client type,
+	// DefaultTypeName if empty
+	TypeName string
+
+	// Source is the document name written in the generated file header,
+	// if any
+	Source string
+}
+
+// methods are the HTTP methods of the operations, in generation order.
+var methods = []string{
+	"GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH", "TRACE",
+}
+
+// generator holds the state of a generation.
+type generator struct {
+	doc *Document
+	cfg Config
+
+	// names are the identifiers of the package scope
+	names names
+	// schemas maps the component schemas to their Go type
+	schemas map[string]string
+	// components maps the Go types to their component schema
+	components map[string]*Schema
+	// enums are the Go string types with constants
+	enums map[string]bool
+	// security maps the supported security schemes to their Security field
+	security map[string]string
+
+	imports map[string]bool
+	decls   []string
+}
+
+// Generate returns the Go source of the client of the document, formatted
+// with gofmt.
+//
+// Example:
+//
+//	doc, err := openapi.Parse(data)
+//	if err != nil {
+//	    return err
+//	}
+//	src, err := openapi.Generate(doc, openapi.Config{Package: "petstore"})
+func Generate(doc *Document, cfg Config) ([]byte, error) {
+	if !token.IsIdentifier(cfg.Package) {
+		return nil, errors.Join(ErrInvalidConfig,
+			errors.New("invalid package name "+strconv.Quote(cfg.Package)))
+	}
+	if cfg.TypeName == "" {
+		cfg.TypeName = DefaultTypeName
+	}
+	if !token.IsExported(cfg.TypeName) || !token.IsIdentifier(cfg.TypeName) {
+		return nil, errors.Join(ErrInvalidConfig,
+			errors.New("invalid type name "+strconv.Quote(cfg.TypeName)))
+	}
+
+	g := &generator{
+		doc: doc,
+		cfg: cfg,
+		names: names{
+			cfg.TypeName: true, "Security": true, "New": true,
+			"DefaultServerURL": true, "ErrUnexpectedStatus": true,
+		},
+		schemas:    make(map[string]string),
+		components: make(map[string]*Schema),
+		enums:      make(map[string]bool),
+		security:   make(map[string]string),
+		imports: map[string]bool{
+			"errors":                    true,
+			modulePath + "/http/client": true,
+		},
+	}
+
+	// The component names are reserved first, they can reference each other
+	for _, name := range slices.Sorted(maps.Keys(doc.Components.Schemas)) {
+		t := g.names.unique(goName(name))
+		g.schemas[name] = t
+		g.components[t] = doc.Components.Schemas[name]
+	}
+	for _, name := range slices.Sorted(maps.Keys(doc.Components.Schemas)) {
+		s := doc.Components.Schemas[name]
+		if s == nil {
+			s = &Schema{}
+		}
+
+		t := g.schemas[name]
+		if err := g.defineSchema(s, t, t+" is the "+name+" schema."); err != nil {
+			return nil, err
+		}
+	}
+	schemaDecls := g.decls
+	g.decls = nil
+
+	if err := g.generateSecurity(); err != nil {
+		return nil, err
+	}
+	if err := g.generateOperations(); err != nil {
+		return nil, err
+	}
+
+	var b strings.Builder
+	b.WriteString("// Code generated by goulc-openapi. DO NOT EDIT.\n")
+	if cfg.Source != "" {
+		b.WriteString("// Source: " + cfg.Source + "\n")
+	}
+	b.WriteString("\n")
+
+	pkgDoc := "Package " + cfg.Package + " is the client of the " +
+		strconv.Quote(doc.Info.Title) + " API"
+	if doc.Info.Version != "" {
+		pkgDoc += ", version " + doc.Info.Version
+	}
+	b.WriteString(comment(pkgDoc + ".\n\n" + doc.Info.Description))
+	b.WriteString("package " + cfg.Package + "\n\n")
+	b.WriteString(g.importDecl())
+	b.WriteString(g.clientDecl())
+
+	for _, decl := range g.decls {
+		b.WriteString("\n" + decl)
+	}
+	for _, decl := range schemaDecls {
+		b.WriteString("\n" + decl)
+	}
+
+	src, err := format.Source([]byte(b.String()))
+	if err != nil {
+		return nil, errors.Join(ErrUnsupportedSchema,
+			errors.New("generated code does not compile"), err)
+	}
+
+	return src, nil
+}
+
+// importDecl returns the import declaration, the standard library first.
+func (g *generator) importDecl() string {
+	var std, module []string
+	for _, path := range slices.Sorted(maps.Keys(g.imports)) {
+		if strings.HasPrefix(path, modulePath) {
+			module = append(module, strconv.Quote(path))
+		} else {
+			std = append(std, strconv.Quote(path))
+		}
+	}
+
+	return "import (\n\t" + strings.Join(std, "\n\t") + "\n\n\t" +
+		strings.Join(module, "\n\t") + "\n)\n"
+}
+
+// clientDecl returns the declarations of the client type, its constructor
+// and the error of the unexpected status codes.
+func (g *generator) clientDecl() string {
+	t := g.cfg.TypeName
+
+	var serverURL string
+	if len(g.doc.Servers) > 0 {
+		serverURL = g.doc.Servers[0].URL
+	}
+
+	return fmt.Sprintf(`
+// DefaultServerURL is the first server URL of the document.
+const DefaultServerURL = %[2]s
+
+// ErrUnexpectedStatus is wrapped by the errors of the operations returned
+// when the server answers with a status code other than the documented
+// successful ones.
+var ErrUnexpectedStatus = errors.New("unexpected status code")
+
+// %[1]s is the %[3]s API client, it sends the requests through a child
+// of the http/client Client given to New.
+type %[1]s struct {
+	client   *client.Client
+	security Security
+}
+
+// New returns a %[1]s sending the requests through a child of c, whose URL
+// is the API server root, such as DefaultServerURL. The operations are
+// authenticated with the security authenticators, or with the c one when
+// none of their schemes is set.
+func New(c *client.Client, security Security) (*%[1]s, error) {
+	child := c.NewChild("")
+	if child == nil {
+		return nil, client.ErrClientClosed
+	}
+
+	return &%[1]s{client: child, security: security}, nil
+}
+
+// Close closes the child client, c is left open.
+func (api *%[1]s) Close() error {
+	return api.client.Close()
+}
+`, t, strconv.Quote(serverURL), strconv.Quote(g.doc.Info.Title))
+}
+
+// generateSecurity generates the Security struct, with a field per
+// supported scheme, and the constructors of their authenticators.
+func (g *generator) generateSecurity() error {
+	var fields, constructors, unsupported strings.Builder
+	structFields := make(names)
+
+	for _, name := range slices.Sorted(maps.Keys(g.doc.Components.SecuritySchemes)) {
+		scheme := g.doc.Components.SecuritySchemes[name]
+		if scheme == nil {
+			continue
+		}
+
+		var kind string
+		switch {
+		case scheme.Type == "apiKey" && scheme.In == "header":
+			kind = "an API key in the " + scheme.Name + " header"
+		case scheme.Type == "http":
+			kind = "the HTTP " + strings.ToLower(scheme.Scheme) + " scheme"
+		case scheme.Type == "oauth2":
+			kind = "OAuth2"
+		case scheme.Type == "openIdConnect":
+			kind = "OpenID Connect"
+		default:
+			unsupported.WriteString("\n\nThe " + strconv.Quote(name) + " " +
+				scheme.Type + " scheme is not supported.")
+			continue
+		}
+
+		field := structFields.unique(goName(name))
+		g.security[name] = field
+
+		doc := field + " authenticates with the " + strconv.Quote(name) +
+			" scheme, " + kind + "."
+		if scheme.Description != "" {
+			doc += "\n" + scheme.Description
+		}
+		fields.WriteString(indent(comment(doc)))
+		fields.WriteString("\t" + field + " auth.Authenticator\n")
+
+		constructor, err := g.authConstructor(name, field, scheme)
+		if err != nil {
+			return err
+		}
+		constructors.WriteString(constructor)
+	}
+
+	if len(g.security) > 0 {
+		g.imports[modulePath+"/http/client/auth"] = true
+	}
+
+	g.decls = append(g.decls, comment("Security holds the authenticators of "+
+		"the security schemes. The operations use the first authenticator "+
+		"set among their requirements, or the client one when none is set."+
+		unsupported.String())+
+		"type Security struct {\n"+fields.String()+"}\n"+constructors.String())
+
+	return nil
+}
+
+// authConstructor returns the constructor of the authenticator of the
+// scheme, if the auth package implements it.
+func (g *generator) authConstructor(
+	name, field string, scheme *SecurityScheme,
+) (string, error) {
+	var params, build, scopes string
+
+	switch {
+	case scheme.Type == "apiKey":
+		g.imports[modulePath+"/hided"] = true
+		params = "key hided.String,"
+		build = "auth.NewAPIKey(" + strconv.Quote(scheme.Name) + ", key)"
+
+	case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "basic"):
+		g.imports[modulePath+"/hided"] = true
+		params = "username string, password hided.String,"
+		build = "auth.NewBasic(username, password)"
+
+	case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "bearer"):
+		g.imports[modulePath+"/hided"] = true
+		params = "token hided.String,"
+		build = "auth.NewBearer(token)"
+
+	case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "digest"):
+		params = "username, password string, parameters auth.DigestParameters,"
+		build = "auth.NewDigest(username, password, parameters)"
+
+	case scheme.Type == "oauth2" && scheme.Flows != nil &&
+		scheme.Flows.ClientCredentials != nil:
+		flow := scheme.Flows.ClientCredentials
+		endpoint, path, err := g.tokenEndpoint(flow.TokenURL)
+		if err != nil {
+			return "", err
+		}
+
+		for _, scope := range slices.Sorted(maps.Keys(flow.Scopes)) {
+			scopes += "  - " + scope + ": " + flow.Scopes[scope] + "\n"
+		}
+		if scopes != "" {
+			scopes = "\n\nThe scopes are:\n" + scopes
+		}
+
+		g.imports["log/slog"] = true
+		g.imports[modulePath+"/hided"] = true
+		g.imports[modulePath+"/http/client/auth/oauth2"] = true
+		params = "clientID string, clientSecret hided.String, scopes []string,\n\tlogger *slog.Logger,"
+		build = fmt.Sprintf(`oauth2.NewClientCredentials(oauth2.ClientInHeader, oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Scopes:       scopes,
+		Endpoint:     oauth2.Endpoint{URL: %s, Auth: %s},
+	}, logger, nil)`, strconv.Quote(endpoint), strconv.Quote(path))
+
+	default:
+		// The other schemes need an authenticator provided by the caller
+		return "", nil
+	}
+
+	constructor := g.names.unique("New" + field + "Authenticator")
+	result := "&a"
+	if scheme.Type == "oauth2" {
+		result = "a"
+	}
+
+	return "\n" + comment(constructor+" returns the authenticator of the "+
+		strconv.Quote(name)+" scheme."+scopes) + fmt.Sprintf(`func %s(
+	%s
+) (auth.Authenticator, error) {
+	a, err := %s
+	if err != nil {
+		return nil, err
+	}
+
+	return %s, nil
+}
+`, constructor, params, build, result), nil
+}
+
+// tokenEndpoint splits the token URL in the base URL and the path used by
+// the oauth2 package, a relative URL is resolved against the first server.
+func (g *generator) tokenEndpoint(tokenURL string) (string, string, error) {
+	token, err := url.Parse(tokenURL)
+	if err != nil {
+		return "", "", errors.Join(ErrInvalidDocument, err)
+	}
+
+	if !token.IsAbs() && len(g.doc.Servers) > 0 {
+		server, err := url.Parse(g.doc.Servers[0].URL)
+		if err != nil {
+			return "", "", errors.Join(ErrInvalidDocument, err)
+		}
+		token = server.ResolveReference(token)
+	}
+
+	path := token.EscapedPath()
+	if token.RawQuery != "" {
+		path += "?" + token.RawQuery
+	}
+	if !token.IsAbs() {
+		return "", path, nil
+	}
+
+	return token.Scheme + "://" + token.Host, path, nil
+}
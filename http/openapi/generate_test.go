@@ -0,0 +1,177 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+package openapi_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"gitlab.com/iglou.eu/goulc/http/openapi"
+)
+
+func TestGenerate(t *testing.T) {
+	data, err := os.ReadFile("testdata/petstore.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc, err := openapi.Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	got, err := openapi.Generate(doc, openapi.Config{Package: "petstore", Source: "petstore.json"})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	// The generated client is compiled and tested in internal/petstore
+	want, err := os.ReadFile("internal/petstore/petstore.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Generate() differs from internal/petstore/petstore.go, run go generate ./...")
+	}
+
+	// The generation is deterministic
+	again, _ := openapi.Generate(doc, openapi.Config{Package: "petstore", Source: "petstore.json"})
+	if !bytes.Equal(got, again) {
+		t.Error("Generate() is not deterministic")
+	}
+}
+
+func TestGenerate_Names(t *testing.T) {
+	doc, err := openapi.Parse([]byte(`{
+		"openapi": "3.0.0",
+		"info": {"title": "Candlekeep"},
+		"paths": {
+			"/books": {
+				"get": {"operationId": "close", "responses": {"200": {"description": "ok"}}},
+				"post": {
+					"operationId": "addBook",
+					"parameters": [{"name": "name", "in": "query", "schema": {"type": "string"}}],
+					"requestBody": {"content": {"application/x-www-form-urlencoded": {}}},
+					"responses": {"201": {"description": "created"}}
+				}
+			}
+		},
+		"components": {"schemas": {
+			"AddBookRequest": {"type": "string"},
+			"Client": {"type": "integer"}
+		}}
+	}`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	src, err := openapi.Generate(doc, openapi.Config{Package: "candlekeep", TypeName: "Library"})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+
+	for _, want := range []string{
+		"type Library struct",
+		"func (api *Library) Close2() (*Close2Response, error)",
+		"type AddBookRequest string",
+		"type AddBookRequest2 struct",
+		"\tName2 *string\n",
+		"\tBody url.Values\n",
+		"type Client int64",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("Generate() does not contain %q:\n%s", want, src)
+		}
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		doc       string
+		cfg       openapi.Config
+		wantErrIs error
+	}{
+		{
+			name:      "invalid package",
+			doc:       `{"openapi": "3.0.0"}`,
+			cfg:       openapi.Config{Package: "pet-store"},
+			wantErrIs: openapi.ErrInvalidConfig,
+		},
+		{
+			name:      "unexported type",
+			doc:       `{"openapi": "3.0.0"}`,
+			cfg:       openapi.Config{Package: "petstore", TypeName: "client"},
+			wantErrIs: openapi.ErrInvalidConfig,
+		},
+		{
+			name:      "unknown schema",
+			doc:       `{"openapi": "3.0.0", "components": {"schemas": {"A": {"$ref": "#/components/schemas/B"}}}}`,
+			wantErrIs: openapi.ErrInvalidReference,
+		},
+		{
+			name:      "remote reference",
+			doc:       `{"openapi": "3.0.0", "components": {"schemas": {"A": {"$ref": "common.json#/Pet"}}}}`,
+			wantErrIs: openapi.ErrInvalidReference,
+		},
+		{
+			name: "parameter cycle",
+			doc: `{"openapi": "3.0.0",
+				"paths": {"/": {"get": {"parameters": [{"$ref": "#/components/parameters/a"}]}}},
+				"components": {"parameters": {
+					"a": {"$ref": "#/components/parameters/b"},
+					"b": {"$ref": "#/components/parameters/a"}
+				}}}`,
+			wantErrIs: openapi.ErrInvalidReference,
+		},
+		{
+			name:      "unknown type",
+			doc:       `{"openapi": "3.0.0", "components": {"schemas": {"A": {"type": "file"}}}}`,
+			wantErrIs: openapi.ErrUnsupportedSchema,
+		},
+		{
+			name:      "integer enumeration",
+			doc:       `{"openapi": "3.0.0", "components": {"schemas": {"A": {"type": "string", "enum": ["a", 1]}}}}`,
+			wantErrIs: openapi.ErrUnsupportedSchema,
+		},
+		{
+			name:      "invalid status code",
+			doc:       `{"openapi": "3.0.0", "paths": {"/": {"get": {"responses": {"OK": {}}}}}}`,
+			wantErrIs: openapi.ErrInvalidDocument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := openapi.Parse([]byte(tt.doc))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if tt.cfg.Package == "" {
+				tt.cfg.Package = "petstore"
+			}
+
+			if _, err := openapi.Generate(doc, tt.cfg); !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("Generate() error = %v, want %v", err, tt.wantErrIs)
+			}
+		})
+	}
+}
@@ -0,0 +1,928 @@
+// Code generated by goulc-openapi. DO NOT EDIT.
+// Source: petstore.json
+
+// Package petstore is the client of the "Swagger Petstore" API, version
+// 1.0.0.
+//
+// A sample API that uses a petstore as an example.
+package petstore
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/iglou.eu/goulc/hided"
+	"gitlab.com/iglou.eu/goulc/http/client"
+	"gitlab.com/iglou.eu/goulc/http/client/auth"
+	"gitlab.com/iglou.eu/goulc/http/client/auth/oauth2"
+)
+
+// DefaultServerURL is the first server URL of the document.
+const DefaultServerURL = "https://petstore.wasteland/v1"
+
+// ErrUnexpectedStatus is wrapped by the errors of the operations returned
+// when the server answers with a status code other than the documented
+// successful ones.
+var ErrUnexpectedStatus = errors.New("unexpected status code")
+
+// Client is the "Swagger Petstore" API client, it sends the requests through a child
+// of the http/client Client given to New.
+type Client struct {
+	client   *client.Client
+	security Security
+}
+
+// New returns a Client sending the requests through a child of c, whose URL
+// is the API server root, such as DefaultServerURL. The operations are
+// authenticated with the security authenticators, or with the c one when
+// none of their schemes is set.
+func New(c *client.Client, security Security) (*Client, error) {
+	child := c.NewChild("")
+	if child == nil {
+		return nil, client.ErrClientClosed
+	}
+
+	return &Client{client: child, security: security}, nil
+}
+
+// Close closes the child client, c is left open.
+func (api *Client) Close() error {
+	return api.client.Close()
+}
+
+// Security holds the authenticators of the security schemes. The operations
+// use the first authenticator set among their requirements, or the client
+// one when none is set.
+//
+// The "legacy_key" apiKey scheme is not supported.
+type Security struct {
+	// APIKey authenticates with the "api_key" scheme, an API key in the
+	// X-API-Key header.
+	APIKey auth.Authenticator
+	// Basic authenticates with the "basic" scheme, the HTTP basic scheme.
+	Basic auth.Authenticator
+	// Bearer authenticates with the "bearer" scheme, the HTTP bearer scheme.
+	Bearer auth.Authenticator
+	// PetstoreAuth authenticates with the "petstore_auth" scheme, OAuth2.
+	// The Petstore authorization server.
+	PetstoreAuth auth.Authenticator
+}
+
+// NewAPIKeyAuthenticator returns the authenticator of the "api_key" scheme.
+func NewAPIKeyAuthenticator(
+	key hided.String,
+) (auth.Authenticator, error) {
+	a, err := auth.NewAPIKey("X-API-Key", key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+// NewBasicAuthenticator returns the authenticator of the "basic" scheme.
+func NewBasicAuthenticator(
+	username string, password hided.String,
+) (auth.Authenticator, error) {
+	a, err := auth.NewBasic(username, password)
+	if err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+// NewBearerAuthenticator returns the authenticator of the "bearer" scheme.
+func NewBearerAuthenticator(
+	token hided.String,
+) (auth.Authenticator, error) {
+	a, err := auth.NewBearer(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+// NewPetstoreAuthAuthenticator returns the authenticator of the
+// "petstore_auth" scheme.
+//
+// The scopes are:
+//   - read:pets: read your pets
+//   - write:pets: modify pets in your account
+func NewPetstoreAuthAuthenticator(
+	clientID string, clientSecret hided.String, scopes []string,
+	logger *slog.Logger,
+) (auth.Authenticator, error) {
+	a, err := oauth2.NewClientCredentials(oauth2.ClientInHeader, oauth2.Config{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Scopes:       scopes,
+		Endpoint:     oauth2.Endpoint{URL: "https://auth.wasteland", Auth: "/oauth/token"},
+	}, logger, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// GetHealth sends GET /health.
+func (api *Client) GetHealth() (*GetHealthResponse, error) {
+	r := api.client.R().Path("/health")
+
+	out := &GetHealthResponse{}
+	resp, err := r.Do(http.MethodGet, out)
+	if err != nil {
+		return nil, err
+	}
+	out.StatusCode, out.Header = resp.StatusCode, resp.Header
+
+	if resp.StatusCode == 200 {
+		return out, nil
+	}
+
+	return nil, newGetHealthError(resp)
+}
+
+// GetHealthResponse is the successful response of GetHealth.
+type GetHealthResponse struct {
+	// StatusCode is the HTTP response status code
+	StatusCode int
+	// Header contains the response headers
+	Header http.Header
+
+	// OK is the body of the 200 response: The service status.
+	OK *GetHealthOK
+}
+
+// Verify GetHealthResponse implements client.Unmarshaler interface
+var _ client.Unmarshaler = &GetHealthResponse{}
+
+// Name returns the identifier of the response.
+func (_ *GetHealthResponse) Name() string {
+	return "petstore.GetHealthResponse"
+}
+
+// Unmarshal parses the body of the successful responses, the other ones are
+// parsed by the GetHealthError. An empty body is not parsed.
+func (r *GetHealthResponse) Unmarshal(
+	statusCode int, header http.Header, body []byte,
+) error {
+	r.StatusCode, r.Header = statusCode, header
+
+	if len(body) == 0 {
+		return nil
+	}
+
+	if statusCode == 200 {
+		r.OK = new(GetHealthOK)
+		return json.Unmarshal(body, r.OK)
+	}
+
+	return nil
+}
+
+// GetHealthError is returned by GetHealth when the server answers with a
+// status code other than the documented successful ones, it wraps
+// ErrUnexpectedStatus.
+type GetHealthError struct {
+	// StatusCode is the HTTP response status code
+	StatusCode int
+	// Header contains the response headers
+	Header http.Header
+	// Body contains the raw response body
+	Body []byte
+}
+
+// Error returns the operation and the status code.
+func (e *GetHealthError) Error() string {
+	return "GetHealth: " + ErrUnexpectedStatus.Error() + " " +
+		strconv.Itoa(e.StatusCode)
+}
+
+// Unwrap returns ErrUnexpectedStatus.
+func (e *GetHealthError) Unwrap() error {
+	return ErrUnexpectedStatus
+}
+
+// newGetHealthError returns the GetHealthError of the response, with its
+// documented body parsed.
+func newGetHealthError(resp *client.Response) *GetHealthError {
+	e := &GetHealthError{StatusCode: resp.StatusCode, Header: resp.Header, Body: resp.Body}
+
+	return e
+}
+
+// GetHealthOK is the 200 response body of GetHealth.
+type GetHealthOK struct {
+	Checks map[string]bool   `json:"checks,omitempty"`
+	Status GetHealthOKStatus `json:"status"`
+}
+
+// GetHealthOKStatus is the status property of GetHealthOK.
+type GetHealthOKStatus string
+
+// The GetHealthOKStatus values.
+const (
+	GetHealthOKStatusOk       GetHealthOKStatus = "ok"
+	GetHealthOKStatusDegraded GetHealthOKStatus = "degraded"
+)
+
+// ListPets sends GET /pets.
+//
+// List all pets.
+//
+// The cookie parameters session are not supported.
+//
+// Security: bearer or api_key.
+func (api *Client) ListPets(req *ListPetsRequest) (*ListPetsResponse, error) {
+	if req == nil {
+		req = &ListPetsRequest{}
+	}
+
+	r := api.client.R().Path("/pets")
+	if req.Limit != nil {
+		r = r.Query("limit", strconv.FormatInt(int64(*req.Limit), 10))
+	}
+	for _, v := range req.Tags {
+		r = r.Query("tags", v)
+	}
+	if req.Status != nil {
+		r = r.Query("status", string(*req.Status))
+	}
+	if req.BornAfter != nil {
+		r = r.Query("born_after", (*req.BornAfter).Format(time.RFC3339))
+	}
+	if len(req.IDs) > 0 {
+		values := make([]string, len(req.IDs))
+		for i, v := range req.IDs {
+			values[i] = strconv.FormatInt(v, 10)
+		}
+		r = r.Query("ids", strings.Join(values, ","))
+	}
+	r = r.Header("X-Request-ID", req.XRequestID)
+	switch {
+	case api.security.Bearer != nil:
+		r = r.Auth(api.security.Bearer)
+	case api.security.APIKey != nil:
+		r = r.Auth(api.security.APIKey)
+	}
+
+	out := &ListPetsResponse{}
+	resp, err := r.Do(http.MethodGet, out)
+	if err != nil {
+		return nil, err
+	}
+	out.StatusCode, out.Header = resp.StatusCode, resp.Header
+
+	if resp.StatusCode == 200 {
+		return out, nil
+	}
+
+	return nil, newListPetsError(resp)
+}
+
+// ListPetsRequest holds the parameters of ListPets.
+type ListPetsRequest struct {
+	// Limit is the limit query parameter.
+	// How many items to return at one time (max 100)
+	Limit *int32
+	// Tags is the tags query parameter.
+	// Tags to filter by
+	Tags []string
+	// Status is the status query parameter.
+	Status *PetStatus
+	// BornAfter is the born_after query parameter.
+	BornAfter *time.Time
+	// IDs is the ids query parameter.
+	IDs []int64
+	// XRequestID is the X-Request-ID header parameter, required.
+	XRequestID string
+}
+
+// ListPetsResponse is the successful response of ListPets.
+type ListPetsResponse struct {
+	// StatusCode is the HTTP response status code
+	StatusCode int
+	// Header contains the response headers
+	Header http.Header
+
+	// OK is the body of the 200 response: A paged array of pets.
+	OK Pets
+}
+
+// Verify ListPetsResponse implements client.Unmarshaler interface
+var _ client.Unmarshaler = &ListPetsResponse{}
+
+// Name returns the identifier of the response.
+func (_ *ListPetsResponse) Name() string {
+	return "petstore.ListPetsResponse"
+}
+
+// Unmarshal parses the body of the successful responses, the other ones are
+// parsed by the ListPetsError. An empty body is not parsed.
+func (r *ListPetsResponse) Unmarshal(
+	statusCode int, header http.Header, body []byte,
+) error {
+	r.StatusCode, r.Header = statusCode, header
+
+	if len(body) == 0 {
+		return nil
+	}
+
+	if statusCode == 200 {
+		return json.Unmarshal(body, &r.OK)
+	}
+
+	return nil
+}
+
+// ListPetsError is returned by ListPets when the server answers with a
+// status code other than the documented successful ones, it wraps
+// ErrUnexpectedStatus.
+type ListPetsError struct {
+	// StatusCode is the HTTP response status code
+	StatusCode int
+	// Header contains the response headers
+	Header http.Header
+	// Body contains the raw response body
+	Body []byte
+
+	// Default is the body of the default response: Unexpected error. It is nil
+	// if the body cannot be parsed.
+	Default *Error
+}
+
+// Error returns the operation and the status code.
+func (e *ListPetsError) Error() string {
+	return "ListPets: " + ErrUnexpectedStatus.Error() + " " +
+		strconv.Itoa(e.StatusCode)
+}
+
+// Unwrap returns ErrUnexpectedStatus.
+func (e *ListPetsError) Unwrap() error {
+	return ErrUnexpectedStatus
+}
+
+// newListPetsError returns the ListPetsError of the response, with its
+// documented body parsed.
+func newListPetsError(resp *client.Response) *ListPetsError {
+	e := &ListPetsError{StatusCode: resp.StatusCode, Header: resp.Header, Body: resp.Body}
+
+	e.Default = new(Error)
+	if json.Unmarshal(resp.Body, e.Default) != nil {
+		e.Default = nil
+	}
+
+	return e
+}
+
+// CreatePet sends POST /pets.
+//
+// Create a pet.
+//
+// Security: petstore_auth (write:pets) or api_key and basic (not supported).
+func (api *Client) CreatePet(req *CreatePetRequest) (*CreatePetResponse, error) {
+	if req == nil {
+		req = &CreatePetRequest{}
+	}
+
+	r := api.client.R().Path("/pets")
+	r = r.Marshal(req)
+	if api.security.PetstoreAuth != nil {
+		r = r.Auth(api.security.PetstoreAuth)
+	}
+
+	out := &CreatePetResponse{}
+	resp, err := r.Do(http.MethodPost, out)
+	if err != nil {
+		return nil, err
+	}
+	out.StatusCode, out.Header = resp.StatusCode, resp.Header
+
+	if resp.StatusCode == 201 {
+		return out, nil
+	}
+
+	return nil, newCreatePetError(resp)
+}
+
+// CreatePetRequest holds the parameters and the body of CreatePet, it is
+// marshaled as the request body.
+type CreatePetRequest struct {
+	// Body is the request body, sent as application/json
+	Body NewPet
+}
+
+// Verify CreatePetRequest implements client.Marshaler interface
+var _ client.Marshaler = &CreatePetRequest{}
+
+// Name returns the identifier of the request body.
+func (_ *CreatePetRequest) Name() string {
+	return "petstore.CreatePetRequest"
+}
+
+// ContentType returns the content type of the request body.
+func (_ *CreatePetRequest) ContentType() string {
+	return "application/json"
+}
+
+// Marshal serializes the request body.
+func (r *CreatePetRequest) Marshal() ([]byte, error) {
+	return json.Marshal(r.Body)
+}
+
+// CreatePetResponse is the successful response of CreatePet.
+type CreatePetResponse struct {
+	// StatusCode is the HTTP response status code
+	StatusCode int
+	// Header contains the response headers
+	Header http.Header
+
+	// Created is the body of the 201 response: The created pet.
+	Created *Pet
+}
+
+// Verify CreatePetResponse implements client.Unmarshaler interface
+var _ client.Unmarshaler = &CreatePetResponse{}
+
+// Name returns the identifier of the response.
+func (_ *CreatePetResponse) Name() string {
+	return "petstore.CreatePetResponse"
+}
+
+// Unmarshal parses the body of the successful responses, the other ones are
+// parsed by the CreatePetError. An empty body is not parsed.
+func (r *CreatePetResponse) Unmarshal(
+	statusCode int, header http.Header, body []byte,
+) error {
+	r.StatusCode, r.Header = statusCode, header
+
+	if len(body) == 0 {
+		return nil
+	}
+
+	if statusCode == 201 {
+		r.Created = new(Pet)
+		return json.Unmarshal(body, r.Created)
+	}
+
+	return nil
+}
+
+// CreatePetError is returned by CreatePet when the server answers with a
+// status code other than the documented successful ones, it wraps
+// ErrUnexpectedStatus.
+type CreatePetError struct {
+	// StatusCode is the HTTP response status code
+	StatusCode int
+	// Header contains the response headers
+	Header http.Header
+	// Body contains the raw response body
+	Body []byte
+
+	// Conflict is the body of the 409 response: The pet already exists. It is
+	// nil if the body cannot be parsed.
+	Conflict *CreatePetErrorConflict
+	// Default is the body of the default response: Unexpected error. It is nil
+	// if the body cannot be parsed.
+	Default *Error
+}
+
+// Error returns the operation and the status code.
+func (e *CreatePetError) Error() string {
+	return "CreatePet: " + ErrUnexpectedStatus.Error() + " " +
+		strconv.Itoa(e.StatusCode)
+}
+
+// Unwrap returns ErrUnexpectedStatus.
+func (e *CreatePetError) Unwrap() error {
+	return ErrUnexpectedStatus
+}
+
+// newCreatePetError returns the CreatePetError of the response, with its
+// documented body parsed.
+func newCreatePetError(resp *client.Response) *CreatePetError {
+	e := &CreatePetError{StatusCode: resp.StatusCode, Header: resp.Header, Body: resp.Body}
+
+	switch {
+	case resp.StatusCode == 409:
+		e.Conflict = new(CreatePetErrorConflict)
+		if json.Unmarshal(resp.Body, e.Conflict) != nil {
+			e.Conflict = nil
+		}
+	default:
+		e.Default = new(Error)
+		if json.Unmarshal(resp.Body, e.Default) != nil {
+			e.Default = nil
+		}
+	}
+
+	return e
+}
+
+// CreatePetErrorConflict is the 409 response body of CreatePet.
+type CreatePetErrorConflict struct {
+	ExistingID *int64  `json:"existing_id,omitempty"`
+	Title      *string `json:"title,omitempty"`
+}
+
+// UploadPhoto sends PUT /pets/{pet-id}/photo.
+func (api *Client) UploadPhoto(req *UploadPhotoRequest) (*UploadPhotoResponse, error) {
+	if req == nil {
+		req = &UploadPhotoRequest{}
+	}
+
+	r := api.client.R().Template("/pets/{pet_id}/photo", map[string]any{
+		"pet_id": strconv.FormatInt(req.PetID, 10),
+	})
+	if req.Body != nil {
+		r = r.Marshal(req)
+	}
+
+	out := &UploadPhotoResponse{}
+	resp, err := r.Do(http.MethodPut, out)
+	if err != nil {
+		return nil, err
+	}
+	out.StatusCode, out.Header = resp.StatusCode, resp.Header
+
+	if resp.StatusCode == 200 {
+		return out, nil
+	}
+
+	return nil, newUploadPhotoError(resp)
+}
+
+// UploadPhotoRequest holds the parameters and the body of UploadPhoto, it is
+// marshaled as the request body.
+type UploadPhotoRequest struct {
+	// PetID is the pet-id path parameter, required.
+	PetID int64
+
+	// Body is the request body, sent as application/octet-stream
+	Body []byte
+}
+
+// Verify UploadPhotoRequest implements client.Marshaler interface
+var _ client.Marshaler = &UploadPhotoRequest{}
+
+// Name returns the identifier of the request body.
+func (_ *UploadPhotoRequest) Name() string {
+	return "petstore.UploadPhotoRequest"
+}
+
+// ContentType returns the content type of the request body.
+func (_ *UploadPhotoRequest) ContentType() string {
+	return "application/octet-stream"
+}
+
+// Marshal serializes the request body.
+func (r *UploadPhotoRequest) Marshal() ([]byte, error) {
+	return r.Body, nil
+}
+
+// UploadPhotoResponse is the successful response of UploadPhoto.
+type UploadPhotoResponse struct {
+	// StatusCode is the HTTP response status code
+	StatusCode int
+	// Header contains the response headers
+	Header http.Header
+
+	// OK is the body of the 200 response: The photo URL.
+	OK []byte
+}
+
+// Verify UploadPhotoResponse implements client.Unmarshaler interface
+var _ client.Unmarshaler = &UploadPhotoResponse{}
+
+// Name returns the identifier of the response.
+func (_ *UploadPhotoResponse) Name() string {
+	return "petstore.UploadPhotoResponse"
+}
+
+// Unmarshal parses the body of the successful responses, the other ones are
+// parsed by the UploadPhotoError. An empty body is not parsed.
+func (r *UploadPhotoResponse) Unmarshal(
+	statusCode int, header http.Header, body []byte,
+) error {
+	r.StatusCode, r.Header = statusCode, header
+
+	if len(body) == 0 {
+		return nil
+	}
+
+	if statusCode == 200 {
+		r.OK = body
+		return nil
+	}
+
+	return nil
+}
+
+// UploadPhotoError is returned by UploadPhoto when the server answers with a
+// status code other than the documented successful ones, it wraps
+// ErrUnexpectedStatus.
+type UploadPhotoError struct {
+	// StatusCode is the HTTP response status code
+	StatusCode int
+	// Header contains the response headers
+	Header http.Header
+	// Body contains the raw response body
+	Body []byte
+}
+
+// Error returns the operation and the status code.
+func (e *UploadPhotoError) Error() string {
+	return "UploadPhoto: " + ErrUnexpectedStatus.Error() + " " +
+		strconv.Itoa(e.StatusCode)
+}
+
+// Unwrap returns ErrUnexpectedStatus.
+func (e *UploadPhotoError) Unwrap() error {
+	return ErrUnexpectedStatus
+}
+
+// newUploadPhotoError returns the UploadPhotoError of the response, with its
+// documented body parsed.
+func newUploadPhotoError(resp *client.Response) *UploadPhotoError {
+	e := &UploadPhotoError{StatusCode: resp.StatusCode, Header: resp.Header, Body: resp.Body}
+
+	return e
+}
+
+// ShowPetByID sends GET /pets/{petId}.
+//
+// Info for a specific pet.
+//
+// Security: bearer or api_key.
+func (api *Client) ShowPetByID(req *ShowPetByIDRequest) (*ShowPetByIDResponse, error) {
+	if req == nil {
+		req = &ShowPetByIDRequest{}
+	}
+
+	r := api.client.R().Template("/pets/{petId}", map[string]any{
+		"petId": req.PetID,
+	})
+	switch {
+	case api.security.Bearer != nil:
+		r = r.Auth(api.security.Bearer)
+	case api.security.APIKey != nil:
+		r = r.Auth(api.security.APIKey)
+	}
+
+	out := &ShowPetByIDResponse{}
+	resp, err := r.Do(http.MethodGet, out)
+	if err != nil {
+		return nil, err
+	}
+	out.StatusCode, out.Header = resp.StatusCode, resp.Header
+
+	if resp.StatusCode == 200 {
+		return out, nil
+	}
+
+	return nil, newShowPetByIDError(resp)
+}
+
+// ShowPetByIDRequest holds the parameters of ShowPetByID.
+type ShowPetByIDRequest struct {
+	// PetID is the petId path parameter, required.
+	// The id of the pet
+	PetID string
+}
+
+// ShowPetByIDResponse is the successful response of ShowPetByID.
+type ShowPetByIDResponse struct {
+	// StatusCode is the HTTP response status code
+	StatusCode int
+	// Header contains the response headers
+	Header http.Header
+
+	// OK is the body of the 200 response: The pet.
+	OK *Pet
+}
+
+// Verify ShowPetByIDResponse implements client.Unmarshaler interface
+var _ client.Unmarshaler = &ShowPetByIDResponse{}
+
+// Name returns the identifier of the response.
+func (_ *ShowPetByIDResponse) Name() string {
+	return "petstore.ShowPetByIDResponse"
+}
+
+// Unmarshal parses the body of the successful responses, the other ones are
+// parsed by the ShowPetByIDError. An empty body is not parsed.
+func (r *ShowPetByIDResponse) Unmarshal(
+	statusCode int, header http.Header, body []byte,
+) error {
+	r.StatusCode, r.Header = statusCode, header
+
+	if len(body) == 0 {
+		return nil
+	}
+
+	if statusCode == 200 {
+		r.OK = new(Pet)
+		return json.Unmarshal(body, r.OK)
+	}
+
+	return nil
+}
+
+// ShowPetByIDError is returned by ShowPetByID when the server answers with a
+// status code other than the documented successful ones, it wraps
+// ErrUnexpectedStatus.
+type ShowPetByIDError struct {
+	// StatusCode is the HTTP response status code
+	StatusCode int
+	// Header contains the response headers
+	Header http.Header
+	// Body contains the raw response body
+	Body []byte
+
+	// Status5XX is the body of the 5XX response: Server error. It is nil if the
+	// body cannot be parsed.
+	Status5XX []byte
+	// Default is the body of the default response: Unexpected error. It is nil
+	// if the body cannot be parsed.
+	Default *Error
+}
+
+// Error returns the operation and the status code.
+func (e *ShowPetByIDError) Error() string {
+	return "ShowPetByID: " + ErrUnexpectedStatus.Error() + " " +
+		strconv.Itoa(e.StatusCode)
+}
+
+// Unwrap returns ErrUnexpectedStatus.
+func (e *ShowPetByIDError) Unwrap() error {
+	return ErrUnexpectedStatus
+}
+
+// newShowPetByIDError returns the ShowPetByIDError of the response, with its
+// documented body parsed.
+func newShowPetByIDError(resp *client.Response) *ShowPetByIDError {
+	e := &ShowPetByIDError{StatusCode: resp.StatusCode, Header: resp.Header, Body: resp.Body}
+
+	switch {
+	case resp.StatusCode == 404:
+	case resp.StatusCode/100 == 5:
+		e.Status5XX = resp.Body
+	default:
+		e.Default = new(Error)
+		if json.Unmarshal(resp.Body, e.Default) != nil {
+			e.Default = nil
+		}
+	}
+
+	return e
+}
+
+// DeletePet sends DELETE /pets/{petId}.
+//
+// Security: basic.
+//
+// Deprecated: the operation is deprecated by the API.
+func (api *Client) DeletePet(req *DeletePetRequest) (*DeletePetResponse, error) {
+	if req == nil {
+		req = &DeletePetRequest{}
+	}
+
+	r := api.client.R().Template("/pets/{petId}", map[string]any{
+		"petId": req.PetID,
+	})
+	if api.security.Basic != nil {
+		r = r.Auth(api.security.Basic)
+	}
+
+	out := &DeletePetResponse{}
+	resp, err := r.Do(http.MethodDelete, out)
+	if err != nil {
+		return nil, err
+	}
+	out.StatusCode, out.Header = resp.StatusCode, resp.Header
+
+	if resp.StatusCode == 204 {
+		return out, nil
+	}
+
+	return nil, newDeletePetError(resp)
+}
+
+// DeletePetRequest holds the parameters of DeletePet.
+type DeletePetRequest struct {
+	// PetID is the petId path parameter, required.
+	// The id of the pet
+	PetID string
+}
+
+// DeletePetResponse is the successful response of DeletePet.
+type DeletePetResponse struct {
+	// StatusCode is the HTTP response status code
+	StatusCode int
+	// Header contains the response headers
+	Header http.Header
+}
+
+// Verify DeletePetResponse implements client.Unmarshaler interface
+var _ client.Unmarshaler = &DeletePetResponse{}
+
+// Name returns the identifier of the response.
+func (_ *DeletePetResponse) Name() string {
+	return "petstore.DeletePetResponse"
+}
+
+// Unmarshal parses the body of the successful responses, the other ones are
+// parsed by the DeletePetError. An empty body is not parsed.
+func (r *DeletePetResponse) Unmarshal(
+	statusCode int, header http.Header, body []byte,
+) error {
+	r.StatusCode, r.Header = statusCode, header
+
+	return nil
+}
+
+// DeletePetError is returned by DeletePet when the server answers with a
+// status code other than the documented successful ones, it wraps
+// ErrUnexpectedStatus.
+type DeletePetError struct {
+	// StatusCode is the HTTP response status code
+	StatusCode int
+	// Header contains the response headers
+	Header http.Header
+	// Body contains the raw response body
+	Body []byte
+}
+
+// Error returns the operation and the status code.
+func (e *DeletePetError) Error() string {
+	return "DeletePet: " + ErrUnexpectedStatus.Error() + " " +
+		strconv.Itoa(e.StatusCode)
+}
+
+// Unwrap returns ErrUnexpectedStatus.
+func (e *DeletePetError) Unwrap() error {
+	return ErrUnexpectedStatus
+}
+
+// newDeletePetError returns the DeletePetError of the response, with its
+// documented body parsed.
+func newDeletePetError(resp *client.Response) *DeletePetError {
+	e := &DeletePetError{StatusCode: resp.StatusCode, Header: resp.Header, Body: resp.Body}
+
+	return e
+}
+
+// Error is the Error schema.
+type Error struct {
+	Code    int32  `json:"code"`
+	Message string `json:"message"`
+}
+
+// NewPet is the NewPet schema.
+//
+// A pet to create.
+type NewPet struct {
+	Birth  *time.Time        `json:"birth,omitempty"`
+	Extra  json.RawMessage   `json:"extra,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+	// Name The pet name
+	Name   string       `json:"name"`
+	Owner  *NewPetOwner `json:"owner,omitempty"`
+	Status *PetStatus   `json:"status,omitempty"`
+	Tag    *string      `json:"tag,omitempty"`
+	Weight *float32     `json:"weight,omitempty"`
+}
+
+// NewPetOwner is the owner property of NewPet.
+type NewPetOwner struct {
+	// Deprecated: the property is deprecated by the API.
+	Email *string `json:"email,omitempty"`
+	Name  *string `json:"name,omitempty"`
+}
+
+// Pet is the Pet schema.
+type Pet struct {
+	NewPet
+	ID string `json:"id"`
+}
+
+// PetStatus is the PetStatus schema.
+type PetStatus string
+
+// The PetStatus values.
+const (
+	PetStatusAvailable PetStatus = "available"
+	PetStatusPending   PetStatus = "pending"
+	PetStatusSold      PetStatus = "sold"
+)
+
+// Pets is the Pets schema.
+type Pets []Pet
@@ -0,0 +1,277 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+package petstore_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gitlab.com/iglou.eu/goulc/http/client"
+	"gitlab.com/iglou.eu/goulc/http/openapi/internal/petstore"
+)
+
+// petServer serves the Petstore API, it returns the request it received.
+func petServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Path", r.URL.EscapedPath())
+		w.Header().Set("X-Query", r.URL.RawQuery)
+		w.Header().Set("X-Authorization", r.Header.Get("Authorization"))
+		w.Header().Set("X-Content-Type", r.Header.Get("Content-Type"))
+
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v1/pets":
+			_, _ = w.Write([]byte(`[{"id":"boo","name":"Boo","status":"sold"}]`))
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/pets":
+			var pet petstore.NewPet
+			if json.Unmarshal(body, &pet) != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"code":400,"message":"invalid pet"}`))
+				return
+			}
+			if pet.Name == "Boo" {
+				w.Header().Set("Content-Type", "application/problem+json")
+				w.WriteHeader(http.StatusConflict)
+				_, _ = w.Write([]byte(`{"title":"Boo exists","existing_id":1}`))
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			if pet.Name == "Imoen" {
+				return
+			}
+			_ = json.NewEncoder(w).Encode(petstore.Pet{NewPet: pet, ID: "2"})
+		case r.URL.Path == "/v1/pets/boo":
+			_, _ = w.Write([]byte(`{"id":"boo","name":"Boo"}`))
+		case r.URL.Path == "/v1/pets/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/v1/pets/broken":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("maintenance"))
+		case r.URL.Path == "/v1/pets/42/photo":
+			_, _ = w.Write(append([]byte("https://petstore.wasteland/"), body...))
+		case r.URL.Path == "/v1/health":
+			_, _ = w.Write([]byte(`{"status":"ok","checks":{"db":true}}`))
+		default:
+			w.WriteHeader(http.StatusTeapot)
+		}
+	}))
+	t.Cleanup(ts.Close)
+
+	return ts
+}
+
+func newPetstore(t *testing.T, url string, security petstore.Security) *petstore.Client {
+	t.Helper()
+
+	c, err := client.New(context.Background(), url+"/v1", nil, &client.Options{Timeout: time.Second}, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	api, err := petstore.New(&c, security)
+	if err != nil {
+		t.Fatalf("petstore.New() error = %v", err)
+	}
+	t.Cleanup(func() { api.Close() })
+
+	return api
+}
+
+func TestClient_ListPets(t *testing.T) {
+	ts := petServer(t)
+
+	bearer, _ := petstore.NewBearerAuthenticator("minsc")
+	apiKey, _ := petstore.NewAPIKeyAuthenticator("boo")
+	api := newPetstore(t, ts.URL, petstore.Security{Bearer: bearer, APIKey: apiKey})
+
+	limit, status := int32(10), petstore.PetStatusSold
+	bornAfter := time.Date(1368, time.January, 1, 0, 0, 0, 0, time.UTC)
+	resp, err := api.ListPets(&petstore.ListPetsRequest{
+		Limit:      &limit,
+		Tags:       []string{"hamster", "space"},
+		Status:     &status,
+		BornAfter:  &bornAfter,
+		IDs:        []int64{1, 2},
+		XRequestID: "rashemen",
+	})
+	if err != nil {
+		t.Fatalf("ListPets() error = %v", err)
+	}
+
+	wantQuery := "born_after=1368-01-01T00%3A00%3A00Z&ids=1%2C2&limit=10&status=sold&tags=hamster&tags=space"
+	if got := resp.Header.Get("X-Query"); got != wantQuery {
+		t.Errorf("query = %s, want %s", got, wantQuery)
+	}
+	if got := resp.Header.Get("X-Authorization"); got != "Bearer minsc" {
+		t.Errorf("Authorization = %s, want the first security requirement", got)
+	}
+	if resp.StatusCode != http.StatusOK || len(resp.OK) != 1 ||
+		resp.OK[0].ID != "boo" || *resp.OK[0].Status != petstore.PetStatusSold {
+		t.Errorf("ListPets() = %+v", resp)
+	}
+}
+
+func TestClient_CreatePet(t *testing.T) {
+	ts := petServer(t)
+	api := newPetstore(t, ts.URL, petstore.Security{})
+
+	resp, err := api.CreatePet(&petstore.CreatePetRequest{
+		Body: petstore.NewPet{Name: "Jaheira", Labels: map[string]string{"class": "druid"}},
+	})
+	if err != nil {
+		t.Fatalf("CreatePet() error = %v", err)
+	}
+	if resp.Created == nil || resp.Created.ID != "2" || resp.Created.Name != "Jaheira" ||
+		resp.Created.Labels["class"] != "druid" ||
+		resp.Header.Get("X-Content-Type") != "application/json" {
+		t.Errorf("CreatePet() = %+v", resp)
+	}
+
+	// An empty body is not parsed
+	resp, err = api.CreatePet(&petstore.CreatePetRequest{Body: petstore.NewPet{Name: "Imoen"}})
+	if err != nil {
+		t.Fatalf("CreatePet() error = %v", err)
+	}
+	if resp.StatusCode != http.StatusCreated || resp.Created != nil {
+		t.Errorf("CreatePet() = %+v, want an empty 201", resp)
+	}
+
+	// The documented error bodies are parsed in the operation error
+	_, err = api.CreatePet(&petstore.CreatePetRequest{Body: petstore.NewPet{Name: "Boo"}})
+	var createErr *petstore.CreatePetError
+	if !errors.As(err, &createErr) || !errors.Is(err, petstore.ErrUnexpectedStatus) {
+		t.Fatalf("CreatePet() error = %v, want a CreatePetError", err)
+	}
+	if createErr.StatusCode != http.StatusConflict || createErr.Conflict == nil ||
+		*createErr.Conflict.ExistingID != 1 || createErr.Default != nil {
+		t.Errorf("CreatePet() error = %+v", createErr)
+	}
+}
+
+func TestClient_ShowPetByID(t *testing.T) {
+	ts := petServer(t)
+	api := newPetstore(t, ts.URL, petstore.Security{})
+
+	resp, err := api.ShowPetByID(&petstore.ShowPetByIDRequest{PetID: "boo"})
+	if err != nil || resp.OK == nil || resp.OK.Name != "Boo" {
+		t.Fatalf("ShowPetByID() = %+v, %v", resp, err)
+	}
+
+	tests := []struct {
+		id       string
+		wantCode int
+		wantPath string
+		check    func(e *petstore.ShowPetByIDError) bool
+	}{
+		{
+			id: "missing", wantCode: http.StatusNotFound,
+			check: func(e *petstore.ShowPetByIDError) bool { return e.Default == nil && e.Status5XX == nil },
+		},
+		{
+			id: "broken", wantCode: http.StatusServiceUnavailable,
+			check: func(e *petstore.ShowPetByIDError) bool { return string(e.Status5XX) == "maintenance" },
+		},
+		{
+			// The identifier stays in its path segment
+			id: "a/b?c", wantCode: http.StatusTeapot, wantPath: "/v1/pets/a%2Fb%3Fc",
+			check: func(e *petstore.ShowPetByIDError) bool { return e.Default == nil },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			_, err := api.ShowPetByID(&petstore.ShowPetByIDRequest{PetID: tt.id})
+
+			var showErr *petstore.ShowPetByIDError
+			if !errors.As(err, &showErr) || showErr.StatusCode != tt.wantCode || !tt.check(showErr) {
+				t.Fatalf("ShowPetByID() error = %#v, want status %d", err, tt.wantCode)
+			}
+			if tt.wantPath != "" && showErr.Header.Get("X-Path") != tt.wantPath {
+				t.Errorf("path = %s, want %s", showErr.Header.Get("X-Path"), tt.wantPath)
+			}
+		})
+	}
+}
+
+func TestClient_UploadPhoto(t *testing.T) {
+	ts := petServer(t)
+	api := newPetstore(t, ts.URL, petstore.Security{})
+
+	resp, err := api.UploadPhoto(&petstore.UploadPhotoRequest{PetID: 42, Body: []byte("boo.png")})
+	if err != nil {
+		t.Fatalf("UploadPhoto() error = %v", err)
+	}
+	if string(resp.OK) != "https://petstore.wasteland/boo.png" ||
+		resp.Header.Get("X-Content-Type") != "application/octet-stream" {
+		t.Errorf("UploadPhoto() = %+v", resp)
+	}
+}
+
+func TestClient_GetHealth(t *testing.T) {
+	ts := petServer(t)
+
+	// The operation has no security requirement, the client one is kept
+	basic, _ := petstore.NewBasicAuthenticator("minsc", "boo")
+	api := newPetstore(t, ts.URL, petstore.Security{Basic: basic})
+
+	resp, err := api.GetHealth()
+	if err != nil {
+		t.Fatalf("GetHealth() error = %v", err)
+	}
+	if resp.OK == nil || resp.OK.Status != petstore.GetHealthOKStatusOk || !resp.OK.Checks["db"] ||
+		resp.Header.Get("X-Authorization") != "" {
+		t.Errorf("GetHealth() = %+v", resp)
+	}
+}
+
+func TestNew(t *testing.T) {
+	c, err := client.New(context.Background(), petstore.DefaultServerURL, nil, nil, nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	api, err := petstore.New(&c, petstore.Security{})
+	if err != nil {
+		t.Fatalf("petstore.New() error = %v", err)
+	}
+
+	// Closing the API client leaves the parent open
+	api.Close()
+	if c.IsClosed() {
+		t.Error("petstore.Close() closed the parent client")
+	}
+
+	c.Close()
+	if _, err := petstore.New(&c, petstore.Security{}); !errors.Is(err, client.ErrClientClosed) {
+		t.Errorf("petstore.New() error = %v, want %v", err, client.ErrClientClosed)
+	}
+
+	if _, err := api.GetHealth(); !errors.Is(err, client.ErrClientClosed) {
+		t.Errorf("GetHealth() error = %v, want %v", err, client.ErrClientClosed)
+	}
+}
@@ -0,0 +1,175 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package openapi
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// initialisms are the words kept in upper case in the Go identifiers.
+var initialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true,
+	"DNS": true, "EOF": true, "GUID": true, "HTML": true, "HTTP": true,
+	"HTTPS": true, "ID": true, "IP": true, "JSON": true, "JWT": true,
+	"OS": true, "RAM": true, "RPC": true, "SLA": true, "SQL": true,
+	"SSH": true, "TCP": true, "TLS": true, "TTL": true, "UDP": true,
+	"UI": true, "UID": true, "URI": true, "URL": true, "UTF8": true,
+	"UUID": true, "VM": true, "XML": true,
+}
+
+// goName converts a name of the document, such as "pet_id", "petId" or
+// "X-Request-ID", into an exported Go identifier, "PetID" or "XRequestID".
+func goName(name string) string {
+	var b strings.Builder
+	for _, word := range splitWords(name) {
+		upper := strings.ToUpper(word)
+		if initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		// The plural of an initialism, such as "ids"
+		if singular, found := strings.CutSuffix(upper, "S"); found && initialisms[singular] {
+			b.WriteString(singular + "s")
+			continue
+		}
+
+		runes := []rune(word)
+		b.WriteRune(unicode.ToUpper(runes[0]))
+		b.WriteString(string(runes[1:]))
+	}
+
+	identifier := b.String()
+	if identifier == "" {
+		return "X"
+	}
+	if unicode.IsDigit([]rune(identifier)[0]) {
+		return "N" + identifier
+	}
+
+	return identifier
+}
+
+// splitWords splits a name on the non-alphanumeric characters and on the
+// lower to upper case transitions.
+func splitWords(name string) []string {
+	var words []string
+	var word []rune
+
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+	}
+
+	for _, r := range name {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && len(word) > 0 &&
+			!unicode.IsUpper(word[len(word)-1]):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+
+	return words
+}
+
+// templateVar converts a parameter name into an RFC 6570 variable name,
+// the characters other than ASCII letters, digits and '_' are replaced.
+func templateVar(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) || r == '_' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// names reserves the identifiers of a scope, such as the package or the
+// fields of a struct.
+type names map[string]bool
+
+// unique reserves and returns `name`, suffixed with a number if it is
+// already reserved.
+func (n names) unique(name string) string {
+	candidate := name
+	for i := 2; n[candidate]; i++ {
+		candidate = name + strconv.Itoa(i)
+	}
+	n[candidate] = true
+
+	return candidate
+}
+
+// commentWidth is the width of the comment lines, without the "// " prefix.
+const commentWidth = 74
+
+// comment formats `text` as a Go comment, each line prefixed by "// ". The
+// long lines are wrapped, except the indented ones such as the lists.
+func comment(text string) string {
+	var b strings.Builder
+	for line := range strings.SplitSeq(strings.TrimSpace(text), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			b.WriteString("//\n")
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			b.WriteString("// " + line + "\n")
+			continue
+		}
+
+		width := 0
+		for i, word := range strings.Fields(line) {
+			switch {
+			case i == 0:
+				b.WriteString("// " + word)
+			case width+1+len(word) > commentWidth:
+				b.WriteString("\n// " + word)
+				width = 0
+			default:
+				b.WriteString(" " + word)
+				width++
+			}
+			width += len(word)
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// sentence returns `text` ending with a punctuation mark, so a short
+// summary is not formatted as a heading by gofmt.
+func sentence(text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" || strings.ContainsAny(text[len(text)-1:], ".!?:") {
+		return text
+	}
+
+	return text + "."
+}
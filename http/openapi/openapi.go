@@ -0,0 +1,347 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+// Package openapi generates typed Go clients on top of the http/client
+// package from OpenAPI 3.x documents.
+//
+// Each operation becomes a method of the generated client, with a request
+// struct holding its parameters and body, a response struct implementing
+// client.Unmarshaler and an error type returned on the unexpected status
+// codes. The request structs sending a body implement client.Marshaler.
+// The security schemes are wired to the auth package authenticators.
+//
+// The documents are read in JSON with Parse or in YAML with ParseYAML.
+// The generator is available as a command:
+//
+//	go run gitlab.com/iglou.eu/goulc/cmd/goulc-openapi \
+//	    -package petstore -o petstore.go petstore.json
+//
+// OpenAPI 3.1: https://spec.openapis.org/oas/v3.1.0
+package openapi
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrInvalidDocument is returned when the document cannot be decoded
+	ErrInvalidDocument = errors.New("invalid OpenAPI document")
+	// ErrUnsupportedVersion is returned when the document is not OpenAPI 3.x
+	ErrUnsupportedVersion = errors.New("unsupported OpenAPI version")
+	// ErrInvalidReference is returned when a $ref cannot be resolved, only
+	// the local "#/components/..." references are supported
+	ErrInvalidReference = errors.New("invalid OpenAPI reference")
+)
+
+// Document is the root object of an OpenAPI document, limited to the
+// fields used by the generator.
+type Document struct {
+	OpenAPI    string                `json:"openapi"`
+	Info       Info                  `json:"info"`
+	Servers    []Server              `json:"servers"`
+	Paths      map[string]*PathItem  `json:"paths"`
+	Components Components            `json:"components"`
+	Security   []SecurityRequirement `json:"security"`
+}
+
+// Info holds the metadata of the API.
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+// Server is a server hosting the API.
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description"`
+}
+
+// PathItem holds the operations available on a path.
+type PathItem struct {
+	Parameters []*Parameter `json:"parameters"`
+
+	Get     *Operation `json:"get"`
+	Put     *Operation `json:"put"`
+	Post    *Operation `json:"post"`
+	Delete  *Operation `json:"delete"`
+	Options *Operation `json:"options"`
+	Head    *Operation `json:"head"`
+	Patch   *Operation `json:"patch"`
+	Trace   *Operation `json:"trace"`
+}
+
+// Operation describes a single API operation on a path.
+type Operation struct {
+	OperationID string               `json:"operationId"`
+	Summary     string               `json:"summary"`
+	Description string               `json:"description"`
+	Deprecated  bool                 `json:"deprecated"`
+	Parameters  []*Parameter         `json:"parameters"`
+	RequestBody *RequestBody         `json:"requestBody"`
+	Responses   map[string]*Response `json:"responses"`
+
+	// Security overrides the document requirements when not nil, an empty
+	// list removes them
+	Security *[]SecurityRequirement `json:"security"`
+}
+
+// Parameter describes a path, query, header or cookie parameter.
+type Parameter struct {
+	Ref         string  `json:"$ref"`
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description"`
+	Required    bool    `json:"required"`
+	Deprecated  bool    `json:"deprecated"`
+	Style       string  `json:"style"`
+	Explode     *bool   `json:"explode"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes the body of a request.
+type RequestBody struct {
+	Ref         string                `json:"$ref"`
+	Description string                `json:"description"`
+	Required    bool                  `json:"required"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+// Response describes a response of an operation.
+type Response struct {
+	Ref         string                `json:"$ref"`
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+// MediaType holds the schema of a content type.
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is the subset of the JSON Schema used to generate the Go types.
+type Schema struct {
+	Ref         string `json:"$ref"`
+	Type        Types  `json:"type"`
+	Format      string `json:"format"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Nullable    bool   `json:"nullable"`
+	Deprecated  bool   `json:"deprecated"`
+	Enum        []any  `json:"enum"`
+
+	Properties           map[string]*Schema   `json:"properties"`
+	Required             []string             `json:"required"`
+	AdditionalProperties AdditionalProperties `json:"additionalProperties"`
+	Items                *Schema              `json:"items"`
+
+	AllOf []*Schema `json:"allOf"`
+	OneOf []*Schema `json:"oneOf"`
+	AnyOf []*Schema `json:"anyOf"`
+}
+
+// Types is the schema type, a single name in OpenAPI 3.0 or a list in
+// OpenAPI 3.1, such as ["string", "null"].
+type Types []string
+
+// UnmarshalJSON decodes a type name or a list of type names.
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*t = Types{name}
+		return nil
+	}
+
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return err
+	}
+	*t = names
+
+	return nil
+}
+
+// Is reports if the type is `name`, or if the list contains it.
+func (t Types) Is(name string) bool {
+	for _, n := range t {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Name returns the type name, other than "null".
+func (t Types) Name() string {
+	for _, n := range t {
+		if n != "null" {
+			return n
+		}
+	}
+
+	return ""
+}
+
+// AdditionalProperties is either a boolean or a schema, Allowed reports
+// if the additional properties are allowed.
+type AdditionalProperties struct {
+	Allowed bool
+	Schema  *Schema
+}
+
+// UnmarshalJSON decodes a boolean or a schema.
+func (a *AdditionalProperties) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.Allowed); err == nil {
+		return nil
+	}
+
+	a.Allowed = true
+	return json.Unmarshal(data, &a.Schema)
+}
+
+// Components holds the reusable objects of the document.
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	Parameters      map[string]*Parameter      `json:"parameters"`
+	RequestBodies   map[string]*RequestBody    `json:"requestBodies"`
+	Responses       map[string]*Response       `json:"responses"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes"`
+}
+
+// SecurityScheme describes a security scheme of the API.
+type SecurityScheme struct {
+	Type         string      `json:"type"`
+	Description  string      `json:"description"`
+	Name         string      `json:"name"`
+	In           string      `json:"in"`
+	Scheme       string      `json:"scheme"`
+	BearerFormat string      `json:"bearerFormat"`
+	Flows        *OAuthFlows `json:"flows"`
+}
+
+// OAuthFlows holds the OAuth2 flows of a security scheme, only the client
+// credentials one can be built by the generated code.
+type OAuthFlows struct {
+	ClientCredentials *OAuthFlow `json:"clientCredentials"`
+	AuthorizationCode *OAuthFlow `json:"authorizationCode"`
+	Implicit          *OAuthFlow `json:"implicit"`
+	Password          *OAuthFlow `json:"password"`
+}
+
+// OAuthFlow describes an OAuth2 flow.
+type OAuthFlow struct {
+	TokenURL string            `json:"tokenUrl"`
+	Scopes   map[string]string `json:"scopes"`
+}
+
+// SecurityRequirement maps the security schemes required together to their
+// scopes.
+type SecurityRequirement map[string][]string
+
+// Parse decodes an OpenAPI 3.x document in JSON.
+func Parse(data []byte) (*Document, error) {
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, errors.Join(ErrInvalidDocument, err)
+	}
+
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, errors.Join(ErrUnsupportedVersion,
+			errors.New("openapi version "+doc.OpenAPI+", want 3.x"))
+	}
+
+	return &doc, nil
+}
+
+// Operations returns the operations of the path item with their method.
+func (p *PathItem) Operations() map[string]*Operation {
+	operations := make(map[string]*Operation)
+	for method, op := range map[string]*Operation{
+		"GET": p.Get, "PUT": p.Put, "POST": p.Post, "DELETE": p.Delete,
+		"OPTIONS": p.Options, "HEAD": p.Head, "PATCH": p.Patch, "TRACE": p.Trace,
+	} {
+		if op != nil {
+			operations[method] = op
+		}
+	}
+
+	return operations
+}
+
+// componentName returns the name of the local reference to a component of
+// the given kind, such as "schemas".
+func componentName(ref, kind string) (string, error) {
+	name, found := strings.CutPrefix(ref, "#/components/"+kind+"/")
+	if !found || name == "" || strings.Contains(name, "/") {
+		return "", errors.Join(ErrInvalidReference,
+			errors.New("unsupported reference "+ref+", want #/components/"+kind+"/<name>"))
+	}
+
+	// JSON Pointer escaping, RFC 6901 §4
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(name), nil
+}
+
+// maxReferences bounds the chain of references, to detect the cycles.
+const maxReferences = 32
+
+// resolve follows the local references of `v` to the components of the
+// given kind, such as "parameters".
+func resolve[T any](
+	v *T, ref func(*T) string, kind string, components map[string]*T,
+) (*T, error) {
+	for range maxReferences {
+		r := ref(v)
+		if r == "" {
+			return v, nil
+		}
+
+		name, err := componentName(r, kind)
+		if err != nil {
+			return nil, err
+		}
+
+		var found bool
+		if v, found = components[name]; !found || v == nil {
+			return nil, errors.Join(ErrInvalidReference, errors.New("unknown component "+r))
+		}
+	}
+
+	return nil, errors.Join(ErrInvalidReference, errors.New("reference cycle in "+kind))
+}
+
+// parameter resolves a parameter reference.
+func (d *Document) parameter(p *Parameter) (*Parameter, error) {
+	return resolve(p, func(p *Parameter) string { return p.Ref },
+		"parameters", d.Components.Parameters)
+}
+
+// requestBody resolves a request body reference.
+func (d *Document) requestBody(b *RequestBody) (*RequestBody, error) {
+	return resolve(b, func(b *RequestBody) string { return b.Ref },
+		"requestBodies", d.Components.RequestBodies)
+}
+
+// response resolves a response reference.
+func (d *Document) response(r *Response) (*Response, error) {
+	return resolve(r, func(r *Response) string { return r.Ref },
+		"responses", d.Components.Responses)
+}
@@ -0,0 +1,162 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+package openapi_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"gitlab.com/iglou.eu/goulc/http/openapi"
+)
+
+func TestParse(t *testing.T) {
+	doc, err := openapi.Parse([]byte(`{
+		"openapi": "3.1.0",
+		"info": {"title": "Candlekeep", "version": "1"},
+		"components": {"schemas": {
+			"Book": {
+				"type": ["object", "null"],
+				"additionalProperties": false,
+				"properties": {"tags": {"type": "object", "additionalProperties": {"type": "string"}}}
+			}
+		}}
+	}`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	book := doc.Components.Schemas["Book"]
+	if book.Type.Name() != "object" || !book.Type.Is("null") || book.AdditionalProperties.Allowed {
+		t.Errorf("Parse() Book = %+v", book)
+	}
+	if tags := book.Properties["tags"]; !tags.AdditionalProperties.Allowed ||
+		tags.AdditionalProperties.Schema.Type.Name() != "string" {
+		t.Errorf("Parse() tags = %+v", tags)
+	}
+
+	tests := []struct {
+		name      string
+		doc       string
+		wantErrIs error
+	}{
+		{name: "swagger 2", doc: `{"swagger": "2.0"}`, wantErrIs: openapi.ErrUnsupportedVersion},
+		{name: "yaml", doc: "openapi: 3.0.0", wantErrIs: openapi.ErrInvalidDocument},
+		{name: "invalid type", doc: `{"openapi": "3.0.0", "components": {"schemas": {"A": {"type": 1}}}}`,
+			wantErrIs: openapi.ErrInvalidDocument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := openapi.Parse([]byte(tt.doc)); !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("Parse() error = %v, want %v", err, tt.wantErrIs)
+			}
+		})
+	}
+}
+
+func TestGoName(t *testing.T) {
+	for name, want := range map[string]string{
+		"petId":        "PetID",
+		"pet_id":       "PetID",
+		"X-Request-ID": "XRequestID",
+		"api_key":      "APIKey",
+		"ids":          "IDs",
+		"listPets":     "ListPets",
+		"HTTPServer":   "HTTPServer",
+		"2fa":          "N2fa",
+		"get /pets":    "GetPets",
+		"---":          "X",
+	} {
+		if got := openapi.GoName(name); got != want {
+			t.Errorf("goName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestComment(t *testing.T) {
+	got := openapi.Comment("Short line.\n\nA long description that goes on and on " +
+		"until it does not fit in a single line of the comment anymore.\n  - an indented item")
+	want := "// Short line.\n//\n// A long description that goes on and on until it does not fit in a single\n" +
+		"// line of the comment anymore.\n//   - an indented item\n"
+	if got != want {
+		t.Errorf("comment() = %q, want %q", got, want)
+	}
+}
+
+func TestParseYAML(t *testing.T) {
+	doc, err := openapi.ParseYAML([]byte(`
+openapi: 3.0.3
+info: {title: Candlekeep, version: "1.0"}
+paths:
+  /books:
+    get:
+      operationId: listBooks
+      responses:
+        200: &books
+          description: The books
+          content:
+            application/json:
+              schema: {type: array, items: {$ref: "#/components/schemas/Book"}}
+        206:
+          <<: *books
+          description: Some books
+components:
+  schemas:
+    Book:
+      type: object
+      required: [edition]
+      properties:
+        edition: {type: [string, integer], enum: [2025-01-01, 3, null]}
+`))
+	if err != nil {
+		t.Fatalf("ParseYAML() error = %v", err)
+	}
+
+	responses := doc.Paths["/books"].Get.Responses
+	if responses["200"] == nil || responses["206"] == nil ||
+		responses["206"].Description != "Some books" ||
+		responses["206"].Content["application/json"] == nil {
+		t.Errorf("ParseYAML() responses = %+v", responses)
+	}
+	// The timestamps are kept as text, the numbers are decoded
+	edition := doc.Components.Schemas["Book"].Properties["edition"]
+	if !reflect.DeepEqual(edition.Enum, []any{"2025-01-01", float64(3), nil}) {
+		t.Errorf("ParseYAML() enum = %#v", edition.Enum)
+	}
+
+	tests := []struct {
+		name      string
+		doc       string
+		wantErrIs error
+	}{
+		{name: "empty", doc: "", wantErrIs: openapi.ErrInvalidDocument},
+		{name: "invalid", doc: "openapi: [3.0.0", wantErrIs: openapi.ErrInvalidDocument},
+		{name: "duplicate key", doc: "openapi: 3.0.0\nopenapi: 3.1.0", wantErrIs: openapi.ErrInvalidDocument},
+		{name: "swagger 2", doc: "swagger: \"2.0\"", wantErrIs: openapi.ErrUnsupportedVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := openapi.ParseYAML([]byte(tt.doc)); !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("ParseYAML() error = %v, want %v", err, tt.wantErrIs)
+			}
+		})
+	}
+}
@@ -0,0 +1,946 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package openapi
+
+import (
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// operation is an operation of the document with its generated names.
+type operation struct {
+	*Operation
+
+	path   string
+	method string
+	item   *PathItem
+
+	name     string
+	request  string
+	response string
+	error    string
+}
+
+// parameter is a parameter with its request struct field.
+type parameter struct {
+	*Parameter
+
+	field string
+	// base is the Go type of the value, without the optional pointer
+	base string
+	// pointer is set when the field is a pointer to the base type
+	pointer bool
+}
+
+// body is the request body with its request struct field type.
+type body struct {
+	contentType string
+	media       string
+	goType      string
+	optional    bool
+}
+
+// response is a documented response with its struct field, if it has a
+// body.
+type response struct {
+	code        string
+	description string
+	condition   string
+
+	field       string
+	media       string
+	goType      string
+	contentType string
+}
+
+// generateOperations generates the method of each operation, with its
+// request, response and error types.
+func (g *generator) generateOperations() error {
+	var operations []*operation
+	for _, path := range slices.Sorted(maps.Keys(g.doc.Paths)) {
+		item := g.doc.Paths[path]
+		if item == nil {
+			continue
+		}
+
+		byMethod := item.Operations()
+		for _, method := range methods {
+			if op := byMethod[method]; op != nil {
+				operations = append(operations, &operation{
+					Operation: op, path: path, method: method, item: item,
+				})
+			}
+		}
+	}
+
+	methodNames := names{"Close": true}
+	for _, o := range operations {
+		name := o.OperationID
+		if name == "" {
+			name = strings.ToLower(o.method) + " " +
+				strings.NewReplacer("{", " by ", "}", " ").Replace(o.path)
+		}
+
+		o.name = methodNames.unique(goName(name))
+		o.request = g.names.unique(o.name + "Request")
+		o.response = g.names.unique(o.name + "Response")
+		o.error = g.names.unique(o.name + "Error")
+	}
+
+	for _, o := range operations {
+		if err := g.generateOperation(o); err != nil {
+			return errors.Join(
+				errors.New("operation "+o.method+" "+o.path), err)
+		}
+	}
+
+	return nil
+}
+
+// generateOperation generates the method of the operation and its types,
+// followed by the types of its inline schemas.
+func (g *generator) generateOperation(o *operation) error {
+	g.imports["net/http"] = true
+	g.imports["strconv"] = true
+
+	slot := len(g.decls)
+	g.decls = append(g.decls, "", "", "", "")
+
+	fields := make(names)
+	reqBody, err := g.requestBody(o)
+	if err != nil {
+		return err
+	}
+	if reqBody != nil {
+		// The Marshaler methods and the body are reserved first
+		fields["Body"], fields["Name"] = true, true
+		fields["ContentType"], fields["Marshal"] = true, true
+	}
+
+	params, skipped, err := g.parameters(o, fields)
+	if err != nil {
+		return err
+	}
+
+	responses, err := g.responses(o)
+	if err != nil {
+		return err
+	}
+
+	hasRequest := reqBody != nil || len(params) > 0
+	g.decls[slot] = g.methodDecl(o, params, skipped, reqBody, responses, hasRequest)
+	if hasRequest {
+		g.decls[slot+1] = g.requestDecl(o, params, reqBody)
+	}
+	g.decls[slot+2] = g.responseDecl(o, responses)
+	g.decls[slot+3] = g.errorDecl(o, responses)
+
+	return nil
+}
+
+// parameters returns the parameters of the operation, those of the path
+// item are overridden by the operation ones. The cookie parameters are
+// skipped and their names returned.
+func (g *generator) parameters(
+	o *operation, fields names,
+) ([]parameter, []string, error) {
+	var resolved []*Parameter
+	index := make(map[string]int)
+
+	for _, p := range append(slices.Clone(o.item.Parameters), o.Parameters...) {
+		if p == nil {
+			continue
+		}
+
+		p, err := g.doc.parameter(p)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		key := p.In + " " + p.Name
+		if i, found := index[key]; found {
+			resolved[i] = p
+			continue
+		}
+		index[key] = len(resolved)
+		resolved = append(resolved, p)
+	}
+
+	var params []parameter
+	var skipped []string
+	for _, p := range resolved {
+		switch p.In {
+		case "path", "query", "header":
+		case "cookie":
+			skipped = append(skipped, p.Name)
+			continue
+		default:
+			return nil, nil, errors.Join(ErrInvalidDocument,
+				errors.New("unknown location "+p.In+" of the parameter "+p.Name))
+		}
+
+		field := fields.unique(goName(p.Name))
+		base, err := g.goType(p.Schema, o.request+field,
+			o.request+field+" is the "+p.Name+" parameter of "+o.name+".")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		params = append(params, parameter{
+			Parameter: p,
+			field:     field,
+			base:      base,
+			pointer:   p.In != "path" && !p.Required && !g.nillable(base),
+		})
+	}
+
+	return params, skipped, nil
+}
+
+// requestBody returns the request body of the operation, if any.
+func (g *generator) requestBody(o *operation) (*body, error) {
+	if o.RequestBody == nil {
+		return nil, nil
+	}
+
+	rb, err := g.doc.requestBody(o.RequestBody)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType, media := selectMedia(rb.Content)
+	if media == nil {
+		return nil, nil
+	}
+
+	b := &body{contentType: contentType, optional: !rb.Required}
+	switch {
+	case isJSON(contentType):
+		b.media = "json"
+		g.imports["encoding/json"] = true
+		if b.goType, err = g.goType(media.Schema, o.name+"Body",
+			o.name+"Body is the request body of "+o.name+"."); err != nil {
+			return nil, err
+		}
+		if (b.optional || isNullable(media.Schema)) && !g.nillable(b.goType) {
+			b.goType = "*" + b.goType
+		}
+
+	case contentType == "application/x-www-form-urlencoded":
+		b.media = "form"
+		b.goType = "url.Values"
+		g.imports["net/url"] = true
+
+	default:
+		b.media = "raw"
+		b.goType = "[]byte"
+	}
+
+	return b, nil
+}
+
+// responses returns the documented responses of the operation, the explicit
+// status codes first, then the ranges and the default one.
+func (g *generator) responses(o *operation) ([]response, error) {
+	codes := slices.SortedFunc(maps.Keys(o.Responses), func(a, b string) int {
+		rank := func(code string) int {
+			switch {
+			case code == "default":
+				return 2
+			case strings.HasSuffix(strings.ToUpper(code), "XX"):
+				return 1
+			}
+			return 0
+		}
+		if ra, rb := rank(a), rank(b); ra != rb {
+			return ra - rb
+		}
+		return strings.Compare(a, b)
+	})
+
+	success, failure := make(names), names{"StatusCode": true, "Header": true, "Body": true}
+	var responses []response
+	for _, code := range codes {
+		if o.Responses[code] == nil {
+			continue
+		}
+		resp, err := g.doc.response(o.Responses[code])
+		if err != nil {
+			return nil, err
+		}
+
+		r := response{code: code, description: resp.Description}
+		var field string
+		switch upper := strings.ToUpper(code); {
+		case code == "default":
+			field = "Default"
+		case len(code) == 3 && upper[1:] == "XX" && upper[0] >= '1' && upper[0] <= '5':
+			field = "Status" + upper
+			r.condition = "resp.StatusCode/100 == " + code[:1]
+		default:
+			n, err := strconv.Atoi(code)
+			if err != nil || n < 100 || n > 599 {
+				return nil, errors.Join(ErrInvalidDocument,
+					errors.New("invalid response status code "+code))
+			}
+			field = "Status" + code
+			if text := http.StatusText(n); text != "" {
+				field = goName(text)
+			}
+			r.condition = "resp.StatusCode == " + code
+		}
+
+		contentType, media := selectMedia(resp.Content)
+		if media != nil {
+			if r.isSuccess() {
+				r.field = success.unique(field)
+			} else {
+				r.field = failure.unique(field)
+			}
+			r.contentType = contentType
+
+			if isJSON(contentType) {
+				r.media = "json"
+				g.imports["encoding/json"] = true
+
+				hint := o.name + r.field
+				if !r.isSuccess() {
+					hint = o.error + r.field
+				}
+				t, err := g.goType(media.Schema, hint,
+					hint+" is the "+code+" response body of "+o.name+".")
+				if err != nil {
+					return nil, err
+				}
+				if !g.nillable(t) {
+					t = "*" + t
+				}
+				r.goType = t
+			} else {
+				r.media = "raw"
+				r.goType = "[]byte"
+			}
+		}
+
+		responses = append(responses, r)
+	}
+
+	return responses, nil
+}
+
+// isSuccess reports if the response status code is 2xx.
+func (r response) isSuccess() bool {
+	return r.code != "default" && r.code[0] == '2'
+}
+
+// methodDecl returns the method of the operation.
+func (g *generator) methodDecl(
+	o *operation, params []parameter, skipped []string,
+	reqBody *body, responses []response, hasRequest bool,
+) string {
+	var b strings.Builder
+
+	doc := o.name + " sends " + o.method + " " + o.path + "."
+	if o.Summary != "" {
+		doc += "\n\n" + sentence(o.Summary)
+	}
+	if o.Description != "" {
+		doc += "\n\n" + o.Description
+	}
+	if len(skipped) > 0 {
+		doc += "\n\nThe cookie parameters " + strings.Join(skipped, ", ") +
+			" are not supported."
+	}
+	security, authCode := g.operationSecurity(o)
+	if security != "" {
+		doc += "\n\nSecurity: " + security + "."
+	}
+	if o.Deprecated {
+		doc += "\n\nDeprecated: the operation is deprecated by the API."
+	}
+	b.WriteString(comment(doc))
+
+	if hasRequest {
+		b.WriteString("func (api *" + g.cfg.TypeName + ") " + o.name +
+			"(req *" + o.request + ") (*" + o.response + ", error) {\n")
+		b.WriteString("\tif req == nil {\n\t\treq = &" + o.request + "{}\n\t}\n\n")
+	} else {
+		b.WriteString("func (api *" + g.cfg.TypeName + ") " + o.name +
+			"() (*" + o.response + ", error) {\n")
+	}
+
+	b.WriteString(g.pathCode(o, params))
+	for _, p := range params {
+		switch p.In {
+		case "query":
+			b.WriteString(g.queryCode(p))
+		case "header":
+			b.WriteString(g.headerCode(p))
+		}
+	}
+
+	if reqBody != nil {
+		if reqBody.optional || strings.HasPrefix(reqBody.goType, "*") {
+			b.WriteString("\tif req.Body != nil {\n\t\tr = r.Marshal(req)\n\t}\n")
+		} else {
+			b.WriteString("\tr = r.Marshal(req)\n")
+		}
+	}
+	b.WriteString(authCode)
+
+	method := "http.Method" + o.method[:1] + strings.ToLower(o.method[1:])
+	b.WriteString("\n\tout := &" + o.response + "{}\n")
+	b.WriteString("\tresp, err := r.Do(" + method + ", out)\n")
+	b.WriteString("\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	b.WriteString("\tout.StatusCode, out.Header = resp.StatusCode, resp.Header\n\n")
+
+	var conditions []string
+	for _, r := range responses {
+		if r.isSuccess() {
+			conditions = append(conditions, r.condition)
+		}
+	}
+	if len(conditions) == 0 {
+		conditions = []string{"resp.StatusCode/100 == 2"}
+	}
+	b.WriteString("\tif " + strings.Join(conditions, " || ") +
+		" {\n\t\treturn out, nil\n\t}\n\n")
+	b.WriteString("\treturn nil, new" + o.error + "(resp)\n}\n")
+
+	return b.String()
+}
+
+// pathCode returns the creation of the request builder `r` with the path
+// of the operation, expanded as an URI template.
+func (g *generator) pathCode(o *operation, params []parameter) string {
+	if !strings.Contains(o.path, "{") {
+		if o.path == "" || o.path == "/" {
+			return "\tr := api.client.R()\n"
+		}
+		return "\tr := api.client.R().Path(" + strconv.Quote(o.path) + ")\n"
+	}
+
+	// The parameter names are not always valid template variables
+	var vars strings.Builder
+	template := o.path
+	for _, p := range params {
+		if p.In != "path" {
+			continue
+		}
+
+		name := templateVar(p.Name)
+		template = strings.ReplaceAll(template, "{"+p.Name+"}", "{"+name+"}")
+
+		value := "req." + p.field
+		if !strings.HasPrefix(p.base, "[]") && !strings.HasPrefix(p.base, "map[") {
+			value = g.format(p.base, value)
+		}
+		vars.WriteString("\t\t" + strconv.Quote(name) + ": " + value + ",\n")
+	}
+
+	if vars.Len() == 0 {
+		return "\tr := api.client.R().Template(" + strconv.Quote(template) + ", nil)\n"
+	}
+
+	return "\tr := api.client.R().Template(" + strconv.Quote(template) +
+		", map[string]any{\n" + vars.String() + "\t})\n"
+}
+
+// queryCode returns the code adding the query parameter to `r`.
+func (g *generator) queryCode(p parameter) string {
+	name := strconv.Quote(p.Name)
+	field := "req." + p.field
+	explode := p.Explode == nil || *p.Explode
+
+	switch {
+	case strings.HasPrefix(p.base, "map[string]"):
+		value := g.format(strings.TrimPrefix(p.base, "map[string]"), "v")
+		key := "k"
+		if p.Style == "deepObject" {
+			key = name + `+"["+k+"]"`
+		}
+		return "\tfor k, v := range " + field + " {\n\t\tr = r.Query(" +
+			key + ", " + value + ")\n\t}\n"
+
+	case strings.HasPrefix(p.base, "[]") && p.base != "[]byte" && explode &&
+		(p.Style == "" || p.Style == "form"):
+		value := g.format(strings.TrimPrefix(p.base, "[]"), "v")
+		return "\tfor _, v := range " + field + " {\n\t\tr = r.Query(" +
+			name + ", " + value + ")\n\t}\n"
+
+	case strings.HasPrefix(p.base, "[]") && p.base != "[]byte":
+		separator := ","
+		switch p.Style {
+		case "spaceDelimited":
+			separator = " "
+		case "pipeDelimited":
+			separator = "|"
+		}
+		return g.joinCode(p, "Query", separator)
+	}
+
+	return g.scalarCode(p, "Query")
+}
+
+// headerCode returns the code setting the header parameter on `r`.
+func (g *generator) headerCode(p parameter) string {
+	if strings.HasPrefix(p.base, "[]") && p.base != "[]byte" {
+		return g.joinCode(p, "Header", ",")
+	}
+
+	return g.scalarCode(p, "Header")
+}
+
+// joinCode returns the code sending the list parameter as a single value,
+// with the `method` of the request builder.
+func (g *generator) joinCode(p parameter, method, separator string) string {
+	g.imports["strings"] = true
+
+	name := strconv.Quote(p.Name)
+	field := "req." + p.field
+	item := strings.TrimPrefix(p.base, "[]")
+	if item == "string" {
+		return "\tif len(" + field + ") > 0 {\n\t\tr = r." + method + "(" + name +
+			", strings.Join(" + field + ", " + strconv.Quote(separator) + "))\n\t}\n"
+	}
+
+	return "\tif len(" + field + ") > 0 {\n" +
+		"\t\tvalues := make([]string, len(" + field + "))\n" +
+		"\t\tfor i, v := range " + field + " {\n" +
+		"\t\t\tvalues[i] = " + g.format(item, "v") + "\n\t\t}\n" +
+		"\t\tr = r." + method + "(" + name + ", strings.Join(values, " +
+		strconv.Quote(separator) + "))\n\t}\n"
+}
+
+// scalarCode returns the code sending the scalar parameter, with the
+// `method` of the request builder.
+func (g *generator) scalarCode(p parameter, method string) string {
+	name := strconv.Quote(p.Name)
+	field := "req." + p.field
+
+	switch {
+	case p.pointer:
+		return "\tif " + field + " != nil {\n\t\tr = r." + method + "(" + name +
+			", " + g.format(p.base, "*"+field) + ")\n\t}\n"
+	case g.nillable(p.base):
+		return "\tif " + field + " != nil {\n\t\tr = r." + method + "(" + name +
+			", " + g.format(p.base, field) + ")\n\t}\n"
+	}
+
+	return "\tr = r." + method + "(" + name + ", " + g.format(p.base, field) + ")\n"
+}
+
+// format returns the expression formatting the value `expr` of type `t`
+// as a parameter string.
+func (g *generator) format(t, expr string) string {
+	switch {
+	case t == "string":
+		return expr
+	case g.enums[t], t == "[]byte", t == "json.RawMessage":
+		return "string(" + expr + ")"
+	case t == "bool":
+		return "strconv.FormatBool(" + expr + ")"
+	case t == "int64":
+		return "strconv.FormatInt(" + expr + ", 10)"
+	case t == "int32":
+		return "strconv.FormatInt(int64(" + expr + "), 10)"
+	case t == "float64":
+		return "strconv.FormatFloat(" + expr + ", 'g', -1, 64)"
+	case t == "float32":
+		return "strconv.FormatFloat(float64(" + expr + "), 'g', -1, 32)"
+	case t == "time.Time":
+		if strings.HasPrefix(expr, "*") {
+			expr = "(" + expr + ")"
+		}
+		return expr + ".Format(time.RFC3339)"
+	}
+
+	g.imports["fmt"] = true
+	return "fmt.Sprint(" + expr + ")"
+}
+
+// operationSecurity returns the description of the security requirements
+// of the operation and the code setting the authenticator of the first
+// supported one.
+func (g *generator) operationSecurity(o *operation) (string, string) {
+	requirements := g.doc.Security
+	if o.Security != nil {
+		requirements = *o.Security
+	}
+
+	var alternatives, cases []string
+	anonymous := false
+	for _, requirement := range requirements {
+		var schemes []string
+		for _, name := range slices.Sorted(maps.Keys(requirement)) {
+			scheme := name
+			if scopes := requirement[name]; len(scopes) > 0 {
+				scheme += " (" + strings.Join(scopes, ", ") + ")"
+			}
+			schemes = append(schemes, scheme)
+		}
+
+		switch len(requirement) {
+		case 0:
+			alternatives = append(alternatives, "none")
+			anonymous = true
+		case 1:
+			alternatives = append(alternatives, schemes[0])
+			for name := range requirement {
+				if field, found := g.security[name]; found && !anonymous {
+					cases = append(cases, field)
+				}
+			}
+		default:
+			// A single authenticator is sent per request
+			alternatives = append(alternatives, strings.Join(schemes, " and ")+
+				" (not supported)")
+		}
+	}
+
+	if len(cases) == 0 {
+		return strings.Join(alternatives, " or "), ""
+	}
+
+	var branches []branch
+	for _, field := range slices.Compact(cases) {
+		branches = append(branches, branch{
+			cond: "api.security." + field + " != nil",
+			body: "\t\tr = r.Auth(api.security." + field + ")\n",
+		})
+	}
+
+	return strings.Join(alternatives, " or "), switchCode(branches)
+}
+
+// requestDecl returns the request struct of the operation, implementing
+// client.Marshaler when it has a body.
+func (g *generator) requestDecl(
+	o *operation, params []parameter, reqBody *body,
+) string {
+	var b strings.Builder
+
+	doc := o.request + " holds the parameters of " + o.name + "."
+	if reqBody != nil {
+		doc = o.request + " holds the parameters and the body of " +
+			o.name + ", it is marshaled as the request body."
+	}
+	b.WriteString(comment(doc) + "type " + o.request + " struct {\n")
+
+	for _, p := range params {
+		doc := p.field + " is the " + p.Name + " " + p.In + " parameter"
+		if p.Required {
+			doc += ", required"
+		}
+		doc += "."
+		if p.Description != "" {
+			doc += "\n" + p.Description
+		}
+		if p.Deprecated {
+			doc += "\n\nDeprecated: the parameter is deprecated by the API."
+		}
+
+		t := p.base
+		if p.pointer {
+			t = "*" + t
+		}
+		b.WriteString(indent(comment(doc)) + "\t" + p.field + " " + t + "\n")
+	}
+
+	if reqBody == nil {
+		b.WriteString("}\n")
+		return b.String()
+	}
+
+	if len(params) > 0 {
+		b.WriteString("\n")
+	}
+	b.WriteString("\t// Body is the request body, sent as " + reqBody.contentType + "\n")
+	b.WriteString("\tBody " + reqBody.goType + "\n}\n")
+
+	marshal := "json.Marshal(r.Body)"
+	switch reqBody.media {
+	case "form":
+		marshal = "[]byte(r.Body.Encode()), nil"
+	case "raw":
+		marshal = "r.Body, nil"
+	}
+
+	b.WriteString(`
+// Verify ` + o.request + ` implements client.Marshaler interface
+var _ client.Marshaler = &` + o.request + `{}
+
+// Name returns the identifier of the request body.
+func (_ *` + o.request + `) Name() string {
+	return ` + strconv.Quote(g.cfg.Package+"."+o.request) + `
+}
+
+// ContentType returns the content type of the request body.
+func (_ *` + o.request + `) ContentType() string {
+	return ` + strconv.Quote(reqBody.contentType) + `
+}
+
+// Marshal serializes the request body.
+func (r *` + o.request + `) Marshal() ([]byte, error) {
+	return ` + marshal + `
+}
+`)
+
+	return b.String()
+}
+
+// responseDecl returns the response struct of the operation, implementing
+// client.Unmarshaler for the successful responses.
+func (g *generator) responseDecl(o *operation, responses []response) string {
+	var (
+		b     strings.Builder
+		cases []branch
+	)
+
+	b.WriteString(comment(o.response+" is the successful response of "+o.name+".") +
+		"type " + o.response + " struct {\n" +
+		"\t// StatusCode is the HTTP response status code\n\tStatusCode int\n" +
+		"\t// Header contains the response headers\n\tHeader http.Header\n")
+
+	for _, r := range responses {
+		if !r.isSuccess() || r.field == "" {
+			continue
+		}
+		if len(cases) == 0 {
+			b.WriteString("\n")
+		}
+
+		b.WriteString(indent(comment(responseFieldDoc(r))) +
+			"\t" + r.field + " " + r.goType + "\n")
+		cases = append(cases, branch{
+			cond: strings.Replace(r.condition, "resp.StatusCode", "statusCode", 1),
+			body: unmarshalCode(r, "r."+r.field, "body", "\t\treturn "),
+		})
+	}
+	b.WriteString("}\n")
+
+	b.WriteString(`
+// Verify ` + o.response + ` implements client.Unmarshaler interface
+var _ client.Unmarshaler = &` + o.response + `{}
+
+// Name returns the identifier of the response.
+func (_ *` + o.response + `) Name() string {
+	return ` + strconv.Quote(g.cfg.Package+"."+o.response) + `
+}
+
+// Unmarshal parses the body of the successful responses, the other ones are
+// parsed by the ` + o.error + `. An empty body is not parsed.
+func (r *` + o.response + `) Unmarshal(
+	statusCode int, header http.Header, body []byte,
+) error {
+	r.StatusCode, r.Header = statusCode, header
+`)
+	if len(cases) > 0 {
+		b.WriteString("\n\tif len(body) == 0 {\n\t\treturn nil\n\t}\n\n" +
+			switchCode(cases))
+	}
+	b.WriteString("\n\treturn nil\n}\n")
+
+	return b.String()
+}
+
+// errorDecl returns the error type of the operation and its constructor
+// parsing the documented error bodies.
+func (g *generator) errorDecl(o *operation, responses []response) string {
+	var (
+		b     strings.Builder
+		cases []branch
+	)
+	constructor := "new" + o.error
+
+	b.WriteString(comment(o.error+" is returned by "+o.name+" when the server "+
+		"answers with a status code other than the documented successful "+
+		"ones, it wraps ErrUnexpectedStatus.") +
+		"type " + o.error + " struct {\n" +
+		"\t// StatusCode is the HTTP response status code\n\tStatusCode int\n" +
+		"\t// Header contains the response headers\n\tHeader http.Header\n" +
+		"\t// Body contains the raw response body\n\tBody []byte\n")
+
+	hasBody := false
+	for _, r := range responses {
+		if r.isSuccess() {
+			continue
+		}
+
+		c := branch{cond: r.condition}
+		if r.code == "default" {
+			c.cond = ""
+		}
+		if r.field == "" {
+			cases = append(cases, c)
+			continue
+		}
+
+		if !hasBody {
+			b.WriteString("\n")
+			hasBody = true
+		}
+		b.WriteString(indent(comment(responseFieldDoc(r)+
+			" It is nil if the body cannot be parsed.")) +
+			"\t" + r.field + " " + r.goType + "\n")
+
+		field := "e." + r.field
+		switch {
+		case r.media == "raw":
+			c.body = "\t\t" + field + " = resp.Body\n"
+		case strings.HasPrefix(r.goType, "*"):
+			c.body = "\t\t" + field + " = new(" + r.goType[1:] + ")\n" +
+				"\t\tif json.Unmarshal(resp.Body, " + field + ") != nil {\n" +
+				"\t\t\t" + field + " = nil\n\t\t}\n"
+		default:
+			c.body = "\t\tif json.Unmarshal(resp.Body, &" + field + ") != nil {\n" +
+				"\t\t\t" + field + " = nil\n\t\t}\n"
+		}
+		cases = append(cases, c)
+	}
+	b.WriteString("}\n")
+
+	b.WriteString(`
+// Error returns the operation and the status code.
+func (e *` + o.error + `) Error() string {
+	return ` + strconv.Quote(o.name+": ") + ` + ErrUnexpectedStatus.Error() + " " +
+		strconv.Itoa(e.StatusCode)
+}
+
+// Unwrap returns ErrUnexpectedStatus.
+func (e *` + o.error + `) Unwrap() error {
+	return ErrUnexpectedStatus
+}
+
+` + comment(constructor+" returns the "+o.error+" of the response, with its documented body parsed.") +
+		`func ` + constructor + `(resp *client.Response) *` + o.error + ` {
+	e := &` + o.error + `{StatusCode: resp.StatusCode, Header: resp.Header, Body: resp.Body}
+`)
+	if hasBody {
+		b.WriteString("\n" + switchCode(cases))
+	}
+	b.WriteString("\n\treturn e\n}\n")
+
+	return b.String()
+}
+
+// branch is a case of the generated code, `body` being indented for
+// a switch. The default case has no condition.
+type branch struct {
+	cond string
+	body string
+}
+
+// switchCode returns the switch statement of the branches, a single one
+// is written as an if statement, or as its bare body for a default.
+func switchCode(branches []branch) string {
+	if len(branches) == 1 {
+		c := branches[0]
+		if c.cond == "" {
+			return strings.ReplaceAll("\n"+c.body, "\n\t", "\n")[1:]
+		}
+		return "\tif " + c.cond + " {\n" + c.body + "\t}\n"
+	}
+
+	var b strings.Builder
+	b.WriteString("\tswitch {\n")
+	for _, c := range branches {
+		if c.cond == "" {
+			b.WriteString("\tdefault:\n")
+		} else {
+			b.WriteString("\tcase " + c.cond + ":\n")
+		}
+		b.WriteString(c.body)
+	}
+	b.WriteString("\t}\n")
+
+	return b.String()
+}
+
+// responseFieldDoc returns the documentation of the field of a response
+// body.
+func responseFieldDoc(r response) string {
+	doc := r.field + " is the body of the " + r.code + " response"
+	if r.code == "default" {
+		doc = r.field + " is the body of the default response"
+	}
+	if r.description != "" {
+		doc += ": " + r.description
+	}
+
+	return sentence(doc)
+}
+
+// unmarshalCode returns the code parsing the body `src` of the response
+// into `dst`, the last statement is prefixed by `ret`.
+func unmarshalCode(r response, dst, src, ret string) string {
+	switch {
+	case r.media == "raw":
+		return "\t\t" + dst + " = " + src + "\n" + ret + "nil\n"
+	case strings.HasPrefix(r.goType, "*"):
+		return "\t\t" + dst + " = new(" + r.goType[1:] + ")\n" +
+			ret + "json.Unmarshal(" + src + ", " + dst + ")\n"
+	}
+
+	return ret + "json.Unmarshal(" + src + ", &" + dst + ")\n"
+}
+
+// selectMedia returns the content type used by the generated code, JSON
+// first, then form and the first other one.
+func selectMedia(content map[string]*MediaType) (string, *MediaType) {
+	types := slices.Sorted(maps.Keys(content))
+	for _, t := range types {
+		if isJSON(t) {
+			return t, mediaOrEmpty(content[t])
+		}
+	}
+	for _, t := range types {
+		if t == "application/x-www-form-urlencoded" {
+			return t, mediaOrEmpty(content[t])
+		}
+	}
+	if len(types) > 0 {
+		return types[0], mediaOrEmpty(content[types[0]])
+	}
+
+	return "", nil
+}
+
+// mediaOrEmpty returns the media type, or an empty one if nil.
+func mediaOrEmpty(m *MediaType) *MediaType {
+	if m == nil {
+		return &MediaType{}
+	}
+
+	return m
+}
+
+// isJSON reports if the content type is JSON, such as "application/json"
+// or "application/problem+json".
+func isJSON(contentType string) bool {
+	mediaType, _, _ := strings.Cut(contentType, ";")
+	mediaType = strings.TrimSpace(strings.ToLower(mediaType))
+
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,313 @@
+/*
+ * Copyright 2025 Adrien Kara
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU General Public License as published by
+ * the Free Software Foundation, either version 3 of the License, or
+ * (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+ * GNU General Public License for more details.
+ *
+ * You should have received a copy of the GNU General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ *
+ * SPDX-License-Identifier: GPL-3.0-or-later
+ */
+
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// defineSchema defines the Go type `name` of a component schema.
+func (g *generator) defineSchema(s *Schema, name, doc string) error {
+	switch {
+	case s.Ref == "" && isStruct(s):
+		return g.defineStruct(s, name, doc)
+	case s.Ref == "" && isEnum(s):
+		return g.defineEnum(s, name, doc)
+	}
+
+	t, err := g.goType(s, name+"Value", "")
+	if err != nil {
+		return err
+	}
+	if s.Description != "" {
+		doc += "\n\n" + s.Description
+	}
+	g.decls = append(g.decls, comment(doc)+"type "+name+" "+t+"\n")
+
+	return nil
+}
+
+// goType returns the Go type of the schema. The inline objects and
+// enumerations are defined as named types, the `hint` name is reserved
+// for them and `doc` is their documentation.
+func (g *generator) goType(s *Schema, hint, doc string) (string, error) {
+	if s == nil {
+		return "any", nil
+	}
+	if s.Ref != "" {
+		return g.schemaRef(s.Ref)
+	}
+
+	switch {
+	case len(s.AllOf) == 1 && len(s.Properties) == 0:
+		return g.goType(s.AllOf[0], hint, doc)
+	case isStruct(s):
+		name := g.names.unique(hint)
+		return name, g.defineStruct(s, name, doc)
+	case isEnum(s):
+		name := g.names.unique(hint)
+		return name, g.defineEnum(s, name, doc)
+	case len(s.OneOf) > 0 || len(s.AnyOf) > 0:
+		// The alternatives are left to the caller
+		g.imports["encoding/json"] = true
+		return "json.RawMessage", nil
+	}
+
+	switch s.Type.Name() {
+	case "string":
+		switch s.Format {
+		case "date-time":
+			g.imports["time"] = true
+			return "time.Time", nil
+		case "byte":
+			return "[]byte", nil
+		}
+		return "string", nil
+
+	case "integer":
+		if s.Format == "int32" {
+			return "int32", nil
+		}
+		return "int64", nil
+
+	case "number":
+		if s.Format == "float" {
+			return "float32", nil
+		}
+		return "float64", nil
+
+	case "boolean":
+		return "bool", nil
+
+	case "array":
+		item, err := g.goType(s.Items, hint+"Item", hint+"Item is an item of "+hint+".")
+		if err != nil {
+			return "", err
+		}
+		return "[]" + item, nil
+
+	case "object", "":
+		if s.AdditionalProperties.Schema != nil {
+			value, err := g.goType(s.AdditionalProperties.Schema,
+				hint+"Value", hint+"Value is a value of "+hint+".")
+			if err != nil {
+				return "", err
+			}
+			return "map[string]" + value, nil
+		}
+		if s.Type.Name() == "object" {
+			return "map[string]any", nil
+		}
+		return "any", nil
+	}
+
+	return "", errors.Join(ErrUnsupportedSchema,
+		errors.New("unknown type "+s.Type.Name()+" for "+hint))
+}
+
+// schemaRef returns the Go type of a schema reference.
+func (g *generator) schemaRef(ref string) (string, error) {
+	name, err := componentName(ref, "schemas")
+	if err != nil {
+		return "", err
+	}
+
+	t, found := g.schemas[name]
+	if !found {
+		return "", errors.Join(ErrInvalidReference, errors.New("unknown schema "+ref))
+	}
+
+	return t, nil
+}
+
+// defineStruct defines the struct `name` with the properties of the schema
+// and of its allOf parts, the referenced parts are embedded.
+func (g *generator) defineStruct(s *Schema, name, doc string) error {
+	// The slot keeps the struct before the types of its properties
+	slot := len(g.decls)
+	g.decls = append(g.decls, "")
+
+	var b strings.Builder
+	if s.Description != "" {
+		doc += "\n\n" + s.Description
+	}
+	if s.Deprecated {
+		doc += "\n\nDeprecated: the schema is deprecated by the API."
+	}
+	b.WriteString(comment(doc) + "type " + name + " struct {\n")
+
+	fields := make(names)
+	properties := maps.Clone(s.Properties)
+	required := slices.Clone(s.Required)
+	for _, part := range s.AllOf {
+		if part.Ref != "" {
+			t, err := g.schemaRef(part.Ref)
+			if err != nil {
+				return err
+			}
+			fields[t] = true
+			b.WriteString("\t" + t + "\n")
+			continue
+		}
+
+		if properties == nil {
+			properties = make(map[string]*Schema)
+		}
+		maps.Copy(properties, part.Properties)
+		required = append(required, part.Required...)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(properties)) {
+		property := properties[key]
+		field := fields.unique(goName(key))
+
+		t, err := g.goType(property, name+field,
+			name+field+" is the "+key+" property of "+name+".")
+		if err != nil {
+			return err
+		}
+
+		isRequired := slices.Contains(required, key)
+		tag := key
+		if !isRequired {
+			tag += ",omitempty"
+		}
+		if (!isRequired || isNullable(property)) && !g.nillable(t) {
+			t = "*" + t
+		}
+
+		var fieldDoc string
+		if property != nil && property.Description != "" {
+			fieldDoc = field + " " + property.Description
+		}
+		if property != nil && property.Deprecated {
+			fieldDoc += "\n\nDeprecated: the property is deprecated by the API."
+		}
+		if fieldDoc != "" {
+			b.WriteString(indent(comment(fieldDoc)))
+		}
+		b.WriteString("\t" + field + " " + t + " `json:" + strconv.Quote(tag) + "`\n")
+	}
+
+	b.WriteString("}\n")
+	g.decls[slot] = b.String()
+
+	return nil
+}
+
+// defineEnum defines the string type `name` with a constant per value.
+func (g *generator) defineEnum(s *Schema, name, doc string) error {
+	if s.Description != "" {
+		doc += "\n\n" + s.Description
+	}
+
+	g.enums[name] = true
+
+	var b strings.Builder
+	b.WriteString(comment(doc) + "type " + name + " string\n\n")
+	b.WriteString("// The " + name + " values.\nconst (\n")
+	for _, value := range s.Enum {
+		v, ok := value.(string)
+		if !ok {
+			if value == nil {
+				continue
+			}
+			return errors.Join(ErrUnsupportedSchema,
+				fmt.Errorf("non-string value %v in the %s enumeration", value, name))
+		}
+
+		constant := g.names.unique(name + goName(v))
+		b.WriteString("\t" + constant + " " + name + " = " + strconv.Quote(v) + "\n")
+	}
+	b.WriteString(")\n")
+
+	g.decls = append(g.decls, b.String())
+
+	return nil
+}
+
+// nillable reports if the Go type has a nil value, so it is not turned into
+// a pointer when optional.
+func (g *generator) nillable(t string) bool {
+	switch {
+	case strings.HasPrefix(t, "[]"), strings.HasPrefix(t, "map["),
+		strings.HasPrefix(t, "*"), t == "any", t == "json.RawMessage":
+		return true
+	}
+
+	// The component types defined from a slice or a map
+	s, found := g.components[t]
+	return found && g.nillableSchema(s, 0)
+}
+
+// nillableSchema reports if the Go type of the schema has a nil value.
+func (g *generator) nillableSchema(s *Schema, depth int) bool {
+	switch {
+	case s == nil:
+		return true
+	case depth >= maxReferences:
+		return false
+	case s.Ref != "":
+		t, err := g.schemaRef(s.Ref)
+		return err == nil && g.nillableSchema(g.components[t], depth+1)
+	case isStruct(s), isEnum(s):
+		return false
+	case len(s.AllOf) == 1:
+		return g.nillableSchema(s.AllOf[0], depth+1)
+	case len(s.OneOf) > 0 || len(s.AnyOf) > 0:
+		return true
+	}
+
+	switch s.Type.Name() {
+	case "array", "object", "":
+		return true
+	case "string":
+		return s.Format == "byte"
+	}
+
+	return false
+}
+
+// isStruct reports if the schema is defined as a struct.
+func isStruct(s *Schema) bool {
+	return len(s.Properties) > 0 || len(s.AllOf) > 1 ||
+		len(s.AllOf) == 1 && len(s.Properties) > 0
+}
+
+// isEnum reports if the schema is defined as a string enumeration.
+func isEnum(s *Schema) bool {
+	return len(s.Enum) > 0 && s.Type.Name() == "string"
+}
+
+// isNullable reports if the schema accepts null, OpenAPI 3.0 "nullable" or
+// OpenAPI 3.1 "null" type.
+func isNullable(s *Schema) bool {
+	return s != nil && (s.Nullable || s.Type.Is("null"))
+}
+
+// indent prefixes the lines with a tab.
+func indent(text string) string {
+	return "\t" + strings.ReplaceAll(strings.TrimSuffix(text, "\n"), "\n", "\n\t") + "\n"
+}
@@ -0,0 +1,315 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var (
+	// ErrInvalidTemplate is returned when a URI template cannot be parsed
+	ErrInvalidTemplate = errors.New("invalid URI template")
+)
+
+// templateOperator describes the expansion of an expression operator.
+// RFC 6570 Appendix A: https://www.rfc-editor.org/rfc/rfc6570#appendix-A
+type templateOperator struct {
+	first    string
+	sep      string
+	named    bool
+	ifEmpty  string
+	reserved bool
+}
+
+var templateOperators = map[byte]templateOperator{
+	0:   {first: "", sep: ","},
+	'+': {first: "", sep: ",", reserved: true},
+	'#': {first: "#", sep: ",", reserved: true},
+	'.': {first: ".", sep: "."},
+	'/': {first: "/", sep: "/"},
+	';': {first: ";", sep: ";", named: true},
+	'?': {first: "?", sep: "&", named: true, ifEmpty: "="},
+	'&': {first: "&", sep: "&", named: true, ifEmpty: "="},
+}
+
+// ExpandTemplate expands an RFC 6570 URI template, up to level 4, with the
+// given variables. The values can be strings, []string for lists,
+// map[string]string for associative arrays, or any other type formatted
+// with fmt.Sprint. Missing and nil variables are undefined.
+//
+// Values are percent-encoded, so an identifier such as "a/b?c" expands to
+// "a%2Fb%3Fc" in a path segment. Only the reserved expansions ("{+var}" and
+// "{#var}") keep the reserved characters.
+//
+// Example:
+//
+//	ExpandTemplate("/repos/{owner}/{repo}/issues{?state,labels}",
+//	    map[string]any{"owner": "iglou", "repo": "goulc",
+//	        "state": "open", "labels": []string{"bug", "ui"}})
+//	// "/repos/iglou/goulc/issues?state=open&labels=bug,ui"
+//
+// RFC 6570: https://www.rfc-editor.org/rfc/rfc6570
+func ExpandTemplate(template string, vars map[string]any) (string, error) {
+	var b strings.Builder
+	b.Grow(len(template))
+
+	for template != "" {
+		start := strings.IndexByte(template, '{')
+		if start < 0 {
+			if strings.IndexByte(template, '}') >= 0 {
+				return "", errors.Join(ErrInvalidTemplate,
+					errors.New("unexpected '}'"))
+			}
+			b.WriteString(templateEncode(template, true))
+			break
+		}
+
+		literal := template[:start]
+		if strings.IndexByte(literal, '}') >= 0 {
+			return "", errors.Join(ErrInvalidTemplate,
+				errors.New("unexpected '}'"))
+		}
+		b.WriteString(templateEncode(literal, true))
+
+		end := strings.IndexByte(template[start:], '}')
+		if end < 0 {
+			return "", errors.Join(ErrInvalidTemplate,
+				errors.New("unclosed expression "+template[start:]))
+		}
+
+		if err := expandExpression(&b, template[start+1:start+end], vars); err != nil {
+			return "", err
+		}
+		template = template[start+end+1:]
+	}
+
+	return b.String(), nil
+}
+
+// expandExpression expands the content of a single "{...}" expression.
+func expandExpression(b *strings.Builder, expr string, vars map[string]any) error {
+	if expr == "" {
+		return errors.Join(ErrInvalidTemplate, errors.New("empty expression"))
+	}
+
+	var opChar byte
+	if _, ok := templateOperators[expr[0]]; ok && expr[0] != 0 {
+		opChar = expr[0]
+		expr = expr[1:]
+	} else if strings.ContainsRune("=,!@|", rune(expr[0])) {
+		return errors.Join(ErrInvalidTemplate,
+			errors.New("reserved operator "+expr[:1]))
+	}
+	op := templateOperators[opChar]
+
+	first := true
+	for varspec := range strings.SplitSeq(expr, ",") {
+		name, prefix, explode, err := parseVarspec(varspec)
+		if err != nil {
+			return err
+		}
+
+		value, defined := templateValue(vars[name])
+		if !defined {
+			continue
+		}
+
+		if first {
+			b.WriteString(op.first)
+			first = false
+		} else {
+			b.WriteString(op.sep)
+		}
+
+		if err := expandValue(b, op, name, value, prefix, explode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseVarspec parses a "name", "name:prefix" or "name*" variable.
+func parseVarspec(varspec string) (string, int, bool, error) {
+	name, prefix, explode := varspec, 0, false
+
+	if n, found := strings.CutSuffix(varspec, "*"); found {
+		name, explode = n, true
+	} else if n, length, found := strings.Cut(varspec, ":"); found {
+		var err error
+		prefix, err = strconv.Atoi(length)
+		if err != nil || prefix <= 0 || prefix >= 10000 {
+			return "", 0, false, errors.Join(ErrInvalidTemplate,
+				errors.New("invalid prefix "+varspec))
+		}
+		name = n
+	}
+
+	if name == "" {
+		return "", 0, false, errors.Join(ErrInvalidTemplate,
+			errors.New("empty variable name"))
+	}
+
+	for i := range len(name) {
+		c := name[i]
+		if !isAlphaNum(c) && c != '_' && c != '.' && c != '%' {
+			return "", 0, false, errors.Join(ErrInvalidTemplate,
+				errors.New("invalid variable name "+name))
+		}
+	}
+
+	return name, prefix, explode, nil
+}
+
+// templateValue normalizes a variable, it reports if it is defined.
+// Empty lists and associative arrays are undefined.
+func templateValue(v any) (any, bool) {
+	switch value := v.(type) {
+	case nil:
+		return nil, false
+	case string:
+		return value, true
+	case []string:
+		return value, len(value) > 0
+	case map[string]string:
+		return value, len(value) > 0
+	default:
+		return fmt.Sprint(value), true
+	}
+}
+
+// expandValue writes a defined variable.
+func expandValue(
+	b *strings.Builder, op templateOperator,
+	name string, value any, prefix int, explode bool,
+) error {
+	switch value := value.(type) {
+	case string:
+		if op.named {
+			b.WriteString(name)
+			if value == "" {
+				b.WriteString(op.ifEmpty)
+				return nil
+			}
+			b.WriteByte('=')
+		}
+		if prefix > 0 && utf8.RuneCountInString(value) > prefix {
+			value = string([]rune(value)[:prefix])
+		}
+		b.WriteString(templateEncode(value, op.reserved))
+
+	case []string:
+		if prefix > 0 {
+			return errors.Join(ErrInvalidTemplate,
+				errors.New("prefix applied to the list "+name))
+		}
+
+		if !explode {
+			if op.named {
+				b.WriteString(name + "=")
+			}
+			for i, item := range value {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				b.WriteString(templateEncode(item, op.reserved))
+			}
+			return nil
+		}
+
+		for i, item := range value {
+			if i > 0 {
+				b.WriteString(op.sep)
+			}
+			if op.named {
+				b.WriteString(name)
+				if item == "" {
+					b.WriteString(op.ifEmpty)
+					continue
+				}
+				b.WriteByte('=')
+			}
+			b.WriteString(templateEncode(item, op.reserved))
+		}
+
+	case map[string]string:
+		if prefix > 0 {
+			return errors.Join(ErrInvalidTemplate,
+				errors.New("prefix applied to the associative array "+name))
+		}
+
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		if !explode {
+			if op.named {
+				b.WriteString(name + "=")
+			}
+			for i, key := range keys {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				b.WriteString(templateEncode(key, op.reserved) + "," +
+					templateEncode(value[key], op.reserved))
+			}
+			return nil
+		}
+
+		for i, key := range keys {
+			if i > 0 {
+				b.WriteString(op.sep)
+			}
+			b.WriteString(templateEncode(key, op.reserved))
+			if op.named && value[key] == "" {
+				b.WriteString(op.ifEmpty)
+				continue
+			}
+			b.WriteString("=" + templateEncode(value[key], op.reserved))
+		}
+	}
+
+	return nil
+}
+
+// templateEncode percent-encodes the characters not allowed in the
+// expansion. The reserved characters and the percent-encoded triplets are
+// kept when `reserved` is set.
+func templateEncode(s string, reserved bool) string {
+	const upperhex = "0123456789ABCDEF"
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case isAlphaNum(c) || strings.IndexByte("-._~", c) >= 0:
+			b.WriteByte(c)
+		case reserved && strings.IndexByte(":/?#[]@!$&'()*+,;=", c) >= 0:
+			b.WriteByte(c)
+		case reserved && c == '%' && i+2 < len(s) &&
+			isHex(s[i+1]) && isHex(s[i+2]):
+			b.WriteString(s[i : i+3])
+			i += 2
+		default:
+			b.WriteByte('%')
+			b.WriteByte(upperhex[c>>4])
+			b.WriteByte(upperhex[c&0x0F])
+		}
+	}
+
+	return b.String()
+}
+
+func isAlphaNum(c byte) bool {
+	return 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9'
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'A' <= c && c <= 'F' || 'a' <= c && c <= 'f'
+}
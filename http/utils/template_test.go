@@ -0,0 +1,153 @@
+package utils_test
+
+import (
+	"errors"
+	"testing"
+
+	"gitlab.com/iglou.eu/goulc/http/utils"
+)
+
+// The test cases are the examples of RFC 6570 §3.2, associative arrays
+// being expanded in key order.
+func TestExpandTemplate(t *testing.T) {
+	vars := map[string]any{
+		"count":      []string{"one", "two", "three"},
+		"dom":        []string{"example", "com"},
+		"dub":        "me/too",
+		"hello":      "Hello World!",
+		"half":       "50%",
+		"var":        "value",
+		"who":        "fred",
+		"base":       "http://example.com/home/",
+		"path":       "/foo/bar",
+		"list":       []string{"red", "green", "blue"},
+		"keys":       map[string]string{"semi": ";", "dot": ".", "comma": ","},
+		"v":          "6",
+		"x":          1024,
+		"y":          768,
+		"empty":      "",
+		"empty_keys": map[string]string{},
+		"undef":      nil,
+	}
+
+	tests := map[string]string{
+		// Level 1 and 2
+		"{var}":                "value",
+		"{hello}":              "Hello%20World%21",
+		"{half}":               "50%25",
+		"O{empty}X":            "OX",
+		"O{undef}X":            "OX",
+		"{+var}":               "value",
+		"{+hello}":             "Hello%20World!",
+		"{+half}":              "50%25",
+		"{base}index":          "http%3A%2F%2Fexample.com%2Fhome%2Findex",
+		"{+base}index":         "http://example.com/home/index",
+		"O{+empty}X":           "OX",
+		"{+path}/here":         "/foo/bar/here",
+		"here?ref={+path}":     "here?ref=/foo/bar",
+		"up{+path}{var}/here":  "up/foo/barvalue/here",
+		"X{#var}":              "X#value",
+		"X{#hello}":            "X#Hello%20World!",
+		"foo{#empty}":          "foo#",
+		"foo{#undef}":          "foo",
+		"map?{x,y}":            "map?1024,768",
+		"{x,hello,y}":          "1024,Hello%20World%21,768",
+		"?{x,empty}":           "?1024,",
+		"?{x,undef}":           "?1024",
+		"?{undef,y}":           "?768",
+		"{var:3}":              "val",
+		"{var:30}":             "value",
+		"{list}":               "red,green,blue",
+		"{list*}":              "red,green,blue",
+		"{keys}":               "comma,%2C,dot,.,semi,%3B",
+		"{keys*}":              "comma=%2C,dot=.,semi=%3B",
+		"{+x,hello,y}":         "1024,Hello%20World!,768",
+		"{+path,x}/here":       "/foo/bar,1024/here",
+		"{+path:6}/here":       "/foo/b/here",
+		"{+list}":              "red,green,blue",
+		"{+keys}":              "comma,,,dot,.,semi,;",
+		"{+keys*}":             "comma=,,dot=.,semi=;",
+		"{#x,hello,y}":         "#1024,Hello%20World!,768",
+		"{#path,x}/here":       "#/foo/bar,1024/here",
+		"{#path:6}/here":       "#/foo/b/here",
+		"{#list*}":             "#red,green,blue",
+		"{.who}":               ".fred",
+		"{.who,who}":           ".fred.fred",
+		"{.half,who}":          ".50%25.fred",
+		"www{.dom*}":           "www.example.com",
+		"X{.var}":              "X.value",
+		"X{.empty}":            "X.",
+		"X{.undef}":            "X",
+		"X{.var:3}":            "X.val",
+		"X{.list}":             "X.red,green,blue",
+		"X{.list*}":            "X.red.green.blue",
+		"X{.keys*}":            "X.comma=%2C.dot=..semi=%3B",
+		"X{.empty_keys}":       "X",
+		"{/who}":               "/fred",
+		"{/who,who}":           "/fred/fred",
+		"{/half,who}":          "/50%25/fred",
+		"{/who,dub}":           "/fred/me%2Ftoo",
+		"{/var,empty}":         "/value/",
+		"{/var,undef}":         "/value",
+		"{/var,x}/here":        "/value/1024/here",
+		"{/var:1,var}":         "/v/value",
+		"{/list}":              "/red,green,blue",
+		"{/list*}":             "/red/green/blue",
+		"{/list*,path:4}":      "/red/green/blue/%2Ffoo",
+		"{/keys*}":             "/comma=%2C/dot=./semi=%3B",
+		"{;who}":               ";who=fred",
+		"{;half}":              ";half=50%25",
+		"{;empty}":             ";empty",
+		"{;v,empty,who}":       ";v=6;empty;who=fred",
+		"{;v,bar,who}":         ";v=6;who=fred",
+		"{;x,y,empty}":         ";x=1024;y=768;empty",
+		"{;hello:5}":           ";hello=Hello",
+		"{;list}":              ";list=red,green,blue",
+		"{;list*}":             ";list=red;list=green;list=blue",
+		"{;keys*}":             ";comma=%2C;dot=.;semi=%3B",
+		"{?who}":               "?who=fred",
+		"{?half}":              "?half=50%25",
+		"{?x,y,empty}":         "?x=1024&y=768&empty=",
+		"{?x,y,undef}":         "?x=1024&y=768",
+		"{?var:3}":             "?var=val",
+		"{?list}":              "?list=red,green,blue",
+		"{?list*}":             "?list=red&list=green&list=blue",
+		"{?keys}":              "?keys=comma,%2C,dot,.,semi,%3B",
+		"{?keys*}":             "?comma=%2C&dot=.&semi=%3B",
+		"{&who}":               "&who=fred",
+		"?fixed=yes{&x}":       "?fixed=yes&x=1024",
+		"{&x,y,empty}":         "&x=1024&y=768&empty=",
+		"{&list*}":             "&list=red&list=green&list=blue",
+		"/repos/{who}/{dub}":   "/repos/fred/me%2Ftoo",
+		"/a b/{var}{?undef}":   "/a%20b/value",
+		"/users/{id}{?fields}": "/users/42%3Fadmin%3Dtrue",
+	}
+
+	vars["id"] = "42?admin=true"
+
+	for template, want := range tests {
+		t.Run(template, func(t *testing.T) {
+			got, err := utils.ExpandTemplate(template, vars)
+			if err != nil {
+				t.Fatalf("ExpandTemplate(%q) error = %v", template, err)
+			}
+			if got != want {
+				t.Errorf("ExpandTemplate(%q) = %q, want %q", template, got, want)
+			}
+		})
+	}
+}
+
+func TestExpandTemplate_Errors(t *testing.T) {
+	vars := map[string]any{"list": []string{"red"}}
+
+	for _, template := range []string{
+		"{", "}", "/users/{id", "/users/id}", "{}", "{=id}", "{list:3}", "{a b}", "{var:0}", "{,}",
+	} {
+		t.Run(template, func(t *testing.T) {
+			if _, err := utils.ExpandTemplate(template, vars); !errors.Is(err, utils.ErrInvalidTemplate) {
+				t.Errorf("ExpandTemplate(%q) error = %v, want %v", template, err, utils.ErrInvalidTemplate)
+			}
+		})
+	}
+}